@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type Config struct {
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Auth commands",
+	}
+
+	cmd.AddCommand(StatusCommand())
+	return cmd
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+type Status struct {
+	Profile string    `json:"profile"`
+	Source  string    `json:"source"`
+	Subject string    `json:"subject"`
+	Email   string    `json:"email"`
+	Groups  []string  `json:"groups"`
+	Expiry  time.Time `json:"expiry"`
+	Expired bool      `json:"expired"`
+}
+
+type StatusConfig struct {
+}
+
+func StatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"whoami"},
+		Short:   "Show the identity of the current token",
+		PreRun:  util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			return Whoami(ctx)
+		},
+	}
+	return cmd
+}
+
+// WhoamiCommand is the top level shortcut for auth status.
+func WhoamiCommand() *cobra.Command {
+	cmd := StatusCommand()
+	cmd.Use = "whoami"
+	cmd.Aliases = nil
+	return cmd
+}
+
+// NewStatus describes a token, raw tokens from the flag or environment are
+// decoded without verification when they are JWTs.
+func NewStatus(source string, raw string, token *oidc.Tokens[*oidc.IDTokenClaims]) *Status {
+	status := &Status{
+		Source: source,
+	}
+
+	var claims *oidc.IDTokenClaims
+	switch {
+	case len(raw) > 0:
+		parsed := &oidc.IDTokenClaims{}
+		if _, err := oidc.ParseToken(raw, parsed); err == nil {
+			claims = parsed
+		}
+	case token != nil:
+		claims = token.IDTokenClaims
+		if token.Token != nil {
+			status.Expiry = token.Expiry
+		}
+	}
+
+	if claims != nil {
+		status.Subject = claims.Subject
+		status.Email = claims.Email
+		if status.Expiry.IsZero() {
+			status.Expiry = claims.Expiration.AsTime()
+		}
+
+		switch groups := claims.Claims["groups"].(type) {
+		case []string:
+			status.Groups = groups
+		case []any:
+			for _, group := range groups {
+				status.Groups = append(status.Groups, fmt.Sprintf("%v", group))
+			}
+		}
+	}
+
+	if !status.Expiry.IsZero() {
+		status.Expired = status.Expiry.Before(time.Now())
+	}
+	return status
+}
+
+func Whoami(ctx context.Context) error {
+	cfg, err := config.New[StatusConfig, *Status](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	var raw string
+	token := cfg.Token
+	switch cfg.TokenSource() {
+	case config.TokenSourceEnv:
+		raw = cfg.Api.Token
+	case config.TokenSourceFlag:
+		raw = cfg.Global.Token
+	case config.TokenSourceClientCredentials:
+		// the stored token is the interactive login, the machine token is
+		// cached apart from it.
+		if token, err = cfg.ClientCredentialsToken(ctx); err != nil {
+			return err
+		}
+	case config.TokenSourceKeyring:
+	default:
+		cfg.WriteStderr("not logged in")
+		return config.ErrNoToken
+	}
+
+	status := NewStatus(cfg.TokenSource(), raw, token)
+	if cfg.TokenSource() == config.TokenSourceClientCredentials && len(status.Subject) == 0 {
+		status.Subject = cfg.Auth.ClientId
+	}
+	status.Profile = cfg.Profile()
+	if len(status.Profile) == 0 {
+		status.Profile = config.DefaultProfile
+	}
+
+	cfg.WriteObject(status)
+	return nil
+}
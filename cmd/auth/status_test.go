@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/oidctest"
+)
+
+func Test_NewStatus(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+
+	expired, err := issuer.NewTokens(time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		source  string
+		raw     string
+		subject string
+		expired bool
+	}{
+		{name: "keyring", source: config.TokenSourceKeyring, subject: issuer.Subject, expired: true},
+		{name: "jwt from env", source: config.TokenSourceEnv, raw: expired.IDToken, subject: issuer.Subject, expired: true},
+		{name: "api key from flag", source: config.TokenSourceFlag, raw: "not-a-jwt"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status := NewStatus(c.source, c.raw, expired)
+
+			if status.Source != c.source {
+				t.Fatalf("expected source %s, got %s", c.source, status.Source)
+			}
+			if status.Subject != c.subject {
+				t.Fatalf("expected subject %s, got %s", c.subject, status.Subject)
+			}
+			if status.Expired != c.expired {
+				t.Fatalf("expected expired %v, got %v", c.expired, status.Expired)
+			}
+			if len(c.subject) > 0 && (len(status.Groups) != 1 || status.Groups[0] != "developers") {
+				t.Fatalf("expected groups, got %v", status.Groups)
+			}
+		})
+	}
+}
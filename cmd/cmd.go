@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
+	"fmt"
 	"io"
 	"log"
 	"strings"
 
+	"github.com/getnoops/ops/cmd/auth"
 	"github.com/getnoops/ops/cmd/configs"
 	"github.com/getnoops/ops/cmd/containerrepository"
 	"github.com/getnoops/ops/cmd/deploy"
@@ -14,9 +17,15 @@ import (
 	"github.com/getnoops/ops/cmd/info"
 	"github.com/getnoops/ops/cmd/keys"
 	"github.com/getnoops/ops/cmd/login"
+	"github.com/getnoops/ops/cmd/logout"
+	"github.com/getnoops/ops/cmd/logs"
+	"github.com/getnoops/ops/cmd/metrics"
+	"github.com/getnoops/ops/cmd/mockserver"
 	"github.com/getnoops/ops/cmd/orgs"
+	"github.com/getnoops/ops/cmd/profile"
 	"github.com/getnoops/ops/cmd/secrets"
 	"github.com/getnoops/ops/cmd/settings"
+	"github.com/getnoops/ops/cmd/stack"
 	"github.com/getnoops/ops/cmd/this"
 	"github.com/getnoops/ops/cmd/upgrade"
 	"github.com/getnoops/ops/pkg/queries"
@@ -34,9 +43,41 @@ var (
 )
 
 func New(out io.Writer, in io.Reader, args []string) *cobra.Command {
+	// cancelTimeout releases the --timeout deadline once the command is done.
+	cancelTimeout := func() {}
+
 	cmd := &cobra.Command{
 		Use:   "ops",
 		Short: "The No_Ops cli used to manage deployments",
+		Long: `The No_Ops cli used to manage deployments.
+
+Exit codes:
+  0    success
+  1    error
+  3    not found
+  4    not logged in or the token was rejected
+  5    forbidden
+  6    validation failed
+  7    conflict
+  8    the api could not be reached or returned a server error
+  130  cancelled or timed out`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			timeout := viper.GetDuration("global.timeout")
+			if timeout < 0 {
+				return fmt.Errorf("invalid timeout %s", timeout)
+			}
+			if timeout == 0 {
+				return nil
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			cancelTimeout()
+		},
 		PreRun: func(cmd *cobra.Command, args []string) {
 			cmd.Flags().VisitAll(func(flag *pflag.Flag) {
 				viper.BindPFlag("command."+flag.Name, flag)
@@ -58,12 +99,21 @@ func New(out io.Writer, in io.Reader, args []string) *cobra.Command {
 	util.BindStringPersistentFlag(cmd, "organisation", "The organisation to use", "")
 	util.BindStringPersistentFlag(cmd, "token", "The token to use", "")
 	util.BindStringPersistentFlag(cmd, "format", "The format for printing output", "table")
+	util.BindStringPersistentFlag(cmd, "profile", "The profile to use", "")
+	util.BindBoolPersistentFlag(cmd, "verbose", "Log progress, such as retries and token refreshes", false)
+	util.BindBoolPersistentFlag(cmd, "debug", "Log every api request with its variables, secrets are masked", false)
+	util.BindDurationPersistentFlag(cmd, "timeout", "Cancel the command after this long, e.g. 5m, waits forever when 0", 0)
+	viper.BindEnv("global.profile", "NOOPS_PROFILE")
 
 	cmd.AddCommand(
 		info.New(),
 		login.New(),
+		logout.New(),
+		auth.New(),
+		auth.WhoamiCommand(),
 		upgrade.New(),
 		settings.New(),
+		profile.New(),
 		orgs.New(),
 		envs.New(),
 		configs.New("Compute", queries.ConfigClassCompute),
@@ -73,7 +123,11 @@ func New(out io.Writer, in io.Reader, args []string) *cobra.Command {
 		secrets.New(),
 		keys.New(),
 		deploy.New(),
+		logs.New(),
+		metrics.New(),
+		stack.New(),
 		this.New(),
+		mockserver.New(),
 	)
 	cmd.InitDefaultVersionFlag()
 	return cmd
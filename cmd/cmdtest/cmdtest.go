@@ -0,0 +1,54 @@
+package cmdtest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/getnoops/ops/cmd"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/spf13/viper"
+)
+
+// Result is the output of a command run.
+type Result struct {
+	Stdout string
+	Stderr string
+	Err    error
+}
+
+// Run executes the ops command with the args against the fake. The home path
+// is a temporary directory and the keyring is kept in memory, apart from any
+// other run.
+func Run(t *testing.T, fake *queriestest.Fake, args ...string) *Result {
+	t.Helper()
+
+	t.Setenv("NOOPS_HOME_PATH", t.TempDir())
+	t.Setenv("NOOPS_KEYRING_BACKENDS", string(config.MemoryBackend))
+
+	// commands bind their flags to the global viper.
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	ctx := context.Background()
+	ctx = queries.WithFactory(ctx, fake.Factory)
+	ctx = config.WithWriters(ctx, stdout, stderr)
+
+	root := cmd.New(stdout, os.Stdin, args)
+	root.SetArgs(args)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+
+	err := root.ExecuteContext(ctx)
+	return &Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Err:    err,
+	}
+}
@@ -2,7 +2,6 @@ package configs
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/getnoops/ops/pkg/config"
 	"github.com/getnoops/ops/pkg/queries"
@@ -44,8 +43,8 @@ func Get(ctx context.Context, classes []queries.ConfigClass, code string) error
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -56,11 +55,6 @@ func Get(ctx context.Context, classes []queries.ConfigClass, code string) error
 		return err
 	}
 
-	if config == nil {
-		cfg.WriteStderr(fmt.Sprintf("config '%v' was not found", code))
-		return nil
-	}
-
 	cfg.WriteObject(config)
 	return nil
 }
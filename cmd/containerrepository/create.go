@@ -6,12 +6,14 @@ import (
 	"github.com/getnoops/ops/pkg/config"
 	"github.com/getnoops/ops/pkg/queries"
 	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type CreateConfig struct {
+	waiter.Flags `mapstructure:",squash"`
 }
 
 func CreateCommand() *cobra.Command {
@@ -29,6 +31,8 @@ func CreateCommand() *cobra.Command {
 		},
 		ValidArgs: []string{"compute", "code"},
 	}
+
+	waiter.BindFlags(cmd, "Watch the container repository until it is created")
 	return cmd
 }
 
@@ -45,8 +49,8 @@ func Create(ctx context.Context, computeCode string, code string) error {
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -66,5 +70,15 @@ func Create(ctx context.Context, computeCode string, code string) error {
 	}
 
 	cfg.WriteObject(out)
+
+	if cfg.Command.Watch {
+		renderer := waiter.NewRenderer(cfg.Global.Format, cfg.Stdout())
+		defer renderer.Done()
+
+		if _, err := waiter.ContainerRepository(ctx, q, organisation.Id, config.Code, id, cfg.Command.Options(cfg.Wait, renderer)); err != nil {
+			cfg.WriteStderr("failed to watch container repository")
+			return err
+		}
+	}
 	return nil
 }
@@ -45,8 +45,8 @@ func Delete(ctx context.Context, computeCode string, code string) error {
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -52,8 +52,8 @@ func Get(ctx context.Context, computeCode string, code string) error {
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -93,5 +93,5 @@ func GetRepository(repositories []*queries.ContainerRepositoryItem, code string)
 		}
 	}
 
-	return nil, fmt.Errorf("repository not found")
+	return nil, fmt.Errorf("repository %s: %w", code, queries.ErrNotFound)
 }
@@ -2,17 +2,22 @@ package deploy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/diagnose"
 	"github.com/getnoops/ops/pkg/queries"
 	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type ApplyConfig struct {
+	waiter.Flags          `mapstructure:",squash"`
+	diagnose.FailureFlags `mapstructure:",squash"`
 }
 
 func ApplyCommand() *cobra.Command {
@@ -31,6 +36,9 @@ func ApplyCommand() *cobra.Command {
 		},
 		ValidArgs: []string{"env", "code", "version_number"},
 	}
+
+	waiter.BindFlags(cmd, "Watch deployment for success")
+	diagnose.BindFlags(cmd)
 	return cmd
 }
 
@@ -46,7 +54,7 @@ func GetEnvironment(ctx context.Context, q queries.Queries, organisation *querie
 		return nil, err
 	}
 	if len(paged.Items) == 0 {
-		return nil, fmt.Errorf("environment not found")
+		return nil, fmt.Errorf("environment %s: %w", code, queries.ErrNotFound)
 	}
 	return paged.Items[0], nil
 }
@@ -58,7 +66,7 @@ func GetConfigRevision(revisions []*queries.RevisionItem, versionNumber string)
 		}
 	}
 
-	return nil, fmt.Errorf("revision not found")
+	return nil, fmt.Errorf("revision %s: %w", versionNumber, queries.ErrNotFound)
 }
 
 func GetDeploymentId(ctx context.Context, config *queries.Config, environment *queries.Environment) uuid.UUID {
@@ -70,6 +78,16 @@ func GetDeploymentId(ctx context.Context, config *queries.Config, environment *q
 	return uuid.New()
 }
 
+// GetDeployment returns the deployment of the config to the environment.
+func GetDeployment(config *queries.Config, environment *queries.Environment) (*queries.Deployment, error) {
+	for _, deployment := range config.Deployments {
+		if deployment.Environment.Id == environment.Id {
+			return deployment, nil
+		}
+	}
+	return nil, fmt.Errorf("deployment of %s to %s: %w", config.Code, environment.Code, queries.ErrNotFound)
+}
+
 func Apply(ctx context.Context, env string, code string, versionNumber string) error {
 	cfg, err := config.New[ApplyConfig, *uuid.UUID](ctx, viper.GetViper())
 	if err != nil {
@@ -83,8 +101,8 @@ func Apply(ctx context.Context, env string, code string, versionNumber string) e
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -119,5 +137,27 @@ func Apply(ctx context.Context, env string, code string, versionNumber string) e
 	}
 
 	cfg.WriteObject(out)
+
+	if cfg.Command.Watch {
+		return WatchDeploymentRevision(ctx, cfg, q, organisation.Id, deploymentRevisionId, cfg.Command.Flags, cfg.Command.FailureFlags)
+	}
+	return nil
+}
+
+// WatchDeploymentRevision waits for the deployment revision to be created or
+// updated, when it fails a report of why is written.
+func WatchDeploymentRevision[C any, T any](ctx context.Context, cfg *config.NoOps[C, T], q queries.Queries, organisationId uuid.UUID, id uuid.UUID, flags waiter.Flags, failure diagnose.FailureFlags) error {
+	renderer := waiter.NewRenderer(cfg.Global.Format, cfg.Stdout())
+	defer renderer.Done()
+
+	if _, err := waiter.DeploymentRevision(ctx, q, organisationId, id, flags.Options(cfg.Wait, renderer)); err != nil {
+		if errors.Is(err, waiter.ErrFailed) {
+			renderer.Done()
+			report := diagnose.DeploymentRevision(ctx, q, organisationId, id, failure.FailureLogs)
+			report.Write(cfg.Stdout(), cfg.Global.Format)
+		}
+		cfg.WriteStderr("failed to watch deployment")
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,222 @@
+package deploy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/pkg/diagnose"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/getnoops/ops/pkg/waiter"
+	"github.com/google/uuid"
+)
+
+func Test_Apply(t *testing.T) {
+	cases := []struct {
+		name        string
+		args        []string
+		err         error
+		stderr      string
+		deployments int
+		setup       func(t *testing.T, fake *queriestest.Fake, org *queries.Organisation, env *queries.Environment, config *queries.Config)
+	}{
+		{
+			name:        "deploys revision",
+			args:        []string{"deploy", "apply", "dev", "api", "1.0.0"},
+			deployments: 1,
+		},
+		{
+			name:        "reuses deployment",
+			args:        []string{"deploy", "apply", "dev", "api", "1.0.0"},
+			deployments: 1,
+			setup: func(t *testing.T, fake *queriestest.Fake, org *queries.Organisation, env *queries.Environment, config *queries.Config) {
+				if _, err := fake.NewDeployment(context.Background(), org.Id, uuid.New(), env.Id, config.Id, config.Revisions[0].Id, uuid.New()); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "unknown config",
+			args: []string{"deploy", "apply", "dev", "web", "1.0.0"},
+			err:  queries.ErrNotFound,
+		},
+		{
+			name:   "unknown environment",
+			args:   []string{"deploy", "apply", "prod", "api", "1.0.0"},
+			err:    queries.ErrNotFound,
+			stderr: "environment not found for config",
+		},
+		{
+			name:   "unknown revision",
+			args:   []string{"deploy", "apply", "dev", "api", "2.0.0"},
+			err:    queries.ErrNotFound,
+			stderr: "revision not found with version number 2.0.0",
+		},
+		{
+			name:   "api error",
+			args:   []string{"deploy", "apply", "dev", "api", "1.0.0"},
+			err:    queries.ErrForbidden,
+			stderr: "failed to deploy",
+			setup: func(t *testing.T, fake *queriestest.Fake, org *queries.Organisation, env *queries.Environment, config *queries.Config) {
+				fake.Errors["NewDeployment"] = &queries.Error{Kind: queries.ErrForbidden}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			org := fake.AddOrganisation("noops", "NoOps")
+			env := fake.AddEnvironment(org.Id, "dev")
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			fake.AddRevision(config, "1.0.0")
+			if c.setup != nil {
+				c.setup(t, fake, org, env, config)
+			}
+
+			res := cmdtest.Run(t, fake, append(c.args, "--format", "json")...)
+			if c.err != nil {
+				if !errors.Is(res.Err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if !strings.Contains(res.Stderr, c.stderr) {
+					t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+
+			var deploymentId uuid.UUID
+			if err := json.Unmarshal([]byte(res.Stdout), &deploymentId); err != nil {
+				t.Fatalf("expected deployment id, got %q", res.Stdout)
+			}
+			if len(config.Deployments) != c.deployments {
+				t.Fatalf("expected %d deployments, got %d", c.deployments, len(config.Deployments))
+			}
+			if config.Deployments[0].Id != deploymentId {
+				t.Fatalf("expected deployment %s, got %s", config.Deployments[0].Id, deploymentId)
+			}
+			if config.Deployments[0].Environment.Code != "dev" {
+				t.Fatalf("expected deployment to dev, got %s", config.Deployments[0].Environment.Code)
+			}
+		})
+	}
+}
+
+func Test_Apply_Watch(t *testing.T) {
+	cases := []struct {
+		name  string
+		state queries.StackState
+		err   error
+		// polls is the number of events, any number for a timeout.
+		polls int
+		// report is set when a failure report follows the events.
+		report bool
+	}{
+		{name: "created", state: queries.StackStateCreated, polls: 1},
+		{name: "failed", state: queries.StackStateFailed, err: waiter.ErrFailed, polls: 1, report: true},
+		{name: "wait timeout", state: queries.StackStateCancelling, err: context.DeadlineExceeded},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			fake.DeploymentState = c.state
+			org := fake.AddOrganisation("noops", "NoOps")
+			fake.AddEnvironment(org.Id, "dev")
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			fake.AddRevision(config, "1.0.0")
+
+			res := cmdtest.Run(t, fake, "deploy", "apply", "dev", "api", "1.0.0", "--format", "json", "--watch", "--wait-interval", "10ms", "--wait-timeout", "15ms")
+			if c.err == nil && res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if c.err != nil && !errors.Is(res.Err, c.err) {
+				t.Fatalf("expected %v, got %v", c.err, res.Err)
+			}
+
+			// the deployment id is followed by a line for each poll.
+			lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+			if c.report {
+				report := diagnose.Report{}
+				if err := json.Unmarshal([]byte(lines[len(lines)-1]), &report); err != nil || report.State != c.state {
+					t.Fatalf("expected a failure report, got %q", lines[len(lines)-1])
+				}
+				lines = lines[:len(lines)-1]
+			}
+			if len(lines) < 2 || (c.polls > 0 && len(lines)-1 != c.polls) {
+				t.Fatalf("expected %d events, got %q", c.polls, res.Stdout)
+			}
+			for _, line := range lines[1:] {
+				event := struct {
+					Resource string `json:"resource"`
+					State    string `json:"state"`
+				}{}
+				if err := json.Unmarshal([]byte(line), &event); err != nil {
+					t.Fatalf("expected a json event, got %q", line)
+				}
+				if event.Resource != "Deployment" || event.State != string(c.state) {
+					t.Fatalf("expected the deployment %s, got %s %s", c.state, event.Resource, event.State)
+				}
+			}
+		})
+	}
+}
+
+func Test_Apply_Watch_Report(t *testing.T) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	env := fake.AddEnvironment(org.Id, "dev")
+	config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+	revision := fake.AddRevision(config, "1.0.0")
+
+	// the failed revision updates the existing deployment.
+	deploymentId := uuid.New()
+	if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	fake.SetStack(deploymentId, &queries.StackDetails{
+		Status:        "UPDATE_ROLLBACK_COMPLETE",
+		Status_reason: "The following resource(s) failed to update: [Service]",
+		Resources: []*queries.StackResource{
+			{Logical_resource_id: "Queue", Resource_type: "AWS::SQS::Queue", Resource_status: "UPDATE_FAILED", Resource_status_reason: "Resource update cancelled", Timestamp: now},
+			{Logical_resource_id: "Service", Resource_type: "AWS::ECS::Service", Resource_status: "UPDATE_FAILED", Resource_status_reason: "Circuit breaker triggered", Timestamp: now.Add(-time.Second)},
+			{Logical_resource_id: "TaskDefinition", Resource_type: "AWS::ECS::TaskDefinition", Resource_status: "UPDATE_COMPLETE", Timestamp: now.Add(-time.Minute)},
+		},
+	})
+	fake.AddLogs(deploymentId, "/noops/api/dev",
+		&queries.Log{Event_id: "1", Log_stream_name: "api/1", Message: "starting", Timestamp: now.Add(-2 * time.Hour).UnixMilli()},
+		&queries.Log{Event_id: "2", Log_stream_name: "api/1", Message: "connecting to db", Timestamp: now.Add(-3 * time.Second).UnixMilli()},
+		&queries.Log{Event_id: "3", Log_stream_name: "api/1", Message: "panic: connection refused\n", Timestamp: now.Add(-2 * time.Second).UnixMilli()},
+	)
+	fake.DeploymentState = queries.StackStateFailed
+
+	res := cmdtest.Run(t, fake, "deploy", "apply", "dev", "api", "1.0.0", "--watch", "--wait-interval", "10ms", "--failure-logs", "1")
+	if !errors.Is(res.Err, waiter.ErrFailed) {
+		t.Fatalf("expected %v, got %v", waiter.ErrFailed, res.Err)
+	}
+
+	// the first failure is the cause, only the last log lines are shown.
+	for _, expected := range []string{
+		"Deployment of api to dev failed",
+		"Stack noops-api-dev in ap-southeast-2: UPDATE_ROLLBACK_COMPLETE",
+		"Service (AWS::ECS::Service) UPDATE_FAILED: Circuit breaker triggered",
+		"Last log lines of /noops/api/dev:",
+		"api/1 panic: connection refused",
+	} {
+		if !strings.Contains(res.Stdout, expected) {
+			t.Fatalf("expected %q in %q", expected, res.Stdout)
+		}
+	}
+	if strings.Index(res.Stdout, "Service (") > strings.Index(res.Stdout, "Queue (") || strings.Contains(res.Stdout, "TaskDefinition") || strings.Contains(res.Stdout, "connecting to db") {
+		t.Fatalf("unexpected report %q", res.Stdout)
+	}
+}
@@ -0,0 +1,124 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type CancelConfig struct {
+	waiter.Flags `mapstructure:",squash"`
+}
+
+func CancelCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel [config] [env]",
+		Short: "Cancel the deployment of a config to an environment which is in progress",
+		Long: `Cancels the deployment of the config to the environment while it is being
+created or updated, CloudFormation rolls back the changes made so far.
+
+With --watch the command waits until the deployment settles and reports the
+state it was left in.`,
+		Args:   cobra.ExactArgs(2),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configCode := args[0]
+			environmentCode := args[1]
+
+			ctx := cmd.Context()
+			return Cancel(ctx, configCode, environmentCode)
+		},
+		ValidArgs: []string{"config", "env"},
+	}
+
+	waiter.BindFlags(cmd, "Watch deployment until it settles")
+	return cmd
+}
+
+// InProgress is true for the states a deployment can be cancelled in.
+func InProgress(state queries.StackState) bool {
+	switch state {
+	case queries.StackStateNew, queries.StackStateCreating, queries.StackStateUpdating, queries.StackStateCancelling:
+		return true
+	}
+	return false
+}
+
+func Cancel(ctx context.Context, configCode string, environmentCode string) error {
+	cfg, err := config.New[CancelConfig, *uuid.UUID](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := q.GetConfig(ctx, organisation.Id, configCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get config")
+		return err
+	}
+
+	environment, err := GetEnvironment(ctx, q, organisation, environmentCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get environment")
+		return err
+	}
+
+	deployment, err := GetDeployment(config, environment)
+	if err != nil {
+		cfg.WriteStderr(fmt.Sprintf("%s is not deployed to %s", config.Code, environment.Code))
+		return err
+	}
+	if !InProgress(deployment.State) {
+		return fmt.Errorf("the deployment of %s to %s is %s, only a deployment in progress can be cancelled: %w", config.Code, environment.Code, deployment.State, queries.ErrConflict)
+	}
+
+	// a deployment already cancelling is only watched.
+	if deployment.State != queries.StackStateCancelling {
+		if _, err := q.CancelDeployment(ctx, organisation.Id, deployment.Id); err != nil {
+			cfg.WriteStderr("failed to cancel deployment")
+			return err
+		}
+	}
+
+	cfg.WriteStderr(fmt.Sprintf("Cancelling the deployment of %s to %s", config.Code, environment.Code))
+
+	if !cfg.Command.Watch {
+		return nil
+	}
+
+	renderer := waiter.NewRenderer(cfg.Global.Format, cfg.Stdout())
+	state, err := waiter.Deployment(ctx, q, organisation.Id, deployment.Id, waiter.CancelStackStates, cfg.Command.Options(cfg.Wait, renderer))
+	renderer.Done()
+	if err != nil {
+		cfg.WriteStderr("failed to watch deployment")
+		return err
+	}
+
+	switch state {
+	case queries.StackStateCreated, queries.StackStateUpdated:
+		cfg.WriteStderr(fmt.Sprintf("The deployment of %s to %s finished or rolled back before the cancel took effect and is %s", config.Code, environment.Code, state))
+	default:
+		cfg.WriteStderr(fmt.Sprintf("The deployment of %s to %s was cancelled and is %s", config.Code, environment.Code, state))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package deploy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func Test_Cancel(t *testing.T) {
+	cases := []struct {
+		name        string
+		args        []string
+		state       queries.StackState
+		cancelState queries.StackState
+		err         error
+		stderr      string
+		stdout      string
+		json        bool
+		cancels     int
+	}{
+		{
+			name:    "in progress",
+			args:    []string{"deploy", "cancel", "api", "dev"},
+			state:   queries.StackStateUpdating,
+			stderr:  "Cancelling the deployment of api to dev\n",
+			cancels: 1,
+		},
+		{
+			name:    "watch",
+			args:    []string{"deploy", "cancel", "api", "dev", "--watch", "--wait-interval", "10ms"},
+			state:   queries.StackStateCreating,
+			stdout:  "Deployment failed\n",
+			stderr:  "The deployment of api to dev was cancelled and is failed\n",
+			cancels: 1,
+		},
+		{
+			name:        "watch until rolled back",
+			args:        []string{"deploy", "cancel", "api", "dev", "--watch", "--wait-interval", "10ms"},
+			state:       queries.StackStateUpdating,
+			cancelState: queries.StackStateUpdated,
+			stderr:      "The deployment of api to dev finished or rolled back before the cancel took effect and is updated\n",
+			cancels:     1,
+		},
+		{
+			name:    "json",
+			args:    []string{"deploy", "cancel", "api", "dev", "--watch", "--wait-interval", "10ms", "--format", "json"},
+			state:   queries.StackStateUpdating,
+			stderr:  "The deployment of api to dev was cancelled and is failed\n",
+			json:    true,
+			cancels: 1,
+		},
+		{
+			name:   "already cancelling",
+			args:   []string{"deploy", "cancel", "api", "dev"},
+			state:  queries.StackStateCancelling,
+			stderr: "Cancelling the deployment of api to dev\n",
+		},
+		{
+			name:  "not in progress",
+			args:  []string{"deploy", "cancel", "api", "dev"},
+			state: queries.StackStateCreated,
+			err:   queries.ErrConflict,
+		},
+		{
+			name:   "not deployed",
+			args:   []string{"deploy", "cancel", "web", "dev"},
+			state:  queries.StackStateUpdating,
+			err:    queries.ErrNotFound,
+			stderr: "web is not deployed to dev",
+		},
+		{
+			name:   "unknown environment",
+			args:   []string{"deploy", "cancel", "api", "prod"},
+			state:  queries.StackStateUpdating,
+			err:    queries.ErrNotFound,
+			stderr: "failed to get environment",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			fake.DeploymentState = c.state
+			if len(c.cancelState) > 0 {
+				fake.CancelState = c.cancelState
+			}
+			org := fake.AddOrganisation("noops", "NoOps")
+			env := fake.AddEnvironment(org.Id, "dev")
+			fake.AddConfig(org.Id, "web", queries.ConfigClassCompute)
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			revision := fake.AddRevision(config, "1.0.0")
+
+			revisionId := uuid.New()
+			if _, err := fake.NewDeployment(context.Background(), org.Id, uuid.New(), env.Id, config.Id, revision.Id, revisionId); err != nil {
+				t.Fatal(err)
+			}
+
+			res := cmdtest.Run(t, fake, c.args...)
+			if c.err != nil {
+				if !errors.Is(res.Err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if !strings.Contains(res.Stderr, c.stderr) {
+					t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+				}
+				if fake.Calls("CancelDeployment") != 0 {
+					t.Fatal("expected the deployment not to be cancelled")
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if !strings.HasSuffix(res.Stdout, c.stdout) {
+				t.Fatalf("expected stdout to end with %q, got %q", c.stdout, res.Stdout)
+			}
+			if !strings.HasSuffix(res.Stderr, c.stderr) {
+				t.Fatalf("expected stderr to end with %q, got %q", c.stderr, res.Stderr)
+			}
+			if c.json {
+				for _, line := range strings.Split(strings.TrimSpace(res.Stdout), "\n") {
+					if !json.Valid([]byte(line)) {
+						t.Fatalf("expected only json on stdout, got %q", res.Stdout)
+					}
+				}
+			}
+			if calls := fake.Calls("CancelDeployment"); calls != c.cancels {
+				t.Fatalf("expected %d cancels, got %d", c.cancels, calls)
+			}
+			if c.cancels > 0 && fake.DeploymentRevision(revisionId).State != fake.CancelState {
+				t.Fatalf("expected the revision to be %s, got %s", fake.CancelState, fake.DeploymentRevision(revisionId).State)
+			}
+		})
+	}
+}
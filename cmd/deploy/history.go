@@ -0,0 +1,190 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type HistoryConfig struct {
+	Limit int `mapstructure:"limit"`
+}
+
+func HistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history [config] [env]",
+		Short: "List the versions of a config and where they are deployed",
+		Long: `Lists the versions of the config, newest first, with the state of each
+environment they were deployed to and whether it is the active or previous
+version there. Give an environment to only show its deployments.`,
+		Args:   cobra.RangeArgs(1, 2),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configCode := args[0]
+			environmentCode := ""
+			if len(args) > 1 {
+				environmentCode = args[1]
+			}
+
+			ctx := cmd.Context()
+			return History(ctx, configCode, environmentCode)
+		},
+		ValidArgs: []string{"config", "env"},
+	}
+
+	util.BindIntFlag(cmd, "limit", "The number of versions shown", 20)
+	return cmd
+}
+
+// HistoryItem is a version of a config and its deployment to an environment,
+// the environment is empty for a version which was never deployed.
+type HistoryItem struct {
+	Version      string              `json:"version" yaml:"version"`
+	VersionState queries.ConfigState `json:"version_state" yaml:"version_state"`
+	CreatedAt    time.Time           `json:"created_at" yaml:"created_at"`
+	Environment  string              `json:"environment,omitempty" yaml:"environment,omitempty"`
+	State        queries.StackState  `json:"state,omitempty" yaml:"state,omitempty"`
+	UpdatedAt    *time.Time          `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+	Active       bool                `json:"active" yaml:"active"`
+	Previous     bool                `json:"previous" yaml:"previous"`
+}
+
+// NewHistory returns an item for each deployment of each version, filtered to
+// the environment when its code is given.
+func NewHistory(deployments *queries.Deployments, environmentCode string) []*HistoryItem {
+	out := []*HistoryItem{}
+	for _, version := range deployments.Versions {
+		deployed := false
+		for _, item := range version.Items {
+			if !item.Exists || item.Environment == nil {
+				continue
+			}
+			if len(environmentCode) > 0 && item.Environment.Code != environmentCode {
+				continue
+			}
+			deployed = true
+
+			updatedAt := item.Updated_at
+			out = append(out, &HistoryItem{
+				Version:      version.Version_number,
+				VersionState: version.State,
+				CreatedAt:    version.Created_at,
+				Environment:  item.Environment.Code,
+				State:        item.State,
+				UpdatedAt:    &updatedAt,
+				Active:       item.Active,
+				Previous:     item.Previous,
+			})
+		}
+
+		// versions never deployed are listed when no environment is given.
+		if !deployed && len(environmentCode) == 0 {
+			out = append(out, &HistoryItem{
+				Version:      version.Version_number,
+				VersionState: version.State,
+				CreatedAt:    version.Created_at,
+			})
+		}
+	}
+	return out
+}
+
+// WriteHistory writes a row per item, the active and previous versions of
+// each environment are marked.
+func WriteHistory(w io.Writer, items []*HistoryItem) {
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		// without a style the last character of the widest cells is cut off.
+		StyleFunc(func(row, col int) lipgloss.Style { return lipgloss.NewStyle().PaddingRight(1) }).
+		Headers("Version", "Created", "Environment", "State", "Updated", "")
+
+	for _, item := range items {
+		if len(item.Environment) == 0 {
+			t.Row(item.Version, item.CreatedAt.Local().Format(time.RFC3339), "-", "-", "-", "")
+			continue
+		}
+
+		mark := ""
+		switch {
+		case item.Active:
+			mark = "active"
+		case item.Previous:
+			mark = "previous"
+		}
+		updatedAt := "-"
+		if item.UpdatedAt != nil && !item.UpdatedAt.IsZero() {
+			updatedAt = item.UpdatedAt.Local().Format(time.RFC3339)
+		}
+		t.Row(item.Version, item.CreatedAt.Local().Format(time.RFC3339), item.Environment, string(item.State), updatedAt, mark)
+	}
+	fmt.Fprintln(w, t.Render())
+}
+
+func History(ctx context.Context, configCode string, environmentCode string) error {
+	cfg, err := config.New[HistoryConfig, *HistoryItem](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	format := strings.ToLower(cfg.Global.Format)
+	switch format {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("invalid format %s, should be one of: [table,json,yaml]", cfg.Global.Format)
+	}
+	if cfg.Command.Limit <= 0 {
+		return fmt.Errorf("invalid limit %d, should be at least 1", cfg.Command.Limit)
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := q.GetConfig(ctx, organisation.Id, configCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get config")
+		return err
+	}
+
+	if len(environmentCode) > 0 {
+		if _, err := GetEnvironment(ctx, q, organisation, environmentCode); err != nil {
+			cfg.WriteStderr("failed to get environment")
+			return err
+		}
+	}
+
+	deployments, err := q.GetDeployments(ctx, organisation.Id, config.Id, 1, cfg.Command.Limit)
+	if err != nil {
+		cfg.WriteStderr("failed to get deployments")
+		return err
+	}
+
+	items := NewHistory(deployments, environmentCode)
+	if format == "table" {
+		WriteHistory(cfg.Stdout(), items)
+		return nil
+	}
+	cfg.WriteList(items)
+	return nil
+}
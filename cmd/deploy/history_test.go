@@ -0,0 +1,115 @@
+package deploy_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/cmd/deploy"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+// setupHistory deploys 1.0.0 and 1.1.0 to dev before 1.2.0 fails, prod only
+// has 1.0.0 and 1.3.0 was never deployed.
+func setupHistory(t *testing.T) (*queriestest.Fake, *queries.Organisation, *queries.Config, map[string]*queries.RevisionItem) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	dev := fake.AddEnvironment(org.Id, "dev")
+	prod := fake.AddEnvironment(org.Id, "prod")
+	config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+
+	revisions := map[string]*queries.RevisionItem{}
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0"} {
+		revisions[version] = fake.AddRevision(config, version)
+	}
+
+	deploy := func(deploymentId uuid.UUID, environment *queries.Environment, version string, state queries.StackState) {
+		fake.DeploymentState = state
+		if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, environment.Id, config.Id, revisions[version].Id, uuid.New()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	devId, prodId := uuid.New(), uuid.New()
+	deploy(devId, dev, "1.0.0", queries.StackStateCreated)
+	deploy(prodId, prod, "1.0.0", queries.StackStateCreated)
+	deploy(devId, dev, "1.1.0", queries.StackStateUpdated)
+	deploy(devId, dev, "1.2.0", queries.StackStateFailed)
+	fake.DeploymentState = queries.StackStateUpdated
+	return fake, org, config, revisions
+}
+
+func Test_History(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "every environment",
+			args:     []string{"deploy", "history", "api"},
+			expected: []string{"1.3.0 -", "1.2.0 dev failed active", "1.1.0 dev updated previous", "1.0.0 dev created", "1.0.0 prod created active"},
+		},
+		{
+			name:     "environment",
+			args:     []string{"deploy", "history", "api", "dev"},
+			expected: []string{"1.2.0 dev failed active", "1.1.0 dev updated previous", "1.0.0 dev created"},
+		},
+		{
+			name:     "limit",
+			args:     []string{"deploy", "history", "api", "--limit", "2"},
+			expected: []string{"1.3.0 -", "1.2.0 dev failed active"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake, _, _, _ := setupHistory(t)
+
+			res := cmdtest.Run(t, fake, append(c.args, "--format", "json")...)
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+
+			items := []*deploy.HistoryItem{}
+			if err := json.Unmarshal([]byte(res.Stdout), &items); err != nil {
+				t.Fatalf("expected json, got %q", res.Stdout)
+			}
+			rows := []string{}
+			for _, item := range items {
+				row := item.Version + " -"
+				if len(item.Environment) > 0 {
+					row = fmt.Sprintf("%s %s %s", item.Version, item.Environment, item.State)
+				}
+				if item.Active {
+					row += " active"
+				}
+				if item.Previous {
+					row += " previous"
+				}
+				rows = append(rows, row)
+			}
+			if strings.Join(rows, ",") != strings.Join(c.expected, ",") {
+				t.Fatalf("expected %v, got %v", c.expected, rows)
+			}
+		})
+	}
+}
+
+func Test_History_Table(t *testing.T) {
+	fake, _, _, _ := setupHistory(t)
+
+	res := cmdtest.Run(t, fake, "deploy", "history", "api", "dev")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	for _, expected := range []string{"Version", "1.2.0", "failed", "active", "previous"} {
+		if !strings.Contains(res.Stdout, expected) {
+			t.Fatalf("expected %q in %q", expected, res.Stdout)
+		}
+	}
+}
@@ -14,5 +14,9 @@ func New() *cobra.Command {
 	}
 
 	cmd.AddCommand(ApplyCommand())
+	cmd.AddCommand(CancelCommand())
+	cmd.AddCommand(HistoryCommand())
+	cmd.AddCommand(RollbackCommand())
+	cmd.AddCommand(PromoteCommand())
 	return cmd
 }
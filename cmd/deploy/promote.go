@@ -0,0 +1,231 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/diagnose"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type PromoteConfig struct {
+	From  string `mapstructure:"from"`
+	To    string `mapstructure:"to"`
+	Chain bool   `mapstructure:"chain"`
+
+	waiter.Flags          `mapstructure:",squash"`
+	diagnose.FailureFlags `mapstructure:",squash"`
+}
+
+func PromoteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote [config...]",
+		Short: "Deploy the versions of configs active in one environment to another",
+		Long: `Deploys the version of each config which is active in the --from environment
+to the --to environment, --to defaults to the environment after --from in the
+sort order of the environments. The active deployment must have succeeded.
+
+With --chain the versions are promoted through every environment between
+--from and --to in the sort order, each is watched until it succeeds before
+the next is deployed. --to defaults to the last environment.`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			return Promote(ctx, args)
+		},
+		ValidArgs: []string{"config"},
+	}
+
+	util.BindStringFlag(cmd, "from", "The environment the versions are active in", "")
+	util.BindStringFlag(cmd, "to", "The environment the versions are deployed to, defaults to the next environment", "")
+	util.BindBoolFlag(cmd, "chain", "Promote through every environment between --from and --to", false)
+	waiter.BindFlags(cmd, "Watch deployments for success")
+	diagnose.BindFlags(cmd)
+	return cmd
+}
+
+// PromotionPath returns the environments the versions move through, the
+// source first. Without chain it is the source and the target, the target
+// defaults to the environment after the source in the sort order. With chain
+// it is every environment from the source to the target, the target defaults
+// to the last environment.
+func PromotionPath(environments []*queries.Environment, from string, to string, chain bool) ([]*queries.Environment, error) {
+	sorted := append([]*queries.Environment{}, environments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Sort_order < sorted[j].Sort_order
+	})
+
+	index := func(code string) (int, error) {
+		for i, environment := range sorted {
+			if environment.Code == code {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("environment %s: %w", code, queries.ErrNotFound)
+	}
+
+	source, err := index(from)
+	if err != nil {
+		return nil, err
+	}
+
+	target := source + 1
+	if chain {
+		target = len(sorted) - 1
+	}
+	if len(to) > 0 {
+		if target, err = index(to); err != nil {
+			return nil, err
+		}
+	}
+	if target == source || target >= len(sorted) {
+		return nil, fmt.Errorf("there is no environment to promote %s to, use --to", from)
+	}
+
+	if !chain {
+		return []*queries.Environment{sorted[source], sorted[target]}, nil
+	}
+	if target < source {
+		return nil, fmt.Errorf("environment %s is before %s, a chain follows the sort order of the environments", to, from)
+	}
+	return sorted[source : target+1], nil
+}
+
+// Succeeded is true for the states of a deployment which can be promoted.
+func Succeeded(state queries.StackState) bool {
+	return state == queries.StackStateCreated || state == queries.StackStateUpdated
+}
+
+func Promote(ctx context.Context, configCodes []string) error {
+	cfg, err := config.New[PromoteConfig, *uuid.UUID](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+	if len(cfg.Command.From) == 0 {
+		return fmt.Errorf("--from is required")
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	environments := []*queries.Environment{}
+	states := []queries.StackState{queries.StackStateCreated}
+	if err := q.EachEnvironment(ctx, organisation.Id, nil, states, queries.PageOptions{}, func(environment *queries.Environment) error {
+		environments = append(environments, environment)
+		return nil
+	}); err != nil {
+		cfg.WriteStderr("failed to get environments")
+		return err
+	}
+
+	path, err := PromotionPath(environments, cfg.Command.From, cfg.Command.To, cfg.Command.Chain)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < len(path); i++ {
+		source, target := path[i-1], path[i]
+
+		// every config is checked before any is deployed to the environment.
+		promotions := []*promotion{}
+		for _, code := range configCodes {
+			p, err := newPromotion(ctx, q, organisation, code, source, target)
+			if err != nil {
+				cfg.WriteStderr(fmt.Sprintf("failed to promote %s from %s to %s", code, source.Code, target.Code))
+				return err
+			}
+			promotions = append(promotions, p)
+		}
+
+		revisionIds := []uuid.UUID{}
+		for _, p := range promotions {
+			if p.deploymentId == uuid.Nil {
+				cfg.WriteStderr(fmt.Sprintf("%s %s is already active in %s", p.config.Code, p.item.Version_number, target.Code))
+				continue
+			}
+
+			deploymentRevisionId := uuid.New()
+			if _, err := q.NewDeployment(ctx, organisation.Id, p.deploymentId, target.Id, p.config.Id, p.item.Config_revision_id, deploymentRevisionId); err != nil {
+				cfg.WriteStderr("failed to deploy")
+				return err
+			}
+			cfg.WriteStderr(fmt.Sprintf("Promoting %s %s from %s to %s", p.config.Code, p.item.Version_number, source.Code, target.Code))
+			revisionIds = append(revisionIds, deploymentRevisionId)
+		}
+
+		// a chain only moves on once the environment has succeeded.
+		if !cfg.Command.Watch && i == len(path)-1 {
+			continue
+		}
+		for _, id := range revisionIds {
+			if err := WatchDeploymentRevision(ctx, cfg, q, organisation.Id, id, cfg.Command.Flags, cfg.Command.FailureFlags); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// promotion is the active version of a config in the source environment and
+// the deployment it is promoted to, the deployment id is nil when the
+// version is already active in the target.
+type promotion struct {
+	config       *queries.Config
+	item         *queries.DeploymentItem
+	deploymentId uuid.UUID
+}
+
+func newPromotion(ctx context.Context, q queries.Queries, organisation *queries.Organisation, code string, source *queries.Environment, target *queries.Environment) (*promotion, error) {
+	config, err := q.GetConfig(ctx, organisation.Id, code)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceItems, err := GetDeploymentItems(ctx, q, organisation.Id, config.Id, source.Id)
+	if err != nil {
+		return nil, err
+	}
+	var active *queries.DeploymentItem
+	for _, item := range sourceItems {
+		if item.Active {
+			active = item
+		}
+	}
+	if active == nil {
+		return nil, fmt.Errorf("%s has no active version in %s: %w", config.Code, source.Code, queries.ErrNotFound)
+	}
+	if !Succeeded(active.State) {
+		return nil, fmt.Errorf("%s %s in %s is %s, only a successful deployment can be promoted: %w", config.Code, active.Version_number, source.Code, active.State, queries.ErrConflict)
+	}
+
+	targetItems, err := GetDeploymentItems(ctx, q, organisation.Id, config.Id, target.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range targetItems {
+		if item.Active && item.Config_revision_id == active.Config_revision_id && Succeeded(item.State) {
+			return &promotion{config: config, item: active}, nil
+		}
+	}
+
+	return &promotion{config: config, item: active, deploymentId: GetDeploymentId(ctx, config, target)}, nil
+}
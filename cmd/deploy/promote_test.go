@@ -0,0 +1,208 @@
+package deploy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/cmd/deploy"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+// setupPromote has api 1.1.0 active in dev and 1.0.0 in staging and prod,
+// web 2.0.0 is only in dev.
+func setupPromote(t *testing.T, apiState queries.StackState) (*queriestest.Fake, func(config string, environment string) string) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	environments := map[string]*queries.Environment{}
+	for _, code := range []string{"dev", "staging", "prod"} {
+		environments[code] = fake.AddEnvironment(org.Id, code)
+	}
+
+	configs := map[string]*queries.Config{}
+	revisions := map[string]*queries.RevisionItem{}
+	for code, versions := range map[string][]string{"api": {"1.0.0", "1.1.0"}, "web": {"2.0.0"}} {
+		configs[code] = fake.AddConfig(org.Id, code, queries.ConfigClassCompute)
+		for _, version := range versions {
+			revisions[code+version] = fake.AddRevision(configs[code], version)
+		}
+	}
+
+	deployments := map[string]uuid.UUID{}
+	deploy := func(config string, environment string, version string, state queries.StackState) {
+		id, ok := deployments[config+environment]
+		if !ok {
+			id = uuid.New()
+			deployments[config+environment] = id
+		}
+		fake.DeploymentState = state
+		if _, err := fake.NewDeployment(context.Background(), org.Id, id, environments[environment].Id, configs[config].Id, revisions[config+version].Id, uuid.New()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deploy("api", "dev", "1.0.0", queries.StackStateCreated)
+	deploy("api", "staging", "1.0.0", queries.StackStateCreated)
+	deploy("api", "prod", "1.0.0", queries.StackStateCreated)
+	deploy("api", "dev", "1.1.0", apiState)
+	deploy("web", "dev", "2.0.0", queries.StackStateCreated)
+	fake.DeploymentState = queries.StackStateUpdated
+
+	active := func(config string, environment string) string {
+		out, err := fake.GetDeployments(context.Background(), org.Id, configs[config].Id, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, version := range out.Versions {
+			for _, item := range version.Items {
+				if item.Active && item.Environment.Code == environment {
+					return item.Version_number
+				}
+			}
+		}
+		return ""
+	}
+	return fake, active
+}
+
+func Test_Promote(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		apiState queries.StackState
+		err      error
+		stdout   []string
+		stderr   []string
+		json     bool
+		active   map[string]string
+	}{
+		{
+			name:   "next environment",
+			args:   []string{"api", "web", "--from", "dev"},
+			stderr: []string{"Promoting api 1.1.0 from dev to staging", "Promoting web 2.0.0 from dev to staging"},
+			active: map[string]string{"api staging": "1.1.0", "web staging": "2.0.0", "api prod": "1.0.0", "web prod": ""},
+		},
+		{
+			name:   "to an environment",
+			args:   []string{"api", "--from", "dev", "--to", "prod"},
+			stderr: []string{"Promoting api 1.1.0 from dev to prod"},
+			active: map[string]string{"api staging": "1.0.0", "api prod": "1.1.0"},
+		},
+		{
+			name:   "chain",
+			args:   []string{"api", "web", "--from", "dev", "--chain", "--wait-interval", "10ms"},
+			stdout: []string{"Deployment updated", "Deployment updated"},
+			stderr: []string{
+				"Promoting api 1.1.0 from dev to staging",
+				"Promoting web 2.0.0 from dev to staging",
+				"Promoting api 1.1.0 from staging to prod",
+				"Promoting web 2.0.0 from staging to prod",
+			},
+			active: map[string]string{"api staging": "1.1.0", "web staging": "2.0.0", "api prod": "1.1.0", "web prod": "2.0.0"},
+		},
+		{
+			name:   "json",
+			args:   []string{"api", "--from", "dev", "--chain", "--wait-interval", "10ms", "--format", "json"},
+			stderr: []string{"Promoting api 1.1.0 from dev to staging", "Promoting api 1.1.0 from staging to prod"},
+			json:   true,
+			active: map[string]string{"api staging": "1.1.0", "api prod": "1.1.0"},
+		},
+		{
+			name:   "already active",
+			args:   []string{"api", "--from", "staging"},
+			stderr: []string{"api 1.0.0 is already active in prod"},
+			active: map[string]string{"api prod": "1.0.0"},
+		},
+		{name: "failed in the source", args: []string{"api", "web", "--from", "dev"}, apiState: queries.StackStateFailed, err: queries.ErrConflict},
+		{name: "in progress in the source", args: []string{"api", "--from", "dev"}, apiState: queries.StackStateUpdating, err: queries.ErrConflict},
+		{name: "not active in the source", args: []string{"api", "web", "--from", "staging"}, err: queries.ErrNotFound},
+		{name: "unknown environment", args: []string{"api", "--from", "test"}, err: queries.ErrNotFound},
+		{name: "no next environment", args: []string{"api", "--from", "prod"}},
+		{name: "chain backwards", args: []string{"api", "--from", "prod", "--to", "dev", "--chain"}},
+		{name: "no from", args: []string{"api"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state := c.apiState
+			if len(state) == 0 {
+				state = queries.StackStateUpdated
+			}
+			fake, active := setupPromote(t, state)
+			deploys := fake.Calls("NewDeployment")
+
+			res := cmdtest.Run(t, fake, append([]string{"deploy", "promote"}, c.args...)...)
+			if c.active == nil {
+				if res.Err == nil || (c.err != nil && !errors.Is(res.Err, c.err)) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if fake.Calls("NewDeployment") != deploys {
+					t.Fatal("expected nothing to be deployed")
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+			if c.json {
+				for _, line := range lines {
+					if !json.Valid([]byte(line)) {
+						t.Fatalf("expected only json on stdout, got %q", res.Stdout)
+					}
+				}
+			} else if strings.Join(lines, ",") != strings.Join(c.stdout, ",") {
+				t.Fatalf("expected %q, got %q", c.stdout, lines)
+			}
+			if lines := strings.Split(strings.TrimSpace(res.Stderr), "\n"); strings.Join(lines, ",") != strings.Join(c.stderr, ",") {
+				t.Fatalf("expected stderr %q, got %q", c.stderr, lines)
+			}
+			for key, version := range c.active {
+				config, environment, _ := strings.Cut(key, " ")
+				if out := active(config, environment); out != version {
+					t.Fatalf("expected %s %s to be active in %s, got %q", config, version, environment, out)
+				}
+			}
+		})
+	}
+}
+
+func Test_PromotionPath(t *testing.T) {
+	environments := []*queries.Environment{
+		{Code: "prod", Sort_order: 3},
+		{Code: "dev", Sort_order: 1},
+		{Code: "staging", Sort_order: 2},
+		{Code: "test", Sort_order: 1},
+	}
+
+	cases := []struct {
+		from     string
+		to       string
+		chain    bool
+		expected string
+	}{
+		{from: "dev", expected: "dev,test"},
+		{from: "test", expected: "test,staging"},
+		{from: "staging", to: "dev", expected: "staging,dev"},
+		{from: "dev", chain: true, expected: "dev,test,staging,prod"},
+		{from: "test", to: "staging", chain: true, expected: "test,staging"},
+	}
+	for _, c := range cases {
+		path, err := deploy.PromotionPath(environments, c.from, c.to, c.chain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codes := []string{}
+		for _, environment := range path {
+			codes = append(codes, environment.Code)
+		}
+		if strings.Join(codes, ",") != c.expected {
+			t.Fatalf("expected %s from %s to %q, got %v", c.expected, c.from, c.to, codes)
+		}
+	}
+}
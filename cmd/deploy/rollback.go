@@ -0,0 +1,155 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/diagnose"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type RollbackConfig struct {
+	To string `mapstructure:"to"`
+
+	waiter.Flags          `mapstructure:",squash"`
+	diagnose.FailureFlags `mapstructure:",squash"`
+}
+
+func RollbackCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback [config] [env]",
+		Short: "Deploy the previous version of a config to an environment again",
+		Long: `Deploys the version of the config which was active in the environment
+before the current one, or the version given with --to.`,
+		Args:   cobra.ExactArgs(2),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configCode := args[0]
+			environmentCode := args[1]
+
+			ctx := cmd.Context()
+			return Rollback(ctx, configCode, environmentCode)
+		},
+		ValidArgs: []string{"config", "env"},
+	}
+
+	util.BindStringFlag(cmd, "to", "The version to roll back to, defaults to the previous version", "")
+	waiter.BindFlags(cmd, "Watch deployment for success")
+	diagnose.BindFlags(cmd)
+	return cmd
+}
+
+// GetDeploymentItems returns the items of the deployment to the environment,
+// newest version first.
+func GetDeploymentItems(ctx context.Context, q queries.Queries, organisationId uuid.UUID, configId uuid.UUID, environmentId uuid.UUID) ([]*queries.DeploymentItem, error) {
+	out := []*queries.DeploymentItem{}
+	for page := 1; ; page++ {
+		deployments, err := q.GetDeployments(ctx, organisationId, configId, page, queries.DefaultPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range deployments.Versions {
+			for _, item := range version.Items {
+				if item.Exists && item.Environment != nil && item.Environment.Id == environmentId {
+					out = append(out, item)
+				}
+			}
+		}
+		if len(deployments.Versions) < queries.DefaultPageSize {
+			return out, nil
+		}
+	}
+}
+
+func Rollback(ctx context.Context, configCode string, environmentCode string) error {
+	cfg, err := config.New[RollbackConfig, *uuid.UUID](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := q.GetConfig(ctx, organisation.Id, configCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get config")
+		return err
+	}
+
+	environment, err := GetEnvironment(ctx, q, organisation, environmentCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get environment")
+		return err
+	}
+
+	deployment, err := GetDeployment(config, environment)
+	if err != nil {
+		cfg.WriteStderr(fmt.Sprintf("%s is not deployed to %s", config.Code, environment.Code))
+		return err
+	}
+
+	items, err := GetDeploymentItems(ctx, q, organisation.Id, config.Id, environment.Id)
+	if err != nil {
+		cfg.WriteStderr("failed to get deployments")
+		return err
+	}
+
+	var active, target *queries.DeploymentItem
+	for _, item := range items {
+		if item.Active {
+			active = item
+		}
+		if item.Previous && len(cfg.Command.To) == 0 {
+			target = item
+		}
+	}
+
+	var revisionId uuid.UUID
+	var versionNumber string
+	if len(cfg.Command.To) > 0 {
+		revision, err := GetConfigRevision(config.Revisions, cfg.Command.To)
+		if err != nil {
+			cfg.WriteStderr(fmt.Sprintf("revision not found with version number %s", cfg.Command.To))
+			return err
+		}
+		revisionId, versionNumber = revision.Id, revision.Version_number
+	} else {
+		if target == nil {
+			return fmt.Errorf("%s has no previous version in %s, use --to: %w", config.Code, environment.Code, queries.ErrNotFound)
+		}
+		revisionId, versionNumber = target.Config_revision_id, target.Version_number
+	}
+	if active != nil && active.Config_revision_id == revisionId {
+		return fmt.Errorf("%s %s is already active in %s: %w", config.Code, versionNumber, environment.Code, queries.ErrConflict)
+	}
+
+	deploymentRevisionId := uuid.New()
+	if _, err := q.NewDeployment(ctx, organisation.Id, deployment.Id, environment.Id, config.Id, revisionId, deploymentRevisionId); err != nil {
+		cfg.WriteStderr("failed to deploy")
+		return err
+	}
+
+	cfg.WriteStderr(fmt.Sprintf("Rolling back %s in %s to %s", config.Code, environment.Code, versionNumber))
+
+	if cfg.Command.Watch {
+		return WatchDeploymentRevision(ctx, cfg, q, organisation.Id, deploymentRevisionId, cfg.Command.Flags, cfg.Command.FailureFlags)
+	}
+	return nil
+}
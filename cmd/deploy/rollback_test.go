@@ -0,0 +1,94 @@
+package deploy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/pkg/queries"
+)
+
+func Test_Rollback(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		version string
+		err     error
+		stdout  string
+		stderr  string
+		json    bool
+	}{
+		{
+			name:    "previous",
+			args:    []string{"deploy", "rollback", "api", "dev"},
+			version: "1.1.0",
+			stderr:  "Rolling back api in dev to 1.1.0\n",
+		},
+		{
+			name:    "to a version",
+			args:    []string{"deploy", "rollback", "api", "dev", "--to", "1.0.0", "--watch", "--wait-interval", "10ms"},
+			version: "1.0.0",
+			stdout:  "Deployment updated\n",
+			stderr:  "Rolling back api in dev to 1.0.0\n",
+		},
+		{
+			name:    "json",
+			args:    []string{"deploy", "rollback", "api", "dev", "--watch", "--wait-interval", "10ms", "--format", "json"},
+			version: "1.1.0",
+			stderr:  "Rolling back api in dev to 1.1.0\n",
+			json:    true,
+		},
+		{name: "already active", args: []string{"deploy", "rollback", "api", "prod", "--to", "1.0.0"}, err: queries.ErrConflict},
+		{name: "no previous version", args: []string{"deploy", "rollback", "api", "prod"}, err: queries.ErrNotFound},
+		{name: "unknown version", args: []string{"deploy", "rollback", "api", "dev", "--to", "2.0.0"}, err: queries.ErrNotFound},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake, org, config, revisions := setupHistory(t)
+			deploys := fake.Calls("NewDeployment")
+
+			res := cmdtest.Run(t, fake, c.args...)
+			if c.err != nil {
+				if !errors.Is(res.Err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if fake.Calls("NewDeployment") != deploys {
+					t.Fatal("expected nothing to be deployed")
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if c.json {
+				for _, line := range strings.Split(strings.TrimSpace(res.Stdout), "\n") {
+					if !json.Valid([]byte(line)) {
+						t.Fatalf("expected only json on stdout, got %q", res.Stdout)
+					}
+				}
+			} else if res.Stdout != c.stdout {
+				t.Fatalf("expected %q, got %q", c.stdout, res.Stdout)
+			}
+			if res.Stderr != c.stderr {
+				t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+			}
+
+			// the version is active again and the failed one is not previous.
+			deployments, err := fake.GetDeployments(context.Background(), org.Id, config.Id, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, version := range deployments.Versions {
+				for _, item := range version.Items {
+					if item.Environment.Code == "dev" && item.Active && item.Config_revision_id != revisions[c.version].Id {
+						t.Fatalf("expected %s to be active, got %s", c.version, item.Version_number)
+					}
+				}
+			}
+		})
+	}
+}
@@ -11,8 +11,9 @@ import (
 )
 
 type ListConfig struct {
-	Page     int `mapstructure:"page" default:"1"`
-	PageSize int `mapstructure:"page-size" default:"10"`
+	Page     int `mapstructure:"page" default:"0"`
+	Limit    int `mapstructure:"limit" default:"0"`
+	PageSize int `mapstructure:"page-size" default:"50"`
 }
 
 func ListCommand() *cobra.Command {
@@ -26,8 +27,10 @@ func ListCommand() *cobra.Command {
 		},
 	}
 
-	util.BindIntFlag(cmd, "page", "The page to load", 1)
-	util.BindIntFlag(cmd, "page-size", "The number of items in the page", 10)
+	util.BindIntFlag(cmd, "limit", "The maximum number of items to list, 0 lists all", 0)
+	util.BindIntFlag(cmd, "page-size", "The number of items requested in each page", 50)
+	util.BindIntFlag(cmd, "page", "The single page to load, 0 loads every page", 0)
+	cmd.Flags().MarkDeprecated("page", "every page is loaded, use --limit to list fewer items")
 	return cmd
 }
 
@@ -44,19 +47,39 @@ func List(ctx context.Context) error {
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
 	}
 
-	out, err := q.GetEnvironments(ctx, organisation.Id, nil, nil, cfg.Command.Page, cfg.Command.PageSize)
+	opts := queries.PageOptions{
+		PageSize:    cfg.Command.PageSize,
+		Limit:       cfg.Command.Limit,
+		Concurrency: queries.DefaultConcurrency,
+	}
+
+	if cfg.Command.Page > 0 {
+		page, err := q.GetEnvironments(ctx, organisation.Id, nil, nil, cfg.Command.Page, cfg.Command.PageSize)
+		if err != nil {
+			cfg.WriteStderr("failed to get environments")
+			return err
+		}
+		cfg.WriteList(page.Items)
+		return nil
+	}
+
+	out := []*queries.Environment{}
+	err = q.EachEnvironment(ctx, organisation.Id, nil, nil, opts, func(env *queries.Environment) error {
+		out = append(out, env)
+		return nil
+	})
 	if err != nil {
 		cfg.WriteStderr("failed to get environments")
 		return err
 	}
 
-	cfg.WriteList(out.Items)
+	cfg.WriteList(out)
 	return nil
 }
@@ -49,8 +49,8 @@ func Update(ctx context.Context, id uuid.UUID) error {
 
 	_, orgErr := q.GetCurrentOrganisation(ctx)
 	if orgErr == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return orgErr
 	}
 	if orgErr != nil {
 		return orgErr
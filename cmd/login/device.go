@@ -0,0 +1,78 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zitadel/oidc/v2/pkg/client/rp"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"golang.org/x/oauth2"
+)
+
+// DefaultDeviceInterval is the polling interval used when the issuer
+// does not return one, see RFC 8628 section 3.2.
+var DefaultDeviceInterval = 5 * time.Second
+
+var ErrDeviceCodeExpired = errors.New("device code expired, please login again")
+
+// DeviceLogin runs the OAuth device authorization grant against the provider.
+// The prompt is called with the user code and verification url before the
+// token endpoint is polled until the user approves or the code expires.
+func DeviceLogin(ctx context.Context, provider rp.RelyingParty, scopes []string, prompt func(*oidc.DeviceAuthorizationResponse)) (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	if len(provider.GetDeviceAuthorizationEndpoint()) == 0 {
+		return nil, fmt.Errorf("issuer %s does not support device authorization", provider.Issuer())
+	}
+
+	auth, err := rp.DeviceAuthorization(scopes, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt(auth)
+
+	interval := DefaultDeviceInterval
+	if auth.Interval > 0 {
+		interval = time.Duration(auth.Interval) * time.Second
+	}
+
+	// only the expiry of the device code is reported as expired, a deadline
+	// of the parent context is returned as it is.
+	pollCtx := ctx
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeoutCause(ctx, time.Duration(auth.ExpiresIn)*time.Second, ErrDeviceCodeExpired)
+		defer cancel()
+	}
+
+	resp, err := rp.DeviceAccessToken(pollCtx, auth.DeviceCode, interval, provider)
+	if errors.Is(err, &oidc.Error{ErrorType: oidc.ExpiredToken}) || (err != nil && ctx.Err() == nil && errors.Is(context.Cause(pollCtx), ErrDeviceCodeExpired)) {
+		return nil, ErrDeviceCodeExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := &oidc.Tokens[*oidc.IDTokenClaims]{
+		Token: &oauth2.Token{
+			AccessToken:  resp.AccessToken,
+			TokenType:    resp.TokenType,
+			RefreshToken: resp.RefreshToken,
+		},
+		IDToken: resp.IDToken,
+	}
+	if resp.ExpiresIn > 0 {
+		tokens.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	if len(resp.IDToken) > 0 {
+		claims, err := rp.VerifyTokens[*oidc.IDTokenClaims](ctx, resp.AccessToken, resp.IDToken, provider.IDTokenVerifier())
+		if err != nil {
+			return nil, err
+		}
+		tokens.IDTokenClaims = claims
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,96 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/oidctest"
+	"github.com/zitadel/oidc/v2/pkg/client/rp"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+func newProvider(t *testing.T, issuer *oidctest.Issuer) rp.RelyingParty {
+	t.Helper()
+
+	provider, err := rp.NewRelyingPartyOIDC(issuer.URL, issuer.ClientID, "", "", []string{oidc.ScopeOpenID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func Test_DeviceLogin(t *testing.T) {
+	cases := []struct {
+		name     string
+		pending  int
+		slowDown int
+		polls    int
+	}{
+		{name: "approved", pending: 0, polls: 1},
+		{name: "pending", pending: 2, polls: 3},
+		{name: "slow down", slowDown: 1, polls: 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			issuer := oidctest.NewIssuer("ops")
+			defer issuer.Close()
+
+			issuer.DevicePending = c.pending
+			issuer.DeviceSlowDown = c.slowDown
+
+			var prompted *oidc.DeviceAuthorizationResponse
+			tokens, err := DeviceLogin(context.Background(), newProvider(t, issuer), []string{oidc.ScopeOpenID}, func(auth *oidc.DeviceAuthorizationResponse) {
+				prompted = auth
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if prompted == nil || prompted.UserCode != "ABCD-EFGH" {
+				t.Fatalf("expected user code to be prompted, got %v", prompted)
+			}
+			if tokens.IDTokenClaims == nil || tokens.IDTokenClaims.Subject != issuer.Subject {
+				t.Fatalf("expected subject %s, got %v", issuer.Subject, tokens.IDTokenClaims)
+			}
+			if len(tokens.RefreshToken) == 0 {
+				t.Fatal("expected refresh token")
+			}
+			if polls := issuer.Calls(string(oidc.GrantTypeDeviceCode)); polls != c.polls {
+				t.Fatalf("expected %d polls, got %d", c.polls, polls)
+			}
+		})
+	}
+}
+
+func Test_DeviceLogin_Expired(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+
+	issuer.DevicePending = 100
+	issuer.DeviceExpiresIn = 2
+
+	_, err := DeviceLogin(context.Background(), newProvider(t, issuer), nil, func(*oidc.DeviceAuthorizationResponse) {})
+	if !errors.Is(err, ErrDeviceCodeExpired) {
+		t.Fatalf("expected %v, got %v", ErrDeviceCodeExpired, err)
+	}
+}
+
+func Test_DeviceLogin_Timeout(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+
+	issuer.DevicePending = 100
+	issuer.DeviceExpiresIn = 60
+
+	// the deadline of the caller is not the device code expiring.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err := DeviceLogin(ctx, newProvider(t, issuer), nil, func(*oidc.DeviceAuthorizationResponse) {})
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrDeviceCodeExpired) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -2,11 +2,11 @@ package login
 
 import (
 	"context"
-	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/federated"
 	"github.com/getnoops/ops/pkg/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,19 +14,31 @@ import (
 )
 
 type Config struct {
+	Device    bool   `mapstructure:"device" default:"false"`
+	Federated bool   `mapstructure:"federated" default:"false"`
+	Provider  string `mapstructure:"provider"`
 }
 
 func New() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "login",
-		Short:  "Login to NoOps",
-		Long:   `Using SSO login to NoOps`,
+		Use:   "login",
+		Short: "Login to NoOps",
+		Long: `Using SSO login to NoOps, use --device on machines without a browser.
+
+In CI use --federated to exchange the job's OIDC token for a short lived
+access token. The provider is detected from the environment or selected with
+--provider, the token can also be read from NOOPS_AUTH_FEDERATED_TOKENFILE
+or the variable named by NOOPS_AUTH_FEDERATED_TOKENENV.`,
 		PreRun: util.BindPreRun,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			return Login(ctx)
 		},
 	}
+
+	util.BindBoolFlag(cmd, "device", "Login using the device authorization flow", false)
+	util.BindBoolFlag(cmd, "federated", "Login by exchanging the CI job's OIDC token", false)
+	util.BindStringFlag(cmd, "provider", "The federated token provider, one of: ["+strings.Join(federated.Names(), ",")+"]", "")
 	return cmd
 }
 
@@ -36,17 +48,14 @@ func Login(ctx context.Context) error {
 		return err
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	tokenChan := make(chan *oidc.Tokens[*oidc.IDTokenClaims], 1)
-
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-
-	go func() {
-		<-sigs
-		cancel()
-	}()
+	if cfg.Command.Federated {
+		return LoginFederated(ctx, cfg)
+	}
+	if cfg.Command.Device {
+		return LoginDevice(ctx, cfg)
+	}
 
+	tokenChan := make(chan *oidc.Tokens[*oidc.IDTokenClaims], 1)
 	server, err := NewServer(ctx, cfg, tokenChan)
 	if err != nil {
 		cfg.WriteStderr("failed to create server")
@@ -55,7 +64,10 @@ func Login(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
-		os.Exit(0)
+		// the login was interrupted or timed out, nothing was stored.
+		server.Shutdown(context.Background())
+		cfg.WriteStderr("login cancelled")
+		return ctx.Err()
 	case token := <-tokenChan:
 		if err := server.Shutdown(ctx); err != nil {
 			cfg.WriteStderr("failed to shutdown server")
@@ -69,3 +81,56 @@ func Login(ctx context.Context) error {
 	}
 	return nil
 }
+
+func LoginDevice(ctx context.Context, cfg *config.NoOps[Config, string]) error {
+	provider, err := cfg.NewRelyingPartyOIDC(ctx, "")
+	if err != nil {
+		cfg.WriteStderr("failed to create provider")
+		return err
+	}
+
+	prompt := func(auth *oidc.DeviceAuthorizationResponse) {
+		url := auth.VerificationURIComplete
+		if len(url) == 0 {
+			url = auth.VerificationURI
+		}
+
+		out := lipgloss.JoinVertical(
+			lipgloss.Left,
+			cfg.Styles.Title.Render("To authenticate please follow the link below and enter the code:"),
+			cfg.Styles.Url.Render(url),
+			cfg.Styles.Desc.Render(auth.UserCode),
+		)
+		cfg.WriteStdout(out)
+	}
+
+	token, err := DeviceLogin(ctx, provider, cfg.Auth.Scopes, prompt)
+	if err != nil {
+		cfg.WriteStderr("failed to login with device code")
+		return err
+	}
+
+	if err := cfg.StoreToken(token); err != nil {
+		cfg.WriteStderr("failed to store token")
+		return err
+	}
+	return nil
+}
+
+func LoginFederated(ctx context.Context, cfg *config.NoOps[Config, string]) error {
+	if len(cfg.Command.Provider) > 0 {
+		cfg.Auth.Federated.Provider = cfg.Command.Provider
+	}
+
+	token, err := cfg.ExchangeFederatedToken(ctx)
+	if err != nil {
+		cfg.WriteStderr("failed to exchange federated token")
+		return err
+	}
+
+	if err := cfg.StoreTokenGrant(token, oidc.GrantTypeTokenExchange); err != nil {
+		cfg.WriteStderr("failed to store token")
+		return err
+	}
+	return nil
+}
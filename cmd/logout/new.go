@@ -0,0 +1,97 @@
+package logout
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/zitadel/oidc/v2/pkg/client/rp"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+type Result struct {
+	Profile string `json:"profile"`
+	Revoked bool   `json:"revoked"`
+	Removed bool   `json:"removed"`
+}
+
+type Config struct {
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "logout",
+		Short:  "Logout of NoOps",
+		Long:   `Revokes the stored token with the issuer and removes it from the keyring`,
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			return Logout(ctx)
+		},
+	}
+	return cmd
+}
+
+// Revoke revokes the refresh token, or the access token when there is no
+// refresh token. It returns false when the issuer has no revocation endpoint.
+func Revoke(provider rp.RelyingParty, token *oidc.Tokens[*oidc.IDTokenClaims]) (bool, error) {
+	if token == nil || token.Token == nil {
+		return false, nil
+	}
+	if len(provider.GetRevokeEndpoint()) == 0 {
+		return false, nil
+	}
+
+	if len(token.RefreshToken) > 0 {
+		if err := rp.RevokeToken(provider, token.RefreshToken, "refresh_token"); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if err := rp.RevokeToken(provider, token.AccessToken, "access_token"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func Logout(ctx context.Context) error {
+	cfg, err := config.New[Config, *Result](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	result := &Result{
+		Profile: cfg.Profile(),
+	}
+	if len(result.Profile) == 0 {
+		result.Profile = config.DefaultProfile
+	}
+
+	if cfg.Token != nil {
+		provider, err := cfg.NewRelyingPartyOIDC(ctx, "")
+		if err != nil {
+			cfg.WriteStderr("failed to create provider")
+			return err
+		}
+
+		// a failed revocation should not stop the local logout.
+		revoked, err := Revoke(provider, cfg.Token)
+		if err != nil {
+			cfg.WriteStderr(fmt.Sprintf("failed to revoke token: %v", err))
+		}
+		result.Revoked = revoked
+		result.Removed = true
+	}
+
+	if err := cfg.DeleteToken(); err != nil {
+		cfg.WriteStderr("failed to remove token")
+		return err
+	}
+
+	cfg.WriteObject(result)
+	return nil
+}
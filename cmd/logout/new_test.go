@@ -0,0 +1,36 @@
+package logout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/oidctest"
+	"github.com/zitadel/oidc/v2/pkg/client/rp"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+func Test_Revoke(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+
+	provider, err := rp.NewRelyingPartyOIDC(issuer.URL, issuer.ClientID, "", "", []string{oidc.ScopeOpenID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := issuer.NewTokens(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := Revoke(provider, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Fatal("expected token to be revoked")
+	}
+	if !issuer.Revoked(tokens.RefreshToken) {
+		t.Fatal("expected issuer to receive the refresh token")
+	}
+}
@@ -0,0 +1,212 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/getnoops/ops/cmd/deploy"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/diagnose"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Follow   bool   `mapstructure:"follow"`
+	Since    string `mapstructure:"since"`
+	Until    string `mapstructure:"until"`
+	Grep     string `mapstructure:"grep"`
+	LogGroup string `mapstructure:"log-group"`
+	Region   string `mapstructure:"region"`
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs [config] [env]",
+		Short: "Show the logs of a deployment",
+		Long: `Streams the CloudWatch logs of the config deployed to the environment.
+
+--since and --until take a duration before now, e.g. 15m, or a time, e.g.
+2024-03-01T10:00:00Z. Logs are printed as json lines with --format json.`,
+		Args:   cobra.ExactArgs(2),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configCode := args[0]
+			environmentCode := args[1]
+
+			ctx := cmd.Context()
+			return Logs(ctx, configCode, environmentCode)
+		},
+		ValidArgs: []string{"config", "env"},
+	}
+
+	util.BindBoolPFlag(cmd, "follow", "f", "Keep streaming new logs until cancelled", false)
+	util.BindStringFlag(cmd, "since", "Show logs after this duration ago or time", "15m")
+	util.BindStringFlag(cmd, "until", "Show logs before this duration ago or time, defaults to now", "")
+	util.BindStringFlag(cmd, "grep", "Only show logs matching the regular expression", "")
+	util.BindStringFlag(cmd, "log-group", "The log group, defaults to the one created for the deployment", "")
+	util.BindStringFlag(cmd, "region", "The region, defaults to the first region of the environment", "")
+	return cmd
+}
+
+// errStop ends the subscription once the last page is shown.
+var errStop = errors.New("stop")
+
+func Logs(ctx context.Context, configCode string, environmentCode string) error {
+	cfg, err := config.New[Config, *queries.Log](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	var grep *regexp.Regexp
+	if len(cfg.Command.Grep) > 0 {
+		grep, err = regexp.Compile(cfg.Command.Grep)
+		if err != nil {
+			return fmt.Errorf("invalid grep %s: %w", cfg.Command.Grep, err)
+		}
+	}
+
+	now := time.Now()
+	since, err := util.ParseTime(cfg.Command.Since, now)
+	if err != nil {
+		return err
+	}
+	startTime := since.UnixMilli()
+
+	var endTime *int64
+	if len(cfg.Command.Until) > 0 {
+		until, err := util.ParseTime(cfg.Command.Until, now)
+		if err != nil {
+			return err
+		}
+		if until.Before(since) {
+			return fmt.Errorf("invalid until %s, it is before since %s", cfg.Command.Until, cfg.Command.Since)
+		}
+		end := until.UnixMilli()
+		endTime = &end
+	} else if !cfg.Command.Follow {
+		end := now.UnixMilli()
+		endTime = &end
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := q.GetConfig(ctx, organisation.Id, configCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get config")
+		return err
+	}
+
+	environment, err := deploy.GetEnvironment(ctx, q, organisation, environmentCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get environment")
+		return err
+	}
+
+	deployment, err := deploy.GetDeployment(config, environment)
+	if err != nil {
+		cfg.WriteStderr(fmt.Sprintf("%s is not deployed to %s", config.Code, environment.Code))
+		return err
+	}
+
+	region := cfg.Command.Region
+	if len(region) == 0 {
+		if len(environment.Regions) == 0 {
+			return fmt.Errorf("environment %s has no regions, use --region", environment.Code)
+		}
+		region = environment.Regions[0]
+	}
+
+	logGroup := cfg.Command.LogGroup
+	if len(logGroup) == 0 {
+		var ok bool
+		if deployment.Stack != nil {
+			logGroup, ok = diagnose.LogGroup(deployment.Stack.Outputs)
+		}
+		if !ok {
+			return fmt.Errorf("no log group found for the deployment, use --log-group")
+		}
+	}
+
+	input := &queries.LogsSubscriptionInput{
+		Id:              uuid.New(),
+		Organisation_id: organisation.Id,
+		Deployment_id:   deployment.Id,
+		Region:          region,
+		Log_group:       logGroup,
+		Start_time:      &startTime,
+		End_time:        endTime,
+	}
+
+	asJSON := strings.ToLower(cfg.Global.Format) == "json"
+	write := func(log *queries.Log) {
+		if grep != nil && !grep.MatchString(log.Message) {
+			return
+		}
+		if asJSON {
+			cfg.WriteObject(log)
+			return
+		}
+		timestamp := time.UnixMilli(log.Timestamp).Format(time.RFC3339)
+		cfg.WriteStdout(fmt.Sprintf("%s %s %s", timestamp, log.Log_stream_name, strings.TrimRight(log.Message, "\n")))
+	}
+
+	for {
+		var last *queries.Log
+		err := q.SubscribeLogs(ctx, input, func(page *queries.LogsOutput) error {
+			for _, log := range page.Logs {
+				write(log)
+				last = log
+			}
+			if page.Next_token == nil && endTime != nil {
+				return errStop
+			}
+			return nil
+		})
+		if errors.Is(err, errStop) {
+			return nil
+		}
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if errors.Is(err, queries.ErrSubscriptionsUnavailable) {
+				cfg.WriteStderr("logs are streamed over a websocket, check it is not blocked by a proxy and api.subscriptions is enabled")
+			} else {
+				cfg.WriteStderr("failed to get logs")
+			}
+			return err
+		}
+		if !cfg.Command.Follow || endTime != nil {
+			return nil
+		}
+
+		// the stream ended, carry on after the last log shown.
+		if input.Next_token == nil && last != nil {
+			start := last.Timestamp + 1
+			input.Start_time = &start
+		}
+		if err := util.Sleep(ctx, cfg.Wait.Interval); err != nil {
+			return err
+		}
+	}
+}
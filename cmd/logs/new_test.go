@@ -0,0 +1,152 @@
+package logs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func Test_Logs(t *testing.T) {
+	now := time.Now()
+	events := []*queries.Log{
+		{Event_id: "1", Log_stream_name: "api/1", Message: "starting", Timestamp: now.Add(-time.Hour).UnixMilli()},
+		{Event_id: "2", Log_stream_name: "api/1", Message: "listening on :8080\n", Timestamp: now.Add(-10 * time.Minute).UnixMilli()},
+		{Event_id: "3", Log_stream_name: "api/1", Message: "GET /health 200", Timestamp: now.Add(-5 * time.Minute).UnixMilli()},
+		{Event_id: "4", Log_stream_name: "api/2", Message: "GET /orders 500", Timestamp: now.Add(-time.Minute).UnixMilli()},
+	}
+
+	cases := []struct {
+		name     string
+		args     []string
+		err      error
+		stderr   string
+		expected []string
+		region   string
+		logGroup string
+	}{
+		{
+			name:     "since 15m by default",
+			args:     []string{"logs", "api", "dev"},
+			expected: []string{"2", "3", "4"},
+		},
+		{
+			name:     "since and until",
+			args:     []string{"logs", "api", "dev", "--since", "2h", "--until", "7m"},
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "grep",
+			args:     []string{"logs", "api", "dev", "--grep", "GET /\\w+ 5\\d\\d"},
+			expected: []string{"4"},
+		},
+		{
+			name:     "region and log group",
+			args:     []string{"logs", "api", "dev", "--region", "us-east-1", "--log-group", "/custom"},
+			expected: []string{},
+			region:   "us-east-1",
+			logGroup: "/custom",
+		},
+		{
+			name:   "not deployed",
+			args:   []string{"logs", "web", "dev"},
+			err:    queries.ErrNotFound,
+			stderr: "web is not deployed to dev",
+		},
+		{
+			name:   "unknown environment",
+			args:   []string{"logs", "api", "prod"},
+			err:    queries.ErrNotFound,
+			stderr: "failed to get environment",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			org := fake.AddOrganisation("noops", "NoOps")
+			env := fake.AddEnvironment(org.Id, "dev")
+			fake.AddConfig(org.Id, "web", queries.ConfigClassCompute)
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			revision := fake.AddRevision(config, "1.0.0")
+
+			deploymentId := uuid.New()
+			if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+				t.Fatal(err)
+			}
+			fake.AddLogs(deploymentId, "/noops/api/dev", events...)
+
+			res := cmdtest.Run(t, fake, append(c.args, "--format", "json")...)
+			if c.err != nil {
+				if !errors.Is(res.Err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if !strings.Contains(res.Stderr, c.stderr) {
+					t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+
+			ids := []string{}
+			for _, line := range strings.Split(strings.TrimSpace(res.Stdout), "\n") {
+				if len(line) == 0 {
+					continue
+				}
+				var log queries.Log
+				if err := json.Unmarshal([]byte(line), &log); err != nil {
+					t.Fatalf("expected a json line, got %q", line)
+				}
+				ids = append(ids, log.Event_id)
+			}
+			if strings.Join(ids, ",") != strings.Join(c.expected, ",") {
+				t.Fatalf("expected events %v, got %v", c.expected, ids)
+			}
+
+			inputs := fake.LogsInputs()
+			if len(inputs) == 0 {
+				t.Fatal("expected the logs to be subscribed")
+			}
+			region, logGroup := "ap-southeast-2", "/noops/api/dev"
+			if len(c.region) > 0 {
+				region, logGroup = c.region, c.logGroup
+			}
+			if inputs[0].Region != region || inputs[0].Log_group != logGroup || inputs[0].Deployment_id != deploymentId {
+				t.Fatalf("unexpected input %+v", inputs[0])
+			}
+		})
+	}
+}
+
+func Test_Logs_Plain(t *testing.T) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	env := fake.AddEnvironment(org.Id, "dev")
+	config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+	revision := fake.AddRevision(config, "1.0.0")
+
+	deploymentId := uuid.New()
+	if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := time.Now().Add(-time.Minute).Truncate(time.Second)
+	fake.AddLogs(deploymentId, "/noops/api/dev", &queries.Log{Event_id: "1", Log_stream_name: "api/1", Message: "listening on :8080\n", Timestamp: timestamp.UnixMilli()})
+
+	res := cmdtest.Run(t, fake, "logs", "api", "dev")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	expected := timestamp.Format(time.RFC3339) + " api/1 listening on :8080\n"
+	if res.Stdout != expected {
+		t.Fatalf("expected %q, got %q", expected, res.Stdout)
+	}
+}
@@ -0,0 +1,365 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getnoops/ops/cmd/deploy"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Since     string        `mapstructure:"since"`
+	Until     string        `mapstructure:"until"`
+	Period    time.Duration `mapstructure:"period"`
+	Statistic string        `mapstructure:"statistic"`
+	Metric    []string      `mapstructure:"metric"`
+	Resource  []string      `mapstructure:"resource"`
+	Dimension []string      `mapstructure:"dimension"`
+	Region    string        `mapstructure:"region"`
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics [config] [env]",
+		Short: "Show the metrics of a deployment",
+		Long: `Shows the CloudWatch metrics of each resource of the config deployed to the
+environment, e.g. CpuUtilized for a container and FreeStorageSpace for a
+database.
+
+--since and --until take a duration before now, e.g. 3h, or a time, e.g.
+2024-03-01T10:00:00Z. --format takes table, csv, json, yaml or spark for
+sparklines.`,
+		Args:   cobra.ExactArgs(2),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configCode := args[0]
+			environmentCode := args[1]
+
+			ctx := cmd.Context()
+			return Metrics(ctx, configCode, environmentCode)
+		},
+		ValidArgs: []string{"config", "env"},
+	}
+
+	util.BindStringFlag(cmd, "since", "Show metrics after this duration ago or time", "3h")
+	util.BindStringFlag(cmd, "until", "Show metrics before this duration ago or time, defaults to now", "")
+	util.BindDurationFlag(cmd, "period", "The period each value is aggregated over", 5*time.Minute)
+	util.BindStringFlag(cmd, "statistic", "The statistic to show instead of each metric's default, e.g. Maximum or p99", "")
+	util.BindStringSliceFlag(cmd, "metric", "The metrics to show instead of the presets for the resource type", []string{})
+	util.BindStringSliceFlag(cmd, "resource", "The codes of the resources to show, defaults to all", []string{})
+	util.BindStringSliceFlag(cmd, "dimension", "A dimension added to every metric, replacing the resource's dimension of the same name, e.g. ServiceName=api", []string{})
+	util.BindStringFlag(cmd, "region", "The region, defaults to the first region of the environment", "")
+	return cmd
+}
+
+// metricQuery is a metric query and the series it fills.
+type metricQuery struct {
+	input  *queries.MetricQueryInput
+	series *Series
+}
+
+func Metrics(ctx context.Context, configCode string, environmentCode string) error {
+	cfg, err := config.New[Config, *Series](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	format := strings.ToLower(cfg.Global.Format)
+	switch format {
+	case "table", "csv", "json", "yaml", "spark":
+	default:
+		return fmt.Errorf("invalid format %s, should be one of: [table,csv,json,yaml,spark]", cfg.Global.Format)
+	}
+
+	now := time.Now()
+	since, err := util.ParseTime(cfg.Command.Since, now)
+	if err != nil {
+		return err
+	}
+	until := now
+	if len(cfg.Command.Until) > 0 {
+		if until, err = util.ParseTime(cfg.Command.Until, now); err != nil {
+			return err
+		}
+	}
+	if !until.After(since) {
+		return fmt.Errorf("invalid until %s, it is not after since %s", cfg.Command.Until, cfg.Command.Since)
+	}
+
+	period, err := Period(cfg.Command.Period)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Command.Statistic) > 0 {
+		if err := ValidateStatistic(cfg.Command.Statistic); err != nil {
+			return err
+		}
+	}
+
+	dimensions := []*queries.MetricDimension{}
+	for _, dimension := range cfg.Command.Dimension {
+		name, value, ok := strings.Cut(dimension, "=")
+		if !ok || len(name) == 0 {
+			return fmt.Errorf("invalid dimension %s, should be Name=Value", dimension)
+		}
+		dimensions = append(dimensions, &queries.MetricDimension{Name: name, Value: value})
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := q.GetConfig(ctx, organisation.Id, configCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get config")
+		return err
+	}
+
+	environment, err := deploy.GetEnvironment(ctx, q, organisation, environmentCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get environment")
+		return err
+	}
+
+	deployment, err := deploy.GetDeployment(config, environment)
+	if err != nil {
+		cfg.WriteStderr(fmt.Sprintf("%s is not deployed to %s", config.Code, environment.Code))
+		return err
+	}
+
+	region := cfg.Command.Region
+	if len(region) == 0 {
+		if len(environment.Regions) == 0 {
+			return fmt.Errorf("environment %s has no regions, use --region", environment.Code)
+		}
+		region = environment.Regions[0]
+	}
+
+	resources, err := selectResources(config, cfg.Command.Resource)
+	if err != nil {
+		return err
+	}
+
+	// the physical ids of the stack's resources identify them in the metrics.
+	stackResources := []*queries.StackResource{}
+	if deployment.Stack != nil && len(deployment.Stack.Name) > 0 {
+		details, err := q.GetStackInfo(ctx, &queries.StackSetInstanceInput{
+			Organisation_id: organisation.Id,
+			Environment_id:  environment.Id,
+			Aws_region:      region,
+			Stack_set_name:  deployment.Stack.Name,
+		})
+		if err != nil {
+			cfg.WriteStderr(fmt.Sprintf("failed to get stack %s", deployment.Stack.Name))
+			return err
+		}
+		stackResources = details.Resources
+	}
+
+	// the metrics of a namespace are fetched together.
+	namespaces := map[string][]*metricQuery{}
+	series := []*Series{}
+	for _, resource := range resources {
+		preset := Presets[resource.Type]
+		resourceDimensions, err := selectDimensions(config, stackResources, resource, dimensions)
+		if err != nil {
+			return err
+		}
+
+		metrics := preset.Metrics
+		if len(cfg.Command.Metric) > 0 {
+			metrics = []PresetMetric{}
+			for _, name := range cfg.Command.Metric {
+				metrics = append(metrics, PresetMetric{Name: name, Statistic: "Average"})
+			}
+		}
+
+		for _, metric := range metrics {
+			statistic := metric.Statistic
+			if len(cfg.Command.Statistic) > 0 {
+				statistic = cfg.Command.Statistic
+			}
+
+			s := &Series{Resource: resource.Code, Type: resource.Type, Metric: metric.Name, Statistic: statistic, Points: []Point{}}
+			series = append(series, s)
+			namespaces[preset.Namespace] = append(namespaces[preset.Namespace], &metricQuery{
+				series: s,
+				input: &queries.MetricQueryInput{
+					Id:               queryId(len(series), resource.Code, metric.Name),
+					Label:            resource.Code + " " + metric.Name,
+					Metric_statistic: statistic,
+					Metric_name:      metric.Name,
+					Period:           period,
+					Dimensions:       resourceDimensions,
+				},
+			})
+		}
+	}
+
+	names := []string{}
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	for _, namespace := range names {
+		byId := map[string]*metricQuery{}
+		input := &queries.MetricsInput{
+			Organisation_id:  organisation.Id,
+			Deployment_id:    deployment.Id,
+			Region:           region,
+			Start_time:       int(since.Unix()),
+			End_time:         int(until.Unix()),
+			Metric_queries:   []*queries.MetricQueryInput{},
+			Metric_namespace: namespace,
+		}
+		for _, query := range namespaces[namespace] {
+			byId[query.input.Id] = query
+			input.Metric_queries = append(input.Metric_queries, query.input)
+		}
+
+		out, err := q.GetMetrics(ctx, input)
+		if err != nil {
+			cfg.WriteStderr(fmt.Sprintf("failed to get %s metrics", namespace))
+			return err
+		}
+		for _, message := range out.Messages {
+			cfg.WriteStderr(fmt.Sprintf("%s: %s", message.Code, message.Value))
+		}
+		for _, result := range out.Metric_data_results {
+			query, ok := byId[result.Id]
+			if !ok {
+				continue
+			}
+			query.series.Status = result.Status_code
+			query.series.Points = newSeries(result)
+			if result.Status_code != queries.MetricStatusCodeComplete {
+				cfg.WriteStderr(fmt.Sprintf("%s %s: %s", query.series.Resource, query.series.Metric, result.Status_code))
+			}
+		}
+	}
+
+	switch format {
+	case "csv":
+		return WriteCSV(cfg.Stdout(), series)
+	case "spark":
+		WriteSparklines(cfg.Stdout(), series)
+	case "table":
+		WriteTable(cfg.Stdout(), series)
+	default:
+		cfg.WriteList(series)
+	}
+	return nil
+}
+
+// selectResources returns the resources with the codes, or every resource
+// with metrics when no codes are given.
+func selectResources(config *queries.Config, codes []string) ([]*queries.Resources, error) {
+	if len(codes) == 0 {
+		out := []*queries.Resources{}
+		for _, resource := range config.Resources {
+			if _, ok := Presets[resource.Type]; ok {
+				out = append(out, resource)
+			}
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("%s has no resources with metrics", config.Code)
+		}
+		return out, nil
+	}
+
+	out := []*queries.Resources{}
+	for _, code := range codes {
+		var found *queries.Resources
+		for _, resource := range config.Resources {
+			if resource.Code == code {
+				found = resource
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("resource %s of %s: %w", code, config.Code, queries.ErrNotFound)
+		}
+		if _, ok := Presets[found.Type]; !ok {
+			return nil, fmt.Errorf("resource %s is a %s which has no metrics", code, found.Type)
+		}
+		out = append(out, found)
+	}
+	return out, nil
+}
+
+// selectDimensions returns the dimensions identifying the resource, from the
+// physical id of its resource in the stack of the deployment, with the
+// dimensions given replacing those of the same name. The stack resource is
+// the one whose logical id is the code of the resource, or the only one of
+// its type when the config has one resource of the type.
+func selectDimensions(config *queries.Config, stackResources []*queries.StackResource, resource *queries.Resources, dimensions []*queries.MetricDimension) ([]*queries.MetricDimension, error) {
+	preset := Presets[resource.Type]
+
+	candidates := []*queries.StackResource{}
+	for _, stackResource := range stackResources {
+		if stackResource.Resource_type == preset.ResourceType && len(stackResource.Physical_resource_id) > 0 {
+			candidates = append(candidates, stackResource)
+		}
+	}
+
+	var found *queries.StackResource
+	code := strings.ToLower(logicalIdPattern.ReplaceAllString(resource.Code, ""))
+	for _, candidate := range candidates {
+		if strings.ToLower(candidate.Logical_resource_id) == code {
+			found = candidate
+			break
+		}
+	}
+	if found == nil && len(candidates) == 1 {
+		count := 0
+		for _, other := range config.Resources {
+			if other.Type == resource.Type {
+				count++
+			}
+		}
+		if count == 1 {
+			found = candidates[0]
+		}
+	}
+
+	if found == nil {
+		if len(dimensions) == 0 {
+			return nil, fmt.Errorf("resource %s has no %s in the stack of its deployment, use --resource and --dimension to identify it", resource.Code, preset.ResourceType)
+		}
+		return dimensions, nil
+	}
+
+	out := preset.Dimensions(found.Physical_resource_id)
+	for _, dimension := range dimensions {
+		replaced := false
+		for i, existing := range out {
+			if existing.Name == dimension.Name {
+				out[i] = dimension
+				replaced = true
+			}
+		}
+		if !replaced {
+			out = append(out, dimension)
+		}
+	}
+	return out, nil
+}
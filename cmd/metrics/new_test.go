@@ -0,0 +1,233 @@
+package metrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/cmd/metrics"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func setup(t *testing.T) (*queriestest.Fake, uuid.UUID) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	env := fake.AddEnvironment(org.Id, "dev")
+	config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+	config.Resources = []*queries.Resources{
+		{Code: "api", Type: queries.ResourceTypeContainer},
+		{Code: "db", Type: queries.ResourceTypeDatabase},
+		{Code: "files", Type: queries.ResourceTypeBucket},
+	}
+	revision := fake.AddRevision(config, "1.0.0")
+
+	deploymentId := uuid.New()
+	if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetStack(deploymentId, &queries.StackDetails{
+		Resources: []*queries.StackResource{
+			{Logical_resource_id: "api", Resource_type: "AWS::ECS::Service", Physical_resource_id: "arn:aws:ecs:ap-southeast-2:123456789012:service/noops-dev/api-dev"},
+			{Logical_resource_id: "db", Resource_type: "AWS::RDS::DBInstance", Physical_resource_id: "api-db-dev"},
+		},
+	})
+	return fake, deploymentId
+}
+
+// dimensions returns the dimensions of the query as Name=Value.
+func dimensions(query *queries.MetricQueryInput) string {
+	out := []string{}
+	for _, dimension := range query.Dimensions {
+		out = append(out, dimension.Name+"="+dimension.Value)
+	}
+	return strings.Join(out, ",")
+}
+
+func Test_Metrics(t *testing.T) {
+	fake, deploymentId := setup(t)
+
+	res := cmdtest.Run(t, fake, "metrics", "api", "dev", "--format", "json")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	series := []*metrics.Series{}
+	if err := json.Unmarshal([]byte(res.Stdout), &series); err != nil {
+		t.Fatalf("expected json, got %q", res.Stdout)
+	}
+
+	// the presets of the container and database, buckets have none.
+	expected := []string{"api CpuUtilized Average", "api MemoryUtilized Average", "api RunningTaskCount Average", "db CPUUtilization Average", "db FreeStorageSpace Minimum", "db DatabaseConnections Average"}
+	names := []string{}
+	for _, s := range series {
+		names = append(names, s.Resource+" "+s.Metric+" "+s.Statistic)
+		if len(s.Points) != 36 || s.Status != queries.MetricStatusCodeComplete {
+			t.Fatalf("expected 36 points over 3h, got %d %s", len(s.Points), s.Status)
+		}
+	}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	inputs := fake.MetricsInputs()
+	if len(inputs) != 2 || inputs[0].Metric_namespace != "AWS/RDS" || inputs[1].Metric_namespace != "ECS/ContainerInsights" {
+		t.Fatalf("expected a call per namespace, got %+v", inputs)
+	}
+	input := inputs[0]
+	if input.Deployment_id != deploymentId || input.Region != "ap-southeast-2" || input.End_time-input.Start_time != 3*60*60 || input.Metric_queries[0].Period != 300 {
+		t.Fatalf("unexpected input %+v", input)
+	}
+
+	// each query is of the resource it is for.
+	if out := dimensions(inputs[0].Metric_queries[0]); out != "DBInstanceIdentifier=api-db-dev" {
+		t.Fatalf("unexpected database dimensions %s", out)
+	}
+	if out := dimensions(inputs[1].Metric_queries[0]); out != "ClusterName=noops-dev,ServiceName=api-dev" {
+		t.Fatalf("unexpected container dimensions %s", out)
+	}
+}
+
+func Test_Metrics_Dimensions(t *testing.T) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	env := fake.AddEnvironment(org.Id, "dev")
+	config := fake.AddConfig(org.Id, "orders", queries.ConfigClassCompute)
+	config.Resources = []*queries.Resources{
+		{Code: "orders", Type: queries.ResourceTypeQueue},
+		{Code: "orders-dlq", Type: queries.ResourceTypeQueue},
+	}
+	revision := fake.AddRevision(config, "1.0.0")
+	deploymentId := uuid.New()
+	if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	stack := &queries.StackDetails{
+		Resources: []*queries.StackResource{
+			{Logical_resource_id: "ordersdlq", Resource_type: "AWS::SQS::Queue", Physical_resource_id: "https://sqs.ap-southeast-2.amazonaws.com/123456789012/orders-dlq-dev"},
+			{Logical_resource_id: "orders", Resource_type: "AWS::SQS::Queue", Physical_resource_id: "https://sqs.ap-southeast-2.amazonaws.com/123456789012/orders-dev"},
+		},
+	}
+	fake.SetStack(deploymentId, stack)
+
+	res := cmdtest.Run(t, fake, "metrics", "orders", "dev", "--metric", "NumberOfMessagesSent", "--dimension", "Extra=1")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	inputs := fake.MetricsInputs()
+	if len(inputs) != 1 || len(inputs[0].Metric_queries) != 2 {
+		t.Fatalf("unexpected inputs %+v", inputs)
+	}
+	expected := []string{"QueueName=orders-dev,Extra=1", "QueueName=orders-dlq-dev,Extra=1"}
+	for i, query := range inputs[0].Metric_queries {
+		if out := dimensions(query); out != expected[i] {
+			t.Fatalf("expected %s for %s, got %s", expected[i], query.Label, out)
+		}
+	}
+
+	// a resource missing from the stack can't be told apart from the others.
+	stack.Resources = stack.Resources[:1]
+	res = cmdtest.Run(t, fake, "metrics", "orders", "dev", "--resource", "orders")
+	if res.Err == nil || !strings.Contains(res.Err.Error(), "--dimension") {
+		t.Fatalf("expected an error, got %v", res.Err)
+	}
+}
+
+func Test_Metrics_Flags(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		err   error
+		check func(t *testing.T, fake *queriestest.Fake, res *cmdtest.Result)
+	}{
+		{
+			name: "metric and statistic",
+			args: []string{"--resource", "db", "--metric", "ReadIOPS,WriteIOPS", "--statistic", "p99", "--period", "1m", "--since", "10m", "--dimension", "DBInstanceIdentifier=db-1", "--region", "us-east-1"},
+			check: func(t *testing.T, fake *queriestest.Fake, res *cmdtest.Result) {
+				inputs := fake.MetricsInputs()
+				if len(inputs) != 1 || len(inputs[0].Metric_queries) != 2 || inputs[0].Region != "us-east-1" {
+					t.Fatalf("unexpected inputs %+v", inputs)
+				}
+				query := inputs[0].Metric_queries[1]
+				if query.Metric_name != "WriteIOPS" || query.Metric_statistic != "p99" || query.Period != 60 || query.Dimensions[0].Value != "db-1" {
+					t.Fatalf("unexpected query %+v", query)
+				}
+			},
+		},
+		{
+			name: "csv",
+			args: []string{"--resource", "api", "--metric", "CpuUtilized", "--since", "15m", "--format", "csv"},
+			check: func(t *testing.T, fake *queriestest.Fake, res *cmdtest.Result) {
+				lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+				if len(lines) != 4 || lines[0] != "resource,type,metric,statistic,timestamp,value" || !strings.HasPrefix(lines[1], "api,container,CpuUtilized,Average,") {
+					t.Fatalf("unexpected csv %q", res.Stdout)
+				}
+			},
+		},
+		{
+			name: "spark",
+			args: []string{"--resource", "api", "--since", "15m", "--format", "spark"},
+			check: func(t *testing.T, fake *queriestest.Fake, res *cmdtest.Result) {
+				if !strings.Contains(res.Stdout, "api CpuUtilized       ▁▄█  2 (min 0, max 2)") {
+					t.Fatalf("unexpected sparklines %q", res.Stdout)
+				}
+			},
+		},
+		{
+			name: "table",
+			args: []string{"--resource", "api", "--since", "15m"},
+			check: func(t *testing.T, fake *queriestest.Fake, res *cmdtest.Result) {
+				if !strings.Contains(res.Stdout, "CpuUtilized") || !strings.Contains(res.Stdout, "Latest") {
+					t.Fatalf("unexpected table %q", res.Stdout)
+				}
+			},
+		},
+		{name: "invalid period", args: []string{"--period", "90s"}},
+		{name: "invalid statistic", args: []string{"--statistic", "Median"}},
+		{name: "invalid dimension", args: []string{"--dimension", "db-1"}},
+		{name: "invalid format", args: []string{"--format", "env"}},
+		{name: "unknown resource", args: []string{"--resource", "cache"}, err: queries.ErrNotFound},
+		{name: "resource without metrics", args: []string{"--resource", "files"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake, _ := setup(t)
+
+			res := cmdtest.Run(t, fake, append([]string{"metrics", "api", "dev"}, c.args...)...)
+			if c.check == nil {
+				if res.Err == nil || (c.err != nil && !errors.Is(res.Err, c.err)) {
+					t.Fatalf("expected an error, got %v", res.Err)
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			c.check(t, fake, res)
+		})
+	}
+}
+
+func Test_Sparkline(t *testing.T) {
+	points := []metrics.Point{}
+	for i := 0; i < 8; i++ {
+		points = append(points, metrics.Point{Timestamp: time.Unix(int64(i), 0), Value: float64(i)})
+	}
+	if out := metrics.Sparkline(points, 0); out != "▁▂▃▄▅▆▇█" {
+		t.Fatalf("unexpected sparkline %q", out)
+	}
+	// values are averaged to fit the width.
+	if out := metrics.Sparkline(points, 4); out != "▁▃▅█" {
+		t.Fatalf("unexpected sparkline %q", out)
+	}
+	if out := metrics.Sparkline(points[:3:3], 0); out != "▁▄█" {
+		t.Fatalf("unexpected sparkline %q", out)
+	}
+}
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+)
+
+// PresetMetric is a CloudWatch metric shown by default and the statistic it
+// is shown with.
+type PresetMetric struct {
+	Name      string
+	Statistic string
+}
+
+// Preset is the namespace and metrics shown for a type of resource, the
+// dimensions identifying a resource come from the physical id of its
+// CloudFormation resource.
+type Preset struct {
+	Namespace    string
+	ResourceType string
+	Dimensions   func(physicalId string) []*queries.MetricDimension
+	Metrics      []PresetMetric
+}
+
+// Presets are the metrics shown for each type of resource, buckets have no
+// metrics in the api.
+var Presets = map[queries.ResourceType]Preset{
+	queries.ResourceTypeContainer: {
+		Namespace:    "ECS/ContainerInsights",
+		ResourceType: "AWS::ECS::Service",
+		Dimensions:   serviceDimensions,
+		Metrics: []PresetMetric{
+			{Name: "CpuUtilized", Statistic: "Average"},
+			{Name: "MemoryUtilized", Statistic: "Average"},
+			{Name: "RunningTaskCount", Statistic: "Average"},
+		},
+	},
+	queries.ResourceTypeDatabase: {
+		Namespace:    "AWS/RDS",
+		ResourceType: "AWS::RDS::DBInstance",
+		Dimensions:   dimension("DBInstanceIdentifier", "/"),
+		Metrics: []PresetMetric{
+			{Name: "CPUUtilization", Statistic: "Average"},
+			{Name: "FreeStorageSpace", Statistic: "Minimum"},
+			{Name: "DatabaseConnections", Statistic: "Average"},
+		},
+	},
+	queries.ResourceTypeCluster: {
+		Namespace:    "AWS/RDS",
+		ResourceType: "AWS::RDS::DBCluster",
+		Dimensions:   dimension("DBClusterIdentifier", "/"),
+		Metrics: []PresetMetric{
+			{Name: "CPUUtilization", Statistic: "Average"},
+			{Name: "FreeableMemory", Statistic: "Minimum"},
+			{Name: "DatabaseConnections", Statistic: "Average"},
+		},
+	},
+	queries.ResourceTypeQueue: {
+		Namespace:    "AWS/SQS",
+		ResourceType: "AWS::SQS::Queue",
+		Dimensions:   dimension("QueueName", "/"),
+		Metrics: []PresetMetric{
+			{Name: "ApproximateAgeOfOldestMessage", Statistic: "Maximum"},
+			{Name: "ApproximateNumberOfMessagesVisible", Statistic: "Average"},
+			{Name: "NumberOfMessagesSent", Statistic: "Sum"},
+		},
+	},
+	queries.ResourceTypeNotification: {
+		Namespace:    "AWS/SNS",
+		ResourceType: "AWS::SNS::Topic",
+		Dimensions:   dimension("TopicName", ":"),
+		Metrics: []PresetMetric{
+			{Name: "NumberOfMessagesPublished", Statistic: "Sum"},
+			{Name: "NumberOfNotificationsDelivered", Statistic: "Sum"},
+			{Name: "NumberOfNotificationsFailed", Statistic: "Sum"},
+		},
+	},
+}
+
+// dimension returns the dimension named name with the last part of the
+// physical id after sep, e.g. the name of a queue from its url.
+func dimension(name string, sep string) func(string) []*queries.MetricDimension {
+	return func(physicalId string) []*queries.MetricDimension {
+		parts := strings.Split(physicalId, sep)
+		return []*queries.MetricDimension{{Name: name, Value: parts[len(parts)-1]}}
+	}
+}
+
+// serviceDimensions returns the cluster and service names from the arn of an
+// ECS service, arn:aws:ecs:region:account:service/cluster/service.
+func serviceDimensions(physicalId string) []*queries.MetricDimension {
+	_, resource, _ := strings.Cut(physicalId, ":service/")
+	if len(resource) == 0 {
+		resource = physicalId
+	}
+	parts := strings.Split(resource, "/")
+	if len(parts) < 2 {
+		return []*queries.MetricDimension{{Name: "ServiceName", Value: parts[len(parts)-1]}}
+	}
+	return []*queries.MetricDimension{
+		{Name: "ClusterName", Value: parts[len(parts)-2]},
+		{Name: "ServiceName", Value: parts[len(parts)-1]},
+	}
+}
+
+var (
+	statisticPattern = regexp.MustCompile(`^(Average|Sum|Minimum|Maximum|SampleCount|p(100|\d{1,2}(\.\d+)?))$`)
+	idPattern        = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	logicalIdPattern = regexp.MustCompile(`[^a-zA-Z0-9]`)
+)
+
+// ValidateStatistic checks the statistic is one CloudWatch supports, e.g.
+// Average or p99.
+func ValidateStatistic(statistic string) error {
+	if !statisticPattern.MatchString(statistic) {
+		return fmt.Errorf("invalid statistic %s, should be one of: [Average,Sum,Minimum,Maximum,SampleCount] or a percentile such as p99", statistic)
+	}
+	return nil
+}
+
+// Period returns the period in seconds, CloudWatch takes 1, 5, 10, 30 or a
+// multiple of 60 seconds.
+func Period(period time.Duration) (int, error) {
+	seconds := int(period / time.Second)
+	if time.Duration(seconds)*time.Second != period || seconds <= 0 {
+		return 0, fmt.Errorf("invalid period %s, should be a whole number of seconds", period)
+	}
+	switch {
+	case seconds >= 60 && seconds%60 == 0:
+	case seconds == 1 || seconds == 5 || seconds == 10 || seconds == 30:
+	default:
+		return 0, fmt.Errorf("invalid period %s, should be 1s, 5s, 10s, 30s or a multiple of 1m", period)
+	}
+	return seconds, nil
+}
+
+// queryId is the id of a metric query, CloudWatch ids start with a lower
+// case letter and only contain letters, numbers and underscores.
+func queryId(index int, resource string, metric string) string {
+	return fmt.Sprintf("m%d_%s_%s", index, idPattern.ReplaceAllString(resource, "_"), metric)
+}
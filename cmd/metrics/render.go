@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/getnoops/ops/pkg/queries"
+)
+
+// Point is a value of a metric at a time.
+type Point struct {
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Value     float64   `json:"value" yaml:"value"`
+}
+
+// Series is a metric of a resource over the time window.
+type Series struct {
+	Resource  string                   `json:"resource" yaml:"resource"`
+	Type      queries.ResourceType     `json:"type" yaml:"type"`
+	Metric    string                   `json:"metric" yaml:"metric"`
+	Statistic string                   `json:"statistic" yaml:"statistic"`
+	Status    queries.MetricStatusCode `json:"status" yaml:"status"`
+	Points    []Point                  `json:"points" yaml:"points"`
+}
+
+// newSeries pairs the timestamps and values of the result in time order,
+// CloudWatch returns the newest first.
+func newSeries(result *queries.MetricDataResult) []Point {
+	points := []Point{}
+	for i, timestamp := range result.Timestamps {
+		if i >= len(result.Values) {
+			break
+		}
+		points = append(points, Point{Timestamp: timestamp, Value: result.Values[i]})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
+
+// summary is the latest, minimum, maximum and average of the series, ok is
+// false when it has no points.
+func (s *Series) summary() (latest float64, min float64, max float64, avg float64, ok bool) {
+	if len(s.Points) == 0 {
+		return 0, 0, 0, 0, false
+	}
+	min, max = math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	for _, point := range s.Points {
+		min = math.Min(min, point.Value)
+		max = math.Max(max, point.Value)
+		sum += point.Value
+	}
+	return s.Points[len(s.Points)-1].Value, min, max, sum / float64(len(s.Points)), true
+}
+
+// WriteTable writes a row per series with a summary of its values.
+func WriteTable(w io.Writer, series []*Series) {
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		// without a style the last character of the widest cells is cut off.
+		StyleFunc(func(row, col int) lipgloss.Style { return lipgloss.NewStyle().PaddingRight(1) }).
+		Headers("Resource", "Metric", "Statistic", "Latest", "Min", "Max", "Average", "Points")
+
+	for _, s := range series {
+		latest, min, max, avg, ok := s.summary()
+		if !ok {
+			t.Row(s.Resource, s.Metric, s.Statistic, "-", "-", "-", "-", "0")
+			continue
+		}
+		t.Row(s.Resource, s.Metric, s.Statistic, formatValue(latest), formatValue(min), formatValue(max), formatValue(avg), strconv.Itoa(len(s.Points)))
+	}
+	fmt.Fprintln(w, t.Render())
+}
+
+// WriteCSV writes a row per point.
+func WriteCSV(w io.Writer, series []*Series) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"resource", "type", "metric", "statistic", "timestamp", "value"})
+	for _, s := range series {
+		for _, point := range s.Points {
+			out.Write([]string{s.Resource, string(s.Type), s.Metric, s.Statistic, point.Timestamp.UTC().Format(time.RFC3339), strconv.FormatFloat(point.Value, 'f', -1, 64)})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// sparkWidth is the most bars drawn, longer series are averaged into it.
+const sparkWidth = 60
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// WriteSparklines writes a line per series with its values drawn as bars.
+func WriteSparklines(w io.Writer, series []*Series) {
+	width := 0
+	for _, s := range series {
+		if n := len(s.Resource) + 1 + len(s.Metric); n > width {
+			width = n
+		}
+	}
+
+	for _, s := range series {
+		name := fmt.Sprintf("%-*s", width, s.Resource+" "+s.Metric)
+		latest, min, max, _, ok := s.summary()
+		if !ok {
+			fmt.Fprintf(w, "%s  no data\n", name)
+			continue
+		}
+		fmt.Fprintf(w, "%s  %s  %s (min %s, max %s)\n", name, Sparkline(s.Points, sparkWidth), formatValue(latest), formatValue(min), formatValue(max))
+	}
+}
+
+// Sparkline draws the values as bars scaled between their minimum and
+// maximum, at most width bars are drawn.
+func Sparkline(points []Point, width int) string {
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.Value
+	}
+	if width > 0 && len(values) > width {
+		values = downsample(values, width)
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+
+	var b strings.Builder
+	for _, value := range values {
+		index := 0
+		if max > min {
+			index = int((value - min) / (max - min) * float64(len(sparkBars)-1))
+		}
+		b.WriteRune(sparkBars[index])
+	}
+	return b.String()
+}
+
+// downsample averages the values into width buckets.
+func downsample(values []float64, width int) []float64 {
+	out := make([]float64, width)
+	for i := range out {
+		start := i * len(values) / width
+		end := (i + 1) * len(values) / width
+		sum := 0.0
+		for _, value := range values[start:end] {
+			sum += value
+		}
+		out[i] = sum / float64(end-start)
+	}
+	return out
+}
+
+// formatValue rounds the value to 2 decimal places for display.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,104 @@
+package mockserver
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/mockserver"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var StateFilename = "mock.json"
+
+type Config struct {
+	Addr            string        `mapstructure:"addr" default:"127.0.0.1:4000"`
+	File            string        `mapstructure:"file"`
+	DeployDelay     time.Duration `mapstructure:"deploy-delay" default:"5s"`
+	Fail            []string      `mapstructure:"fail"`
+	FailDeployments bool          `mapstructure:"fail-deployments" default:"false"`
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Run a local mock of the NoOps api",
+		Long: `Serves the api used by ops from a local file so the cli can be used offline.
+
+Point ops at it with:
+  NOOPS_API_GRAPHQL=http://127.0.0.1:4000/graphql NOOPS_API_TOKEN=mock ops ...
+
+A new state has the organisation noops with the environments dev and prod.
+Deployments stay creating for --deploy-delay before they are created, or
+failed with --fail-deployments. Use --fail Operation=CODE to make an
+operation return a graphql error, e.g. --fail CreateSecret=CONFLICT.`,
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			return Serve(ctx)
+		},
+	}
+
+	util.BindStringFlag(cmd, "addr", "The address to listen on", "127.0.0.1:4000")
+	util.BindStringFlag(cmd, "file", "The file the state is kept in, defaults to "+StateFilename+" in the home path", "")
+	util.BindDurationFlag(cmd, "deploy-delay", "How long deployments take", 5*time.Second)
+	util.BindStringSliceFlag(cmd, "fail", "Make an operation fail with a graphql error code, e.g. CreateSecret=CONFLICT", []string{})
+	util.BindBoolFlag(cmd, "fail-deployments", "Make every deployment fail", false)
+	return cmd
+}
+
+func ParseFailures(values []string) (map[string]string, error) {
+	failures := map[string]string{}
+	for _, value := range values {
+		op, code, _ := strings.Cut(value, "=")
+		if len(op) == 0 {
+			return nil, fmt.Errorf("invalid failure %q, expected Operation=CODE", value)
+		}
+		if len(code) == 0 {
+			code = "INTERNAL_SERVER_ERROR"
+		}
+		failures[op] = code
+	}
+	return failures, nil
+}
+
+func Serve(ctx context.Context) error {
+	cfg, err := config.New[Config, string](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	file := cfg.Command.File
+	if len(file) == 0 {
+		file = path.Join(cfg.Home.Path, StateFilename)
+	}
+	file, err = util.ResolvePath(file)
+	if err != nil {
+		return err
+	}
+
+	failures, err := ParseFailures(cfg.Command.Fail)
+	if err != nil {
+		return err
+	}
+
+	server, err := mockserver.New(mockserver.Options{
+		File:            file,
+		DeployDelay:     cfg.Command.DeployDelay,
+		FailDeployments: cfg.Command.FailDeployments,
+		Failures:        failures,
+	})
+	if err != nil {
+		cfg.WriteStderr("failed to read mock state")
+		return err
+	}
+	defer server.Close()
+
+	cfg.WriteStdout(fmt.Sprintf("Serving the mock api on http://%s/graphql with state in %s", cfg.Command.Addr, file))
+	return server.ListenAndServe(ctx, cfg.Command.Addr)
+}
@@ -11,8 +11,9 @@ import (
 )
 
 type ListConfig struct {
-	Page     int `mapstructure:"page" default:"1"`
-	PageSize int `mapstructure:"page-size" default:"10"`
+	Page     int `mapstructure:"page" default:"0"`
+	Limit    int `mapstructure:"limit" default:"0"`
+	PageSize int `mapstructure:"page-size" default:"50"`
 }
 
 func ListCommand() *cobra.Command {
@@ -26,8 +27,10 @@ func ListCommand() *cobra.Command {
 		},
 	}
 
-	util.BindIntFlag(cmd, "page", "The page to load", 1)
-	util.BindIntFlag(cmd, "page-size", "The number of items in the page", 10)
+	util.BindIntFlag(cmd, "limit", "The maximum number of items to list, 0 lists all", 0)
+	util.BindIntFlag(cmd, "page-size", "The number of items requested in each page", 50)
+	util.BindIntFlag(cmd, "page", "The single page to load, 0 loads every page", 0)
+	cmd.Flags().MarkDeprecated("page", "every page is loaded, use --limit to list fewer items")
 	return cmd
 }
 
@@ -37,17 +40,37 @@ func List(ctx context.Context) error {
 		return err
 	}
 
-	queries, err := queries.New(ctx, cfg)
+	q, err := queries.New(ctx, cfg)
 	if err != nil {
 		return err
 	}
 
-	out, err := queries.GetMemberOrganisations(ctx, cfg.Command.Page, cfg.Command.PageSize)
+	opts := queries.PageOptions{
+		PageSize:    cfg.Command.PageSize,
+		Limit:       cfg.Command.Limit,
+		Concurrency: queries.DefaultConcurrency,
+	}
+
+	if cfg.Command.Page > 0 {
+		page, err := q.GetMemberOrganisations(ctx, cfg.Command.Page, cfg.Command.PageSize)
+		if err != nil {
+			cfg.WriteStderr("failed to get member organisations")
+			return err
+		}
+		cfg.WriteList(page.Items)
+		return nil
+	}
+
+	out := []*queries.Organisation{}
+	err = q.EachMemberOrganisation(ctx, opts, func(org *queries.Organisation) error {
+		out = append(out, org)
+		return nil
+	})
 	if err != nil {
 		cfg.WriteStderr("failed to get member organisations")
 		return err
 	}
 
-	cfg.WriteList(out.Items)
+	cfg.WriteList(out)
 	return nil
 }
@@ -0,0 +1,93 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type CreateConfig struct {
+	Api          string   `mapstructure:"api" default:""`
+	Issuer       string   `mapstructure:"issuer" default:""`
+	ClientId     string   `mapstructure:"client-id" default:""`
+	ClientSecret string   `mapstructure:"client-secret" default:""`
+	Scopes       []string `mapstructure:"scopes" default:""`
+	Use          bool     `mapstructure:"use" default:"false"`
+}
+
+func CreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "create [name]",
+		Short:  "Create a profile, use --organisation to set its organisation",
+		Args:   cobra.ExactArgs(1),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			ctx := cmd.Context()
+			return Create(ctx, name)
+		},
+		ValidArgs: []string{"name"},
+	}
+
+	util.BindStringFlag(cmd, "api", "The GraphQL api for the profile", "")
+	util.BindStringFlag(cmd, "issuer", "The auth issuer for the profile", "")
+	util.BindStringFlag(cmd, "client-id", "The auth client id for the profile", "")
+	util.BindStringFlag(cmd, "client-secret", "The client secret for a machine user, it is stored in the keyring", "")
+	util.BindStringSliceFlag(cmd, "scopes", "The auth scopes for the profile", []string{})
+	util.BindBoolFlag(cmd, "use", "Use the profile once created", false)
+	return cmd
+}
+
+func Create(ctx context.Context, name string) error {
+	cfg, err := config.New[CreateConfig, string](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	if name == config.DefaultProfile {
+		return fmt.Errorf("profile name %s is reserved", name)
+	}
+
+	profiles, err := cfg.GetProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles.Profiles[name]; ok {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+
+	profiles.Profiles[name] = &config.Profile{
+		Organisation: cfg.Global.Organisation,
+		Api: config.ProfileApi{
+			GraphQL: cfg.Command.Api,
+		},
+		Auth: config.ProfileAuth{
+			Issuer:   cfg.Command.Issuer,
+			ClientId: cfg.Command.ClientId,
+			Scopes:   cfg.Command.Scopes,
+		},
+	}
+	if cfg.Command.Use {
+		profiles.Current = name
+	}
+
+	if err := cfg.StoreProfiles(profiles); err != nil {
+		cfg.WriteStderr("failed to store profiles")
+		return err
+	}
+
+	if len(cfg.Command.ClientSecret) > 0 {
+		if err := cfg.StoreClientSecret(name, cfg.Command.ClientSecret); err != nil {
+			cfg.WriteStderr("failed to store client secret")
+			return err
+		}
+	}
+
+	cfg.WriteStdout(fmt.Sprintf("Created profile %s", name))
+	return nil
+}
@@ -0,0 +1,46 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type DeleteConfig struct {
+}
+
+func DeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "delete [name]",
+		Short:  "Delete a profile and its stored token",
+		Args:   cobra.ExactArgs(1),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			ctx := cmd.Context()
+			return Delete(ctx, name)
+		},
+		ValidArgs: []string{"name"},
+	}
+	return cmd
+}
+
+func Delete(ctx context.Context, name string) error {
+	cfg, err := config.New[DeleteConfig, string](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.DeleteProfile(name); err != nil {
+		cfg.WriteStderr("failed to delete profile")
+		return err
+	}
+
+	cfg.WriteStdout(fmt.Sprintf("Deleted profile %s", name))
+	return nil
+}
@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"context"
+	"sort"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type ProfileItem struct {
+	Name         string `json:"name"`
+	Active       bool   `json:"active"`
+	Organisation string `json:"organisation"`
+	Api          string `json:"api"`
+	Issuer       string `json:"issuer"`
+}
+
+type ListConfig struct {
+}
+
+func ListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "list",
+		Short:  "list profiles",
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			return List(ctx)
+		},
+	}
+	return cmd
+}
+
+func List(ctx context.Context) error {
+	cfg, err := config.New[ListConfig, *ProfileItem](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	profiles, err := cfg.GetProfiles()
+	if err != nil {
+		return err
+	}
+
+	defaults := &ProfileItem{
+		Name:   config.DefaultProfile,
+		Active: len(cfg.Profile()) == 0,
+	}
+	if defaults.Active {
+		defaults.Organisation = cfg.Organisation
+		defaults.Api = cfg.Api.GraphQL
+		defaults.Issuer = cfg.Auth.Issuer
+	}
+	out := []*ProfileItem{defaults}
+
+	names := make([]string, 0, len(profiles.Profiles))
+	for name := range profiles.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		profile := profiles.Profiles[name]
+		out = append(out, &ProfileItem{
+			Name:         name,
+			Active:       name == cfg.Profile(),
+			Organisation: profile.Organisation,
+			Api:          profile.Api.GraphQL,
+			Issuer:       profile.Auth.Issuer,
+		})
+	}
+
+	cfg.WriteList(out)
+	return nil
+}
@@ -0,0 +1,21 @@
+package profile
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type Config struct {
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Profile commands",
+	}
+
+	cmd.AddCommand(ListCommand())
+	cmd.AddCommand(CreateCommand())
+	cmd.AddCommand(UseCommand())
+	cmd.AddCommand(DeleteCommand())
+	return cmd
+}
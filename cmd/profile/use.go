@@ -0,0 +1,61 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type UseConfig struct {
+}
+
+func UseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "use [name]",
+		Short:  "Switch to a profile, use default to switch back to the defaults",
+		Args:   cobra.ExactArgs(1),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			ctx := cmd.Context()
+			return Use(ctx, name)
+		},
+		ValidArgs: []string{"name"},
+	}
+	return cmd
+}
+
+func Use(ctx context.Context, name string) error {
+	cfg, err := config.New[UseConfig, string](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	profiles, err := cfg.GetProfiles()
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case config.DefaultProfile:
+		profiles.Current = ""
+	default:
+		if _, ok := profiles.Profiles[name]; !ok {
+			return fmt.Errorf("profile %s not found", name)
+		}
+		profiles.Current = name
+	}
+
+	if err := cfg.StoreProfiles(profiles); err != nil {
+		cfg.WriteStderr("failed to store profiles")
+		return err
+	}
+
+	cfg.WriteStdout(fmt.Sprintf("Using profile %s", name))
+	return nil
+}
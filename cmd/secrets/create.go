@@ -7,6 +7,7 @@ import (
 	"github.com/getnoops/ops/pkg/config"
 	"github.com/getnoops/ops/pkg/queries"
 	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -24,12 +25,13 @@ func GetEnvironment(ctx context.Context, q queries.Queries, organisation *querie
 		return nil, err
 	}
 	if len(paged.Items) == 0 {
-		return nil, fmt.Errorf("environment not found")
+		return nil, fmt.Errorf("environment %s: %w", code, queries.ErrNotFound)
 	}
 	return paged.Items[0], nil
 }
 
 type CreateSecret struct {
+	waiter.Flags `mapstructure:",squash"`
 }
 
 func CreateCommand() *cobra.Command {
@@ -49,6 +51,8 @@ func CreateCommand() *cobra.Command {
 		},
 		ValidArgs: []string{"compute", "env", "code", "value"},
 	}
+
+	waiter.BindFlags(cmd, "Watch the secret until it is created")
 	return cmd
 }
 
@@ -65,8 +69,8 @@ func Create(ctx context.Context, computeCode string, environmentCode string, cod
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -92,5 +96,15 @@ func Create(ctx context.Context, computeCode string, environmentCode string, cod
 	}
 
 	cfg.WriteObject(out)
+
+	if cfg.Command.Watch {
+		renderer := waiter.NewRenderer(cfg.Global.Format, cfg.Stdout())
+		defer renderer.Done()
+
+		if _, err := waiter.Secret(ctx, q, organisation.Id, config.Code, id, cfg.Command.Options(cfg.Wait, renderer)); err != nil {
+			cfg.WriteStderr("failed to watch secret")
+			return err
+		}
+	}
 	return nil
 }
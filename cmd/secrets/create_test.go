@@ -0,0 +1,111 @@
+package secrets_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func Test_Create(t *testing.T) {
+	cases := []struct {
+		name   string
+		args   []string
+		err    error
+		stderr string
+		setup  func(fake *queriestest.Fake)
+	}{
+		{
+			name: "creates secret",
+			args: []string{"secrets", "create", "api", "dev", "DB_PASSWORD", "hunter2"},
+		},
+		{
+			name: "selects organisation",
+			args: []string{"secrets", "create", "api", "dev", "DB_PASSWORD", "hunter2", "--organisation", "NOOPS"},
+			setup: func(fake *queriestest.Fake) {
+				fake.AddOrganisation("other", "Other")
+			},
+		},
+		{
+			name:   "no organisation",
+			args:   []string{"secrets", "create", "api", "dev", "DB_PASSWORD", "hunter2"},
+			err:    config.ErrNoOrganisation,
+			stderr: "no organisation set",
+			setup: func(fake *queriestest.Fake) {
+				fake.AddOrganisation("other", "Other")
+			},
+		},
+		{
+			name:   "unknown config",
+			args:   []string{"secrets", "create", "web", "dev", "DB_PASSWORD", "hunter2"},
+			err:    queries.ErrNotFound,
+			stderr: "failed to get configs",
+		},
+		{
+			name:   "unknown environment",
+			args:   []string{"secrets", "create", "api", "prod", "DB_PASSWORD", "hunter2"},
+			err:    queries.ErrNotFound,
+			stderr: "failed to get environment",
+		},
+		{
+			name:   "api error",
+			args:   []string{"secrets", "create", "api", "dev", "DB_PASSWORD", "hunter2"},
+			err:    queries.ErrValidation,
+			stderr: "failed to create secret",
+			setup: func(fake *queriestest.Fake) {
+				fake.Errors["CreateSecret"] = &queries.Error{Kind: queries.ErrValidation}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			org := fake.AddOrganisation("noops", "NoOps")
+			fake.AddEnvironment(org.Id, "dev")
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			if c.setup != nil {
+				c.setup(fake)
+			}
+
+			res := cmdtest.Run(t, fake, append(c.args, "--format", "json")...)
+			if c.err != nil {
+				if !errors.Is(res.Err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if !strings.Contains(res.Stderr, c.stderr) {
+					t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+				}
+				if len(config.Secrets) != 0 {
+					t.Fatalf("expected no secrets, got %d", len(config.Secrets))
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+
+			var id uuid.UUID
+			if err := json.Unmarshal([]byte(res.Stdout), &id); err != nil {
+				t.Fatalf("expected secret id, got %q", res.Stdout)
+			}
+			if len(config.Secrets) != 1 {
+				t.Fatalf("expected 1 secret, got %d", len(config.Secrets))
+			}
+
+			secret := config.Secrets[0]
+			if secret.Id != id || secret.Code != "DB_PASSWORD" || secret.Environment.Code != "dev" {
+				t.Fatalf("unexpected secret %+v", secret)
+			}
+			if value, _ := fake.SecretValue(id); value != "hunter2" {
+				t.Fatalf("expected value hunter2, got %q", value)
+			}
+		})
+	}
+}
@@ -55,8 +55,8 @@ func Get(ctx context.Context, computeCode string, environmentCode string, code s
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -96,5 +96,5 @@ func GetSecret(secrets []*queries.SecretItem, environmentCode string, code strin
 		}
 	}
 
-	return nil, fmt.Errorf("secret not found")
+	return nil, fmt.Errorf("secret %s in %s: %w", code, environmentCode, queries.ErrNotFound)
 }
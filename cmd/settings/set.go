@@ -3,6 +3,8 @@ package settings
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
 
 	"github.com/getnoops/ops/pkg/config"
@@ -45,6 +47,22 @@ func Set(ctx context.Context, key string, val string) error {
 		settings["organisation"] = val
 	case "org":
 		settings["organisation"] = val
+	case "keyring.backends":
+		settings["keyring.backends"] = val
+	case "log.level":
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(val)); err != nil {
+			return fmt.Errorf("invalid log level %s, should be one of: [debug,info,warn,error]", val)
+		}
+		settings["log.level"] = strings.ToLower(val)
+	case "api.cacertfile", "api.clientcert", "api.clientkey", "api.proxy", "api.noproxy", "api.subscriptionsurl":
+		settings[strings.ToLower(key)] = val
+	case "api.subscriptions":
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("invalid api.subscriptions %s, should be true or false", val)
+		}
+		settings["api.subscriptions"] = strconv.FormatBool(enabled)
 	default:
 		return fmt.Errorf("unknown setting %s, should be one of: [%s]", key, strings.Join(ValidProps, ","))
 	}
@@ -11,7 +11,7 @@ import (
 	"github.com/spf13/viper"
 )
 
-var ValidProps = []string{"organisation", "org"}
+var ValidProps = []string{"organisation", "org", "keyring.backends", "log.level", "api.cacertfile", "api.clientcert", "api.clientkey", "api.proxy", "api.noproxy", "api.subscriptions", "api.subscriptionsurl"}
 
 type UnsetConfig struct {
 }
@@ -47,6 +47,12 @@ func Unset(ctx context.Context, key string) error {
 		delete(settings, "organisation")
 	case "org":
 		delete(settings, "organisation")
+	case "keyring.backends":
+		delete(settings, "keyring.backends")
+	case "log.level":
+		delete(settings, "log.level")
+	case "api.cacertfile", "api.clientcert", "api.clientkey", "api.proxy", "api.noproxy", "api.subscriptions", "api.subscriptionsurl":
+		delete(settings, strings.ToLower(key))
 	default:
 		return fmt.Errorf("unknown setting %s, should be one of: [%s]", key, strings.Join(ValidProps, ","))
 	}
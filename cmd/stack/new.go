@@ -0,0 +1,119 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/getnoops/ops/cmd/deploy"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Region string `mapstructure:"region"`
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stack [config] [env]",
+		Short: "Show the CloudFormation stack of a deployment",
+		Long: `Shows the status of the CloudFormation stack of the config deployed to the
+environment and each of its resources in the order they changed, failed
+resources are highlighted with the reason they failed.
+
+--format takes table, json or yaml.`,
+		Args:   cobra.ExactArgs(2),
+		PreRun: util.BindPreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configCode := args[0]
+			environmentCode := args[1]
+
+			ctx := cmd.Context()
+			return Show(ctx, configCode, environmentCode)
+		},
+		ValidArgs: []string{"config", "env"},
+	}
+
+	util.BindStringFlag(cmd, "region", "The region, defaults to the first region of the environment", "")
+	return cmd
+}
+
+func Show(ctx context.Context, configCode string, environmentCode string) error {
+	cfg, err := config.New[Config, *Stack](ctx, viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	format := strings.ToLower(cfg.Global.Format)
+	switch format {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("invalid format %s, should be one of: [table,json,yaml]", cfg.Global.Format)
+	}
+
+	q, err := queries.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	organisation, err := q.GetCurrentOrganisation(ctx)
+	if err == config.ErrNoOrganisation {
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := q.GetConfig(ctx, organisation.Id, configCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get config")
+		return err
+	}
+
+	environment, err := deploy.GetEnvironment(ctx, q, organisation, environmentCode)
+	if err != nil {
+		cfg.WriteStderr("failed to get environment")
+		return err
+	}
+
+	deployment, err := deploy.GetDeployment(config, environment)
+	if err != nil {
+		cfg.WriteStderr(fmt.Sprintf("%s is not deployed to %s", config.Code, environment.Code))
+		return err
+	}
+	if deployment.Stack == nil || len(deployment.Stack.Name) == 0 {
+		return fmt.Errorf("the stack of %s in %s has not been created yet", config.Code, environment.Code)
+	}
+
+	region := cfg.Command.Region
+	if len(region) == 0 {
+		if len(environment.Regions) == 0 {
+			return fmt.Errorf("environment %s has no regions, use --region", environment.Code)
+		}
+		region = environment.Regions[0]
+	}
+
+	details, err := q.GetStackInfo(ctx, &queries.StackSetInstanceInput{
+		Organisation_id: organisation.Id,
+		Environment_id:  environment.Id,
+		Aws_region:      region,
+		Stack_set_name:  deployment.Stack.Name,
+	})
+	if err != nil {
+		cfg.WriteStderr(fmt.Sprintf("failed to get stack %s", deployment.Stack.Name))
+		return err
+	}
+
+	stack := NewStack(deployment.Stack.Name, region, details)
+	if format == "table" {
+		WriteTable(cfg.Stdout(), stack)
+		return nil
+	}
+	cfg.WriteObject(stack)
+	return nil
+}
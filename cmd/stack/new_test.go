@@ -0,0 +1,125 @@
+package stack_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/cmd/stack"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func setup(t *testing.T) (*queriestest.Fake, uuid.UUID) {
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	env := fake.AddEnvironment(org.Id, "dev")
+	fake.AddConfig(org.Id, "web", queries.ConfigClassCompute)
+	config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+	revision := fake.AddRevision(config, "1.0.0")
+
+	deploymentId := uuid.New()
+	if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	fake.SetStack(deploymentId, &queries.StackDetails{
+		Status:        "UPDATE_ROLLBACK_COMPLETE",
+		Status_reason: "The following resource(s) failed to update: [Service]",
+		Resources: []*queries.StackResource{
+			{Logical_resource_id: "Queue", Resource_type: "AWS::SQS::Queue", Resource_status: "UPDATE_CANCELLED", Resource_status_reason: "Resource update cancelled", Timestamp: start.Add(3 * time.Minute)},
+			{Logical_resource_id: "Service", Resource_type: "AWS::ECS::Service", Resource_status: "UPDATE_FAILED", Resource_status_reason: "Circuit breaker triggered", Timestamp: start.Add(2 * time.Minute)},
+			{Logical_resource_id: "TaskDefinition", Resource_type: "AWS::ECS::TaskDefinition", Resource_status: "UPDATE_COMPLETE", Timestamp: start},
+		},
+		Outputs: []*queries.StackDetailsOutput{
+			{Output_key: "LogGroupName", Output_value: "/noops/api/dev"},
+		},
+	})
+	return fake, deploymentId
+}
+
+func Test_Stack(t *testing.T) {
+	fake, _ := setup(t)
+
+	res := cmdtest.Run(t, fake, "stack", "api", "dev", "--format", "json")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	var out stack.Stack
+	if err := json.Unmarshal([]byte(res.Stdout), &out); err != nil {
+		t.Fatalf("expected json, got %q", res.Stdout)
+	}
+	if out.Name != "noops-api-dev" || out.Region != "ap-southeast-2" || out.Status != "UPDATE_ROLLBACK_COMPLETE" || len(out.Outputs) != 1 {
+		t.Fatalf("unexpected stack %+v", out)
+	}
+
+	// resources are in the order they changed.
+	ids := []string{}
+	for _, resource := range out.Resources {
+		ids = append(ids, resource.Logical_resource_id)
+	}
+	if strings.Join(ids, ",") != "TaskDefinition,Service,Queue" {
+		t.Fatalf("unexpected resources %v", ids)
+	}
+
+	inputs := fake.StackInfoInputs()
+	if len(inputs) != 1 || inputs[0].Stack_set_name != "noops-api-dev" || inputs[0].Aws_region != "ap-southeast-2" {
+		t.Fatalf("unexpected inputs %+v", inputs)
+	}
+}
+
+func Test_Stack_Table(t *testing.T) {
+	fake, _ := setup(t)
+
+	res := cmdtest.Run(t, fake, "stack", "api", "dev", "--region", "us-east-1")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	for _, expected := range []string{
+		"noops-api-dev in us-east-1: UPDATE_ROLLBACK_COMPLETE",
+		"Circuit breaker triggered",
+		"LogGroupName",
+		"Service (AWS::ECS::Service) failed at",
+	} {
+		if !strings.Contains(res.Stdout, expected) {
+			t.Fatalf("expected %q in %q", expected, res.Stdout)
+		}
+	}
+	if strings.Index(res.Stdout, "TaskDefinition") > strings.Index(res.Stdout, "Queue") {
+		t.Fatalf("expected the resources in the order they changed, got %q", res.Stdout)
+	}
+}
+
+func Test_Stack_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		args   []string
+		err    error
+		stderr string
+	}{
+		{name: "not deployed", args: []string{"stack", "web", "dev"}, err: queries.ErrNotFound, stderr: "web is not deployed to dev"},
+		{name: "unknown environment", args: []string{"stack", "api", "prod"}, err: queries.ErrNotFound, stderr: "failed to get environment"},
+		{name: "invalid format", args: []string{"stack", "api", "dev", "--format", "env"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake, _ := setup(t)
+
+			res := cmdtest.Run(t, fake, c.args...)
+			if res.Err == nil || (c.err != nil && !errors.Is(res.Err, c.err)) {
+				t.Fatalf("expected %v, got %v", c.err, res.Err)
+			}
+			if !strings.Contains(res.Stderr, c.stderr) {
+				t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+			}
+		})
+	}
+}
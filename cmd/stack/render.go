@@ -0,0 +1,111 @@
+package stack
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/getnoops/ops/pkg/diagnose"
+	"github.com/getnoops/ops/pkg/queries"
+)
+
+// Stack is the CloudFormation stack of a deployment in a region.
+type Stack struct {
+	Name         string                        `json:"name" yaml:"name"`
+	Region       string                        `json:"region" yaml:"region"`
+	Status       string                        `json:"status" yaml:"status"`
+	StatusReason string                        `json:"status_reason" yaml:"status_reason"`
+	Resources    []*queries.StackResource      `json:"resources" yaml:"resources"`
+	Outputs      []*queries.StackDetailsOutput `json:"outputs" yaml:"outputs"`
+}
+
+// NewStack returns the stack with its resources in the order they last
+// changed, the oldest first.
+func NewStack(name string, region string, details *queries.StackDetails) *Stack {
+	resources := append([]*queries.StackResource{}, details.Resources...)
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Timestamp.Before(resources[j].Timestamp)
+	})
+
+	outputs := details.Outputs
+	if outputs == nil {
+		outputs = []*queries.StackDetailsOutput{}
+	}
+
+	return &Stack{
+		Name:         name,
+		Region:       region,
+		Status:       details.Status,
+		StatusReason: details.Status_reason,
+		Resources:    resources,
+		Outputs:      outputs,
+	}
+}
+
+// IsFailed reports whether the CloudFormation status is a failure, e.g.
+// CREATE_FAILED or UPDATE_ROLLBACK_COMPLETE.
+func IsFailed(status string) bool {
+	return strings.HasSuffix(status, "_FAILED") || strings.Contains(status, "ROLLBACK")
+}
+
+var failedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+
+// WriteTable writes the status of the stack, a row per resource with failed
+// resources highlighted, its outputs and the first failure.
+func WriteTable(w io.Writer, s *Stack) {
+	status := s.Status
+	if IsFailed(status) {
+		status = failedStyle.Render(status)
+	}
+	fmt.Fprintf(w, "%s in %s: %s\n", s.Name, s.Region, status)
+	if len(s.StatusReason) > 0 {
+		fmt.Fprintf(w, "%s\n", s.StatusReason)
+	}
+
+	if len(s.Resources) > 0 {
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+			// row 0 is the headers, without a style the last character of the
+			// widest cells is cut off.
+			StyleFunc(func(row, col int) lipgloss.Style {
+				style := lipgloss.NewStyle().PaddingRight(1)
+				if row > 0 && diagnose.IsFailedResource(s.Resources[row-1].Resource_status) {
+					return style.Inherit(failedStyle)
+				}
+				return style
+			}).
+			Headers("Time", "Resource", "Type", "Status", "Reason")
+		for _, resource := range s.Resources {
+			t.Row(resource.Timestamp.Local().Format(time.RFC3339), resource.Logical_resource_id, resource.Resource_type, resource.Resource_status, resource.Resource_status_reason)
+		}
+		fmt.Fprintln(w, t.Render())
+	}
+
+	if len(s.Outputs) > 0 {
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+			StyleFunc(func(row, col int) lipgloss.Style { return lipgloss.NewStyle().PaddingRight(1) }).
+			Headers("Output", "Value", "Description")
+		for _, output := range s.Outputs {
+			t.Row(output.Output_key, output.Output_value, output.Description)
+		}
+		fmt.Fprintln(w, t.Render())
+	}
+
+	// later failures are usually caused by the first one.
+	failed := diagnose.FailedResources(s.Resources)
+	if len(failed) > 0 {
+		first := failed[0]
+		failure := fmt.Sprintf("%s (%s) failed at %s: %s", first.Logical_resource_id, first.Resource_type, first.Timestamp.Local().Format(time.RFC3339), first.Resource_status_reason)
+		if len(failed) > 1 {
+			failure = fmt.Sprintf("%d resources failed, the first was %s", len(failed), failure)
+		}
+		fmt.Fprintln(w, failure)
+	}
+}
@@ -3,21 +3,21 @@ package this
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
 
 	"github.com/getnoops/ops/pkg/config"
 	"github.com/getnoops/ops/pkg/models"
 	"github.com/getnoops/ops/pkg/queries"
 	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type DeleteConfig struct {
-	File  string `mapstructure:"file" default:"noops.yaml"`
-	Watch bool   `mapstructure:"watch" default:"false"`
+	File string `mapstructure:"file" default:"noops.yaml"`
+
+	waiter.Flags `mapstructure:",squash"`
 }
 
 func DeleteCommand() *cobra.Command {
@@ -35,28 +35,17 @@ func DeleteCommand() *cobra.Command {
 	}
 
 	util.BindStringPFlag(cmd, "file", "f", "The yaml file with the configuration", "")
-	util.BindBoolFlag(cmd, "watch", "Watch deployment for success", false)
+	waiter.BindFlags(cmd, "Watch deployment until it is deleted")
 	return cmd
 }
 
 func WatchDeployment(ctx context.Context, cfg *config.NoOps[DeleteConfig, *models.Config], q queries.Queries, organisation *queries.Organisation, deploymentId uuid.UUID) error {
-	deployment, err := q.GetDeployment(ctx, organisation.Id, deploymentId)
-	if err != nil {
-		cfg.WriteStderr("failed to get deployment")
-		return err
-	}
-
-	asString := string(deployment.State)
-	if strings.HasSuffix(asString, "ing") {
-		cfg.WriteStdout(fmt.Sprintf("Deployment still %s, waiting 30s", asString))
-		time.Sleep(30 * time.Second)
-		return WatchDeployment(ctx, cfg, q, organisation, deploymentId)
-	}
+	renderer := waiter.NewRenderer(cfg.Global.Format, cfg.Stdout())
+	defer renderer.Done()
 
-	cfg.WriteStdout(fmt.Sprintf("Deployment %s", asString))
-
-	if deployment.State == queries.StackStateFailed {
-		return fmt.Errorf("deployment failed")
+	if _, err := waiter.Deployment(ctx, q, organisation.Id, deploymentId, waiter.DeleteStackStates, cfg.Command.Options(cfg.Wait, renderer)); err != nil {
+		cfg.WriteStderr("failed to watch deployment")
+		return err
 	}
 	return nil
 }
@@ -74,8 +63,8 @@ func Delete(ctx context.Context, environmentCode string) error {
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -93,8 +82,8 @@ func Delete(ctx context.Context, environmentCode string) error {
 
 	config, err := q.GetConfig(ctx, organisation.Id, rev.Code)
 	if err != nil {
-		cfg.WriteStderr("failed to get configs")
-		return nil
+		cfg.WriteStderr("failed to get config")
+		return err
 	}
 
 	environment, err := GetEnvironment(ctx, q, organisation, environmentCode)
@@ -105,8 +94,7 @@ func Delete(ctx context.Context, environmentCode string) error {
 
 	deployment := GetDeployment(ctx, config, environment)
 	if deployment == nil {
-		cfg.WriteStderr("no deployment for environment")
-		return err
+		return fmt.Errorf("no deployment of %s in %s: %w", config.Code, environment.Code, queries.ErrNotFound)
 	}
 
 	if _, err := q.DeleteDeployment(ctx, organisation.Id, deployment.Id); err != nil {
@@ -3,14 +3,15 @@ package this
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/getnoops/ops/cmd/deploy"
 	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/diagnose"
 	"github.com/getnoops/ops/pkg/models"
 	"github.com/getnoops/ops/pkg/queries"
 	"github.com/getnoops/ops/pkg/util"
+	"github.com/getnoops/ops/pkg/waiter"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,7 +22,9 @@ type UpdateConfig struct {
 	File     string   `mapstructure:"file" default:"noops.yaml"`
 	VarFiles []string `mapstructure:"var-file" default:"noops.yaml"`
 	Deploy   string   `mapstructure:"deploy" default:""`
-	Watch    bool     `mapstructure:"watch" default:"false"`
+
+	waiter.Flags          `mapstructure:",squash"`
+	diagnose.FailureFlags `mapstructure:",squash"`
 }
 
 func UpdateCommand() *cobra.Command {
@@ -39,7 +42,8 @@ func UpdateCommand() *cobra.Command {
 	util.BindBoolFlag(cmd, "next", "Use the next minor version", false)
 	util.BindStringFlag(cmd, "deploy", "Deploy the configuration to environment", "")
 	util.BindStringSliceFlag(cmd, "var-file", "Environment like files to update the noops file", []string{})
-	util.BindBoolFlag(cmd, "watch", "Watch deployment for success", false)
+	waiter.BindFlags(cmd, "Watch deployment for success")
+	diagnose.BindFlags(cmd)
 	return cmd
 }
 
@@ -55,7 +59,7 @@ func GetEnvironment(ctx context.Context, q queries.Queries, organisation *querie
 		return nil, err
 	}
 	if len(paged.Items) == 0 {
-		return nil, fmt.Errorf("environment not found")
+		return nil, fmt.Errorf("environment %s: %w", code, queries.ErrNotFound)
 	}
 	return paged.Items[0], nil
 }
@@ -85,28 +89,6 @@ func GetVersion(versionNumber string, next bool) (string, error) {
 	return v.String(), nil
 }
 
-func WatchDeploymentRevision(ctx context.Context, cfg *config.NoOps[UpdateConfig, *models.Config], q queries.Queries, organisation *queries.Organisation, deploymentRevisionId uuid.UUID) error {
-	revision, err := q.GetDeploymentRevision(ctx, organisation.Id, deploymentRevisionId)
-	if err != nil {
-		cfg.WriteStderr("failed to get deployment")
-		return err
-	}
-
-	asString := string(revision.State)
-	if strings.HasSuffix(asString, "ing") {
-		cfg.WriteStdout(fmt.Sprintf("Deployment still %s, waiting 30s", asString))
-		time.Sleep(30 * time.Second)
-		return WatchDeploymentRevision(ctx, cfg, q, organisation, deploymentRevisionId)
-	}
-
-	cfg.WriteStdout(fmt.Sprintf("Deployment %s", asString))
-
-	if revision.State == queries.StackStateFailed {
-		return fmt.Errorf("deployment failed")
-	}
-	return nil
-}
-
 func Deploy(ctx context.Context, cfg *config.NoOps[UpdateConfig, *models.Config], q queries.Queries, organisation *queries.Organisation, environment *queries.Environment, config *queries.Config, configRevisionId uuid.UUID, watch bool) error {
 	if environment == nil {
 		return nil
@@ -126,7 +108,7 @@ func Deploy(ctx context.Context, cfg *config.NoOps[UpdateConfig, *models.Config]
 	cfg.WriteStdout(fmt.Sprintf("Deploying %s to %s", config.Code, environment.Code))
 
 	if watch {
-		return WatchDeploymentRevision(ctx, cfg, q, organisation, deploymentRevisionId)
+		return deploy.WatchDeploymentRevision(ctx, cfg, q, organisation.Id, deploymentRevisionId, cfg.Command.Flags, cfg.Command.FailureFlags)
 	}
 	return nil
 }
@@ -144,8 +126,8 @@ func Upgrade(ctx context.Context) error {
 
 	organisation, err := q.GetCurrentOrganisation(ctx)
 	if err == config.ErrNoOrganisation {
-		cfg.WriteStderr("no organisation set")
-		return nil
+		cfg.WriteStderr("no organisation set, use --organisation or ops settings set organisation <code>")
+		return err
 	}
 	if err != nil {
 		return err
@@ -0,0 +1,172 @@
+package this_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/cmd/cmdtest"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+)
+
+const noopsFile = `name: Api
+code: api
+class: compute
+resources:
+  - code: web
+    type: container
+    data:
+      image: ${IMAGE}
+access:
+  inbound: [web]
+  outbound: []
+`
+
+func writeFile(t *testing.T, name string, data string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func Test_Update(t *testing.T) {
+	cases := []struct {
+		name      string
+		args      []string
+		version   string
+		revisions int
+		deployed  string
+		err       error
+		stdout    string
+		stderr    string
+		setup     func(fake *queriestest.Fake, config *queries.Config)
+	}{
+		{
+			name:      "first version",
+			version:   "0.0.1",
+			revisions: 1,
+			stdout:    "Updated config api 0.0.1",
+		},
+		{
+			name:      "next version",
+			args:      []string{"--next"},
+			version:   "1.0.1",
+			revisions: 2,
+			stdout:    "Updated config api 1.0.1",
+			setup: func(fake *queriestest.Fake, config *queries.Config) {
+				fake.AddRevision(config, "1.0.0")
+			},
+		},
+		{
+			name:      "deploy",
+			args:      []string{"--deploy", "dev", "--watch"},
+			version:   "0.0.1",
+			revisions: 1,
+			deployed:  "dev",
+			stdout:    "Deploying api to dev\nDeployment created",
+		},
+		{
+			name:      "deploy failed",
+			args:      []string{"--deploy", "dev", "--watch"},
+			version:   "0.0.1",
+			revisions: 1,
+			deployed:  "dev",
+			stdout:    "Deployment failed",
+			err:       errors.New("deployment failed"),
+			setup: func(fake *queriestest.Fake, config *queries.Config) {
+				fake.DeploymentState = queries.StackStateFailed
+			},
+		},
+		{
+			name:      "deploy timeout",
+			args:      []string{"--deploy", "dev", "--watch", "--timeout", "50ms"},
+			version:   "0.0.1",
+			revisions: 1,
+			deployed:  "dev",
+			stdout:    "Deployment still creating",
+			err:       context.DeadlineExceeded,
+			setup: func(fake *queriestest.Fake, config *queries.Config) {
+				fake.DeploymentState = queries.StackStateCreating
+			},
+		},
+		{
+			name:   "unknown environment",
+			args:   []string{"--deploy", "prod"},
+			err:    queries.ErrNotFound,
+			stderr: "failed to get environment",
+		},
+		{
+			name:   "unknown config",
+			err:    queries.ErrNotFound,
+			stderr: "failed to get configs",
+			setup: func(fake *queriestest.Fake, config *queries.Config) {
+				config.Code = "web"
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			org := fake.AddOrganisation("noops", "NoOps")
+			fake.AddEnvironment(org.Id, "dev")
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			if c.setup != nil {
+				c.setup(fake, config)
+			}
+
+			t.Setenv("IMAGE", "nginx:latest")
+			file := writeFile(t, "noops.yaml", noopsFile)
+
+			args := append([]string{"this", "update", "--file", file}, c.args...)
+			res := cmdtest.Run(t, fake, args...)
+			if c.err != nil {
+				if res.Err == nil || !(errors.Is(res.Err, c.err) || res.Err.Error() == c.err.Error()) {
+					t.Fatalf("expected %v, got %v", c.err, res.Err)
+				}
+				if !strings.Contains(res.Stderr, c.stderr) {
+					t.Fatalf("expected stderr %q, got %q", c.stderr, res.Stderr)
+				}
+			} else if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if !strings.Contains(res.Stdout, c.stdout) {
+				t.Fatalf("expected stdout %q, got %q", c.stdout, res.Stdout)
+			}
+
+			if len(config.Revisions) != c.revisions {
+				t.Fatalf("expected %d revisions, got %d", c.revisions, len(config.Revisions))
+			}
+			if c.revisions == 0 {
+				return
+			}
+
+			if config.Version_number != c.version {
+				t.Fatalf("expected version %s, got %s", c.version, config.Version_number)
+			}
+			if len(config.Resources) != 1 || config.Resources[0].Data["image"] != "nginx:latest" {
+				t.Fatalf("expected resources from the noops file, got %v", config.Resources)
+			}
+			if config.Access == nil || len(config.Access.Inbound) != 1 {
+				t.Fatalf("expected access from the noops file, got %v", config.Access)
+			}
+
+			if len(c.deployed) == 0 {
+				if len(config.Deployments) != 0 {
+					t.Fatalf("expected no deployments, got %d", len(config.Deployments))
+				}
+				return
+			}
+			if len(config.Deployments) != 1 || config.Deployments[0].Environment.Code != c.deployed {
+				t.Fatalf("expected deployment to %s, got %v", c.deployed, config.Deployments)
+			}
+		})
+	}
+}
@@ -43,7 +43,12 @@ func Update(ctx context.Context) error {
 		return err
 	}
 
-	updater, err := selfupdate.NewUpdater("getnoops/ops", cfg.Command.Prerelease, cfg.Command.Draft)
+	httpClient, err := cfg.HttpClient()
+	if err != nil {
+		return err
+	}
+
+	updater, err := selfupdate.NewUpdater("getnoops/ops", cfg.Command.Prerelease, cfg.Command.Draft, httpClient)
 	if err != nil {
 		return fmt.Errorf("error occurred while creating updater: %w", err)
 	}
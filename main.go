@@ -1,14 +1,82 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/getnoops/ops/cmd"
-	"github.com/spf13/cobra"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
 )
 
+// Exit codes returned by ops, scripts can rely on these to tell failures apart.
+const (
+	// ExitOK is returned when the command succeeds.
+	ExitOK = 0
+	// ExitError is returned for any error not covered below.
+	ExitError = 1
+	// ExitNotFound is returned when an organisation, config, environment or
+	// other resource does not exist, or no organisation is set.
+	ExitNotFound = 3
+	// ExitUnauthenticated is returned when not logged in or the token is rejected.
+	ExitUnauthenticated = 4
+	// ExitForbidden is returned when the account is not allowed to perform the
+	// operation.
+	ExitForbidden = 5
+	// ExitValidation is returned when the api rejects the input.
+	ExitValidation = 6
+	// ExitConflict is returned when the resource already exists or has changed.
+	ExitConflict = 7
+	// ExitTransport is returned when the api could not be reached or returned a
+	// server error.
+	ExitTransport = 8
+	// ExitCancelled is returned when the command was interrupted or timed out.
+	ExitCancelled = 130
+)
+
+// ExitCode maps the error returned by a command to the process exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, queries.ErrNotFound), errors.Is(err, config.ErrNoOrganisation):
+		return ExitNotFound
+	case errors.Is(err, queries.ErrUnauthenticated), errors.Is(err, config.ErrNoToken):
+		return ExitUnauthenticated
+	case errors.Is(err, queries.ErrForbidden):
+		return ExitForbidden
+	case errors.Is(err, queries.ErrValidation):
+		return ExitValidation
+	case errors.Is(err, queries.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, queries.ErrTransport):
+		return ExitTransport
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ExitCancelled
+	default:
+		return ExitError
+	}
+}
+
 func main() {
+	// ctrl-c cancels the command's context, a second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	args := os.Args[1:]
 	rootCmd := cmd.New(os.Stdout, os.Stdin, args)
-	cobra.CheckErr(rootCmd.Execute())
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(ExitCode(err))
+	}
 }
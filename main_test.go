@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+)
+
+func Test_ExitCode(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected int
+	}{
+		{err: nil, expected: ExitOK},
+		{err: errors.New("failed"), expected: ExitError},
+		{err: &queries.Error{Kind: queries.ErrNotFound}, expected: ExitNotFound},
+		{err: fmt.Errorf("environment dev: %w", queries.ErrNotFound), expected: ExitNotFound},
+		{err: config.ErrNoOrganisation, expected: ExitNotFound},
+		{err: config.ErrNoToken, expected: ExitUnauthenticated},
+		{err: &queries.Error{Kind: queries.ErrUnauthenticated}, expected: ExitUnauthenticated},
+		{err: &queries.Error{Kind: queries.ErrForbidden}, expected: ExitForbidden},
+		{err: &queries.Error{Kind: queries.ErrValidation}, expected: ExitValidation},
+		{err: &queries.Error{Kind: queries.ErrConflict}, expected: ExitConflict},
+		{err: &queries.Error{Kind: queries.ErrTransport}, expected: ExitTransport},
+		{err: context.Canceled, expected: ExitCancelled},
+		{err: &queries.Error{Operation: "NewDeployment", MaybeApplied: true, Err: context.DeadlineExceeded}, expected: ExitCancelled},
+	}
+
+	for _, c := range cases {
+		if code := ExitCode(c.err); code != c.expected {
+			t.Fatalf("expected exit code %d for %v, got %d", c.expected, c.err, code)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path"
@@ -29,9 +30,20 @@ import (
 
 var (
 	TokenKey         = "token"
+	ClientTokenKey   = "client_token"
+	ClientSecretKey  = "client_secret"
 	SettingsFilename = "settings.yaml"
 
 	ErrNoOrganisation = errors.New("no organisation set")
+	ErrNoToken        = errors.New("no token found, please login")
+)
+
+const (
+	TokenSourceFlag    = "--token"
+	TokenSourceEnv     = "NOOPS_API_TOKEN"
+	TokenSourceKeyring = "keyring"
+
+	TokenSourceClientCredentials = "client-credentials"
 )
 
 func openSettings(homePath string) (*os.File, error) {
@@ -49,10 +61,16 @@ func openSettings(homePath string) (*os.File, error) {
 type NoOps[C any, T any] struct {
 	Config[C]
 
-	writerStderr *os.File
-	writerStdout *os.File
-	keyring      keyring.Keyring
+	writerStderr   io.Writer
+	writerStdout   io.Writer
+	keyring        keyring.Keyring
+	profile        string
+	tokenKey       string
+	clientTokenKey string
+	httpClient     *http.Client
 
+	// Logger writes to stderr at the level from Log.Level, --verbose or --debug.
+	Logger *slog.Logger
 	Styles Styles
 }
 
@@ -63,14 +81,32 @@ func (c *NoOps[C, T]) getToken(ctx context.Context) (*oauth2.Token, error) {
 	}
 
 	if len(token) > 0 {
+		c.Logger.Debug("using token", "source", c.TokenSource())
 		return &oauth2.Token{
 			TokenType:   "Bearer",
 			AccessToken: token,
 		}, nil
 	}
 
+	if len(c.Auth.ClientSecret) > 0 {
+		token, err := c.ClientCredentialsToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return token.Token, nil
+	}
+
 	if c.Token == nil {
-		return nil, fmt.Errorf("no token found, please login")
+		return nil, ErrNoToken
+	}
+
+	if c.Grant == oidc.GrantTypeTokenExchange {
+		if !c.Token.Valid() {
+			if err := c.exchangeToken(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return c.Token.Token, nil
 	}
 
 	provider, err := c.NewRelyingPartyOIDC(ctx, "")
@@ -81,16 +117,7 @@ func (c *NoOps[C, T]) getToken(ctx context.Context) (*oauth2.Token, error) {
 
 	_, verifyErr := rp.VerifyTokens[*oidc.IDTokenClaims](ctx, c.Token.AccessToken, c.Token.IDToken, provider.IDTokenVerifier())
 	if errors.Is(verifyErr, oidc.ErrExpired) || errors.Is(verifyErr, oidc.ErrSignatureInvalid) {
-		newToken, err := rp.RefreshAccessToken(provider, c.Token.RefreshToken, "", "")
-		if err != nil {
-			c.WriteStderr("failed to refresh token")
-			return nil, err
-		}
-
-		c.Token.Token = newToken
-
-		if err := c.StoreToken(c.Token); err != nil {
-			c.WriteStderr("failed to store token")
+		if err := c.refreshToken(ctx, provider); err != nil {
 			return nil, err
 		}
 	}
@@ -98,25 +125,112 @@ func (c *NoOps[C, T]) getToken(ctx context.Context) (*oauth2.Token, error) {
 	return c.Token.Token, nil
 }
 
+type storedToken struct {
+	Token *oidc.Tokens[*oidc.IDTokenClaims]
+	Grant oidc.GrantType `yaml:"grant,omitempty"`
+}
+
 func (c *NoOps[C, T]) StoreToken(token *oidc.Tokens[*oidc.IDTokenClaims]) error {
-	wrap := struct {
-		Token *oidc.Tokens[*oidc.IDTokenClaims]
-	}{
-		Token: token,
-	}
+	return c.StoreTokenGrant(token, "")
+}
 
-	raw, err := yaml.Marshal(wrap)
+// StoreTokenGrant stores a token along with the grant used to obtain it, tokens
+// which are not from an interactive login are renewed with the same grant.
+func (c *NoOps[C, T]) StoreTokenGrant(token *oidc.Tokens[*oidc.IDTokenClaims], grant oidc.GrantType) error {
+	return c.storeToken(c.tokenKey, token, grant)
+}
+
+// storeToken stores a token and its grant under the keyring entry.
+func (c *NoOps[C, T]) storeToken(key string, token *oidc.Tokens[*oidc.IDTokenClaims], grant oidc.GrantType) error {
+	raw, err := yaml.Marshal(storedToken{
+		Token: token,
+		Grant: grant,
+	})
 	if err != nil {
 		return err
 	}
 
-	return c.keyring.Set(keyring.Item{
-		Key:  TokenKey,
+	if err := c.keyring.Set(keyring.Item{
+		Key:  key,
 		Data: raw,
-	})
+	}); err != nil {
+		return err
+	}
+
+	c.Grant = grant
+	return nil
+}
+
+// AccessToken returns the token used for requests, refreshing it when it has
+// expired, e.g. to authenticate a websocket.
+func (c *NoOps[C, T]) AccessToken(ctx context.Context) (*oauth2.Token, error) {
+	return c.getToken(ctx)
 }
 
+// TokenSource returns where the token used for requests comes from, it
+// follows the same precedence as getToken.
+func (c *NoOps[C, T]) TokenSource() string {
+	if len(c.Api.Token) > 0 {
+		return TokenSourceEnv
+	}
+	if len(c.Global.Token) > 0 {
+		return TokenSourceFlag
+	}
+	if len(c.Auth.ClientSecret) > 0 {
+		return TokenSourceClientCredentials
+	}
+	if c.Token != nil {
+		return TokenSourceKeyring
+	}
+	return ""
+}
+
+// DeleteToken removes the tokens for the active profile from the keyring.
+func (c *NoOps[C, T]) DeleteToken() error {
+	for _, key := range []string{c.tokenKey, c.clientTokenKey} {
+		err := c.keyring.Remove(key)
+		if err != nil && !errors.Is(err, keyring.ErrKeyNotFound) && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	c.Token = nil
+	return nil
+}
+
+// StoreSettings writes the settings file, when a profile is active the
+// organisation is stored against the profile instead.
 func (c *NoOps[C, T]) StoreSettings(settings map[string]string) error {
+	if len(c.profile) > 0 {
+		profiles, err := c.GetProfiles()
+		if err != nil {
+			return err
+		}
+		profile, ok := profiles.Profiles[c.profile]
+		if !ok {
+			return fmt.Errorf("profile %s not found", c.profile)
+		}
+		profile.Organisation = settings["organisation"]
+		if err := c.StoreProfiles(profiles); err != nil {
+			return err
+		}
+
+		current, err := readSettings(c.Home.Path)
+		if err != nil {
+			return err
+		}
+
+		out := map[string]string{}
+		for key, val := range settings {
+			out[key] = val
+		}
+		delete(out, "organisation")
+		if organisation, ok := current["organisation"]; ok {
+			out["organisation"] = organisation
+		}
+		settings = out
+	}
+
 	file, err := openSettings(c.Home.Path)
 	if err != nil {
 		return err
@@ -138,7 +252,26 @@ func (c *NoOps[C, T]) StoreSettings(settings map[string]string) error {
 }
 
 func (c *NoOps[C, T]) GetSettings() (map[string]string, error) {
-	file, err := openSettings(c.Home.Path)
+	out, err := readSettings(c.Home.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.profile) > 0 {
+		profiles, err := c.GetProfiles()
+		if err != nil {
+			return nil, err
+		}
+		delete(out, "organisation")
+		if profile, ok := profiles.Profiles[c.profile]; ok && len(profile.Organisation) > 0 {
+			out["organisation"] = profile.Organisation
+		}
+	}
+	return out, nil
+}
+
+func readSettings(homePath string) (map[string]string, error) {
+	file, err := openSettings(homePath)
 	if err != nil {
 		return nil, err
 	}
@@ -155,14 +288,24 @@ func (c *NoOps[C, T]) GetSettings() (map[string]string, error) {
 }
 
 func (c *NoOps[C, T]) NewRelyingPartyOIDC(ctx context.Context, redirectUri string) (rp.RelyingParty, error) {
-	return rp.NewRelyingPartyOIDC(c.Auth.Issuer, c.Auth.ClientId, "", redirectUri, c.Auth.Scopes, rp.WithPKCE(nil))
+	httpClient, err := c.HttpClient()
+	if err != nil {
+		return nil, err
+	}
+	return rp.NewRelyingPartyOIDC(c.Auth.Issuer, c.Auth.ClientId, "", redirectUri, c.Auth.Scopes, rp.WithPKCE(nil), rp.WithHTTPClient(httpClient))
 }
 
 func (c *NoOps[C, T]) NewHttpClient(ctx context.Context) (*http.Client, error) {
+	httpClient, err := c.HttpClient()
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := c.getToken(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)), nil
 }
 
@@ -188,6 +331,11 @@ func (c *NoOps[C, T]) WriteStdout(out string) {
 	c.writerStdout.Write([]byte("\n"))
 }
 
+// Stdout is where the command writes its output, e.g. progress while waiting.
+func (c *NoOps[C, T]) Stdout() io.Writer {
+	return c.writerStdout
+}
+
 func (c *NoOps[C, T]) WriteList(data []T) {
 	switch strings.ToLower(c.Global.Format) {
 	case "table":
@@ -244,6 +392,26 @@ func (c *NoOps[C, T]) WriteObject(data T) {
 	}
 }
 
+type writersKey struct{}
+
+type writersValue struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// WithWriters returns a context which makes New write the output of a command
+// to stdout and stderr instead of the process streams.
+func WithWriters(ctx context.Context, stdout io.Writer, stderr io.Writer) context.Context {
+	return context.WithValue(ctx, writersKey{}, &writersValue{stdout: stdout, stderr: stderr})
+}
+
+func writers(ctx context.Context) (io.Writer, io.Writer) {
+	if w, ok := ctx.Value(writersKey{}).(*writersValue); ok {
+		return w.stdout, w.stderr
+	}
+	return os.Stdout, os.Stderr
+}
+
 func New[C any, T any](ctx context.Context, v *viper.Viper) (*NoOps[C, T], error) {
 	var config Config[C]
 	defaults.SetDefaults(&config)
@@ -251,17 +419,49 @@ func New[C any, T any](ctx context.Context, v *viper.Viper) (*NoOps[C, T], error
 		return nil, err
 	}
 
+	profiles, err := readProfiles(config.Home.Path)
+	if err != nil {
+		return nil, err
+	}
+	profileName, profile, err := activeProfile(profiles, config.Global.Profile)
+	if err != nil {
+		return nil, err
+	}
+	tokenKey := ProfileTokenKey(profileName)
+
+	settings, err := openSettings(config.Home.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer settings.Close()
+
+	if err := v.MergeConfig(settings); err != nil {
+		return nil, err
+	}
+
+	// the active profile is merged over the settings.
+	if profile != nil {
+		reader, err := profile.reader()
+		if err != nil {
+			return nil, err
+		}
+		if err := v.MergeConfig(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	// the keyring can be configured in the settings.
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, err
+	}
+
 	// read in the auth.
-	ring, err := keyring.Open(keyring.Config{
-		ServiceName:      "No_Ops",
-		FileDir:          config.Home.Path,
-		FilePasswordFunc: keyring.FixedStringPrompt("no_ops"),
-	})
+	ring, err := openKeyring(config.Keyring, config.Home.Path)
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := ring.Get(TokenKey)
+	token, err := getKeyringItem(ring, tokenKey, config.Home.Path)
 	if err != nil && !errors.Is(err, keyring.ErrKeyNotFound) {
 		return nil, err
 	}
@@ -270,10 +470,8 @@ func New[C any, T any](ctx context.Context, v *viper.Viper) (*NoOps[C, T], error
 		return nil, err
 	}
 
-	settings, err := openSettings(config.Home.Path)
-	defer settings.Close()
-
-	if err := v.MergeConfig(settings); err != nil {
+	secret, err := getKeyringItem(ring, ProfileClientSecretKey(profileName), config.Home.Path)
+	if err != nil && !errors.Is(err, keyring.ErrKeyNotFound) {
 		return nil, err
 	}
 
@@ -282,9 +480,23 @@ func New[C any, T any](ctx context.Context, v *viper.Viper) (*NoOps[C, T], error
 		return nil, err
 	}
 
+	// the env takes precedence over a client secret stored for the profile.
+	if len(config.Auth.ClientSecret) == 0 {
+		config.Auth.ClientSecret = string(secret.Data)
+	}
+
 	special := lipgloss.AdaptiveColor{Light: "#43BF6D", Dark: "#73F59F"}
 
-	re := lipgloss.NewRenderer(os.Stdout)
+	stdout, stderr := writers(ctx)
+
+	level, err := logLevel(config.Log, config.Global)
+	if err != nil {
+		return nil, err
+	}
+	logger := newLogger(stderr, level)
+	logger.Debug("loaded settings", "home", config.Home.Path, "profile", profileName, "api", config.Api.GraphQL)
+
+	re := lipgloss.NewRenderer(stdout)
 	descStyle := re.NewStyle().MarginTop(1)
 	urlStyle := re.NewStyle().Foreground(special)
 	titleStyle := re.NewStyle().
@@ -295,10 +507,14 @@ func New[C any, T any](ctx context.Context, v *viper.Viper) (*NoOps[C, T], error
 		Foreground(lipgloss.Color("#FFF7DB"))
 
 	return &NoOps[C, T]{
-		Config:       config,
-		writerStderr: os.Stderr,
-		writerStdout: os.Stdout,
-		keyring:      ring,
+		Config:         config,
+		writerStderr:   stderr,
+		writerStdout:   stdout,
+		keyring:        ring,
+		profile:        profileName,
+		tokenKey:       tokenKey,
+		clientTokenKey: ProfileClientTokenKey(profileName),
+		Logger:         logger,
 		Styles: Styles{
 			Title: titleStyle,
 			Desc:  descStyle,
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+
+	"github.com/99designs/keyring"
+	"github.com/zitadel/oidc/v2/pkg/client"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// StoreClientSecret stores the client secret for a profile in the keyring.
+func (c *NoOps[C, T]) StoreClientSecret(profile string, secret string) error {
+	return c.keyring.Set(keyring.Item{
+		Key:  ProfileClientSecretKey(profile),
+		Data: []byte(secret),
+	})
+}
+
+// MintToken requests an access token from the issuer using the client
+// credentials grant.
+func (c *NoOps[C, T]) MintToken(ctx context.Context) (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	httpClient, err := c.HttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	discovery, err := client.Discover(c.Auth.Issuer, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &clientcredentials.Config{
+		ClientID:     c.Auth.ClientId,
+		ClientSecret: c.Auth.ClientSecret,
+		TokenURL:     discovery.TokenEndpoint,
+		Scopes:       c.Auth.Scopes,
+	}
+
+	token, err := credentials.Token(context.WithValue(ctx, oauth2.HTTPClient, httpClient))
+	if err != nil {
+		return nil, err
+	}
+	return &oidc.Tokens[*oidc.IDTokenClaims]{Token: token}, nil
+}
+
+// ClientCredentialsToken returns the token minted with the client secret, a
+// new one is minted when none is cached or the cached one has expired.
+func (c *NoOps[C, T]) ClientCredentialsToken(ctx context.Context) (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	if c.Token == nil || c.Grant != oidc.GrantTypeClientCredentials || !c.Token.Valid() {
+		if err := c.mintToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return c.Token, nil
+}
+
+// mintToken mints a new access token and caches it in the keyring, like
+// refreshToken it holds the lock and reuses a token another process minted.
+// The token is cached apart from the token of an interactive login so that
+// login is still there once the client secret is no longer used.
+func (c *NoOps[C, T]) mintToken(ctx context.Context) error {
+	unlock, err := c.lockToken(ctx, c.clientTokenKey)
+	if err != nil {
+		c.WriteStderr("failed to lock token")
+		return err
+	}
+	defer unlock()
+
+	stored, grant, err := c.readStoredToken(c.clientTokenKey)
+	if err != nil {
+		c.WriteStderr("failed to read token")
+		return err
+	}
+	if stored != nil && stored.Token != nil && grant == oidc.GrantTypeClientCredentials && stored.Valid() {
+		c.Token = stored
+		c.Grant = grant
+		return nil
+	}
+
+	c.Logger.Info("requesting token with client credentials", "client_id", c.Auth.ClientId)
+	token, err := c.MintToken(ctx)
+	if err != nil {
+		c.WriteStderr("failed to mint token with client credentials")
+		return err
+	}
+
+	if err := c.storeToken(c.clientTokenKey, token, oidc.GrantTypeClientCredentials); err != nil {
+		c.WriteStderr("failed to store token")
+		return err
+	}
+	c.Token = token
+	c.Grant = oidc.GrantTypeClientCredentials
+	return nil
+}
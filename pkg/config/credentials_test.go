@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/oidctest"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"golang.org/x/oauth2"
+)
+
+func Test_GetToken_ClientCredentials(t *testing.T) {
+	issuer := oidctest.NewIssuer("machine")
+	defer issuer.Close()
+	issuer.ClientSecret = "secret"
+
+	homePath := t.TempDir()
+
+	c := newTestNoOps(t, homePath, issuer)
+	c.Auth.ClientSecret = "secret"
+
+	token, err := c.getToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token.AccessToken) == 0 {
+		t.Fatal("expected an access token")
+	}
+	if c.TokenSource() != TokenSourceClientCredentials {
+		t.Fatalf("expected source %s, got %s", TokenSourceClientCredentials, c.TokenSource())
+	}
+
+	// another process reuses the cached token.
+	other := newTestNoOps(t, homePath, issuer)
+	other.Auth.ClientSecret = "secret"
+	cached, err := other.getToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.AccessToken != token.AccessToken {
+		t.Fatalf("expected cached token %s, got %s", token.AccessToken, cached.AccessToken)
+	}
+	if calls := issuer.Calls(string(oidc.GrantTypeClientCredentials)); calls != 1 {
+		t.Fatalf("expected 1 mint, got %d", calls)
+	}
+
+	// once expired a new token is minted.
+	c.Token.Expiry = time.Now().Add(-time.Minute)
+	if err := c.storeToken(c.clientTokenKey, c.Token, oidc.GrantTypeClientCredentials); err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := c.getToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.AccessToken == token.AccessToken {
+		t.Fatal("expected the expired token to be minted again")
+	}
+	if calls := issuer.Calls(string(oidc.GrantTypeClientCredentials)); calls != 2 {
+		t.Fatalf("expected 2 mints, got %d", calls)
+	}
+}
+
+func Test_GetToken_ClientCredentials_KeepsLogin(t *testing.T) {
+	issuer := oidctest.NewIssuer("machine")
+	defer issuer.Close()
+	issuer.ClientSecret = "secret"
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	login := &oidc.Tokens[*oidc.IDTokenClaims]{Token: &oauth2.Token{AccessToken: "interactive", RefreshToken: "refresh"}}
+	if err := c.StoreToken(login); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Auth.ClientSecret = "secret"
+	token, err := c.getToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken == "interactive" {
+		t.Fatal("expected a token minted with client credentials")
+	}
+
+	// the interactive login is still stored for when the secret is unset.
+	stored, grant, err := c.readStoredToken(c.tokenKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == nil || stored.AccessToken != "interactive" || len(grant) > 0 {
+		t.Fatalf("expected the interactive token to be kept, got %+v %s", stored, grant)
+	}
+}
+
+func Test_ClientCredentialsToken(t *testing.T) {
+	issuer := oidctest.NewIssuer("machine")
+	defer issuer.Close()
+	issuer.ClientSecret = "secret"
+
+	homePath := t.TempDir()
+	c := newTestNoOps(t, homePath, issuer)
+	if err := c.StoreToken(&oidc.Tokens[*oidc.IDTokenClaims]{Token: &oauth2.Token{AccessToken: "interactive"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process loads the interactive login and reads the machine token.
+	other := newTestNoOps(t, homePath, issuer)
+	other.Auth.ClientSecret = "secret"
+	token, err := other.ClientCredentialsToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken == "interactive" || other.Grant != oidc.GrantTypeClientCredentials {
+		t.Fatalf("expected the client credentials token, got %s %s", token.AccessToken, other.Grant)
+	}
+
+	// the minted token is reused by the process.
+	again, err := other.ClientCredentialsToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.AccessToken != token.AccessToken {
+		t.Fatalf("expected %s, got %s", token.AccessToken, again.AccessToken)
+	}
+	if calls := issuer.Calls(string(oidc.GrantTypeClientCredentials)); calls != 1 {
+		t.Fatalf("expected 1 mint, got %d", calls)
+	}
+}
+
+func Test_GetToken_ClientCredentials_InvalidSecret(t *testing.T) {
+	issuer := oidctest.NewIssuer("machine")
+	defer issuer.Close()
+	issuer.ClientSecret = "secret"
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.ClientSecret = "wrong"
+
+	if _, err := c.getToken(context.Background()); err == nil {
+		t.Fatal("expected an invalid secret to fail")
+	}
+}
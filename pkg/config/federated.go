@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+
+	"github.com/getnoops/ops/pkg/federated"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+func (c *NoOps[C, T]) federatedOptions() (federated.Options, error) {
+	httpClient, err := c.HttpClient()
+	if err != nil {
+		return federated.Options{}, err
+	}
+
+	audience := c.Auth.Federated.Audience
+	if len(audience) == 0 {
+		audience = c.Auth.ClientId
+	}
+
+	return federated.Options{
+		Audience:   audience,
+		TokenFile:  c.Auth.Federated.TokenFile,
+		TokenEnv:   c.Auth.Federated.TokenEnv,
+		HttpClient: httpClient,
+	}, nil
+}
+
+// ExchangeFederatedToken discovers the CI job's OIDC token and exchanges it
+// with the issuer for a short lived access token.
+func (c *NoOps[C, T]) ExchangeFederatedToken(ctx context.Context) (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	opts, err := c.federatedOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := federated.Lookup(c.Auth.Federated.Provider, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	subjectToken, err := provider.Token(ctx, opts)
+	if err != nil {
+		c.WriteStderr("failed to read " + provider.Name() + " token")
+		return nil, err
+	}
+
+	return federated.Exchange(opts.HttpClient, c.Auth.Issuer, c.Auth.ClientId, subjectToken, c.Auth.Scopes)
+}
+
+// exchangeToken renews an expired federated token, like refreshToken it holds
+// the lock and reuses a token another process has already exchanged.
+func (c *NoOps[C, T]) exchangeToken(ctx context.Context) error {
+	unlock, err := c.lockToken(ctx, c.tokenKey)
+	if err != nil {
+		c.WriteStderr("failed to lock token")
+		return err
+	}
+	defer unlock()
+
+	stored, grant, err := c.readStoredToken(c.tokenKey)
+	if err != nil {
+		c.WriteStderr("failed to read token")
+		return err
+	}
+	if stored != nil && stored.Token != nil && grant == oidc.GrantTypeTokenExchange && stored.Valid() {
+		c.Token = stored
+		return nil
+	}
+
+	c.Logger.Info("exchanging federated token", "provider", c.Auth.Federated.Provider)
+	token, err := c.ExchangeFederatedToken(ctx)
+	if err != nil {
+		c.WriteStderr("failed to exchange federated token")
+		return err
+	}
+
+	if err := c.StoreTokenGrant(token, oidc.GrantTypeTokenExchange); err != nil {
+		c.WriteStderr("failed to store token")
+		return err
+	}
+	c.Token = token
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/oidctest"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"golang.org/x/oauth2"
+)
+
+func Test_GetToken_FederatedExchange(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+	issuer.SubjectToken = "ci-token"
+
+	t.Setenv("NOOPS_ID_TOKEN_TEST", "ci-token")
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.Federated = FederatedConfig{Provider: "env", TokenEnv: "NOOPS_ID_TOKEN_TEST"}
+
+	expired := &oidc.Tokens[*oidc.IDTokenClaims]{
+		Token: &oauth2.Token{
+			AccessToken: "expired",
+			TokenType:   oidc.BearerToken,
+			Expiry:      time.Now().Add(-time.Minute),
+		},
+	}
+	if err := c.StoreTokenGrant(expired, oidc.GrantTypeTokenExchange); err != nil {
+		t.Fatal(err)
+	}
+	c.Token = expired
+
+	token, err := c.getToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken == "expired" {
+		t.Fatal("expected the token to be exchanged again")
+	}
+	if calls := issuer.Calls(string(oidc.GrantTypeTokenExchange)); calls != 1 {
+		t.Fatalf("expected 1 exchange, got %d", calls)
+	}
+
+	// a valid token is used as is.
+	if _, err := c.getToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls := issuer.Calls(string(oidc.GrantTypeTokenExchange)); calls != 1 {
+		t.Fatalf("expected 1 exchange, got %d", calls)
+	}
+
+	stored, err := c.readToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AccessToken != token.AccessToken {
+		t.Fatalf("expected stored token %s, got %s", token.AccessToken, stored.AccessToken)
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/99designs/keyring"
+)
+
+var (
+	KeyringServiceName = "No_Ops"
+
+	// MemoryBackend keeps the keyring in memory for the life of the process,
+	// one per home path. It is only meant for tests and loses every token when
+	// the command exits.
+	MemoryBackend keyring.BackendType = "memory"
+
+	// legacyKeyringPassword is the fixed password file keyrings were
+	// encrypted with before the passphrase was configurable.
+	legacyKeyringPassword = "no_ops"
+
+	memoryMu sync.Mutex
+	memory   = map[string]*memoryKeyring{}
+)
+
+// keyringBackends returns the backends allowed by the config, nil means
+// any backend available on this platform.
+func keyringBackends(cfg KeyringConfig) ([]keyring.BackendType, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, nil
+	}
+
+	valid := map[keyring.BackendType]bool{MemoryBackend: true}
+	for _, backend := range keyring.AvailableBackends() {
+		valid[backend] = true
+	}
+
+	out := []keyring.BackendType{}
+	for _, name := range cfg.Backends {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		backend := keyring.BackendType(name)
+		if !valid[backend] {
+			names := []string{}
+			for backend := range valid {
+				names = append(names, string(backend))
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown keyring backend %s, should be one of: [%s]", name, strings.Join(names, ","))
+		}
+		out = append(out, backend)
+	}
+	return out, nil
+}
+
+func keyringPassword(cfg KeyringConfig) keyring.PromptFunc {
+	if len(cfg.Password) > 0 {
+		return keyring.FixedStringPrompt(cfg.Password)
+	}
+	return func(prompt string) (string, error) {
+		password, err := keyring.TerminalPrompt(prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to read keyring passphrase, set NOOPS_KEYRING_PASSWORD: %w", err)
+		}
+		return password, nil
+	}
+}
+
+func openKeyring(cfg KeyringConfig, homePath string) (keyring.Keyring, error) {
+	backends, err := keyringBackends(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backends) > 0 && backends[0] == MemoryBackend {
+		return memoryKeyringFor(homePath), nil
+	}
+
+	return keyring.Open(keyring.Config{
+		ServiceName:      KeyringServiceName,
+		AllowedBackends:  backends,
+		FileDir:          homePath,
+		FilePasswordFunc: keyringPassword(cfg),
+	})
+}
+
+// getKeyringItem reads the item from the keyring, file keyrings written with
+// the legacy fixed password are migrated to the configured passphrase.
+func getKeyringItem(ring keyring.Keyring, key string, homePath string) (keyring.Item, error) {
+	item, err := ring.Get(key)
+	if err == nil || errors.Is(err, keyring.ErrKeyNotFound) {
+		return item, err
+	}
+
+	legacy, legacyErr := keyring.Open(keyring.Config{
+		ServiceName:      KeyringServiceName,
+		AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+		FileDir:          homePath,
+		FilePasswordFunc: keyring.FixedStringPrompt(legacyKeyringPassword),
+	})
+	if legacyErr != nil {
+		return item, err
+	}
+
+	item, legacyErr = legacy.Get(key)
+	if legacyErr != nil {
+		return item, err
+	}
+
+	if err := ring.Set(item); err != nil {
+		return item, fmt.Errorf("failed to migrate %s to the new keyring passphrase: %w", key, err)
+	}
+	return item, nil
+}
+
+// memoryKeyringFor returns the memory keyring of the home path, so tests with
+// their own home path do not see each other's tokens.
+func memoryKeyringFor(homePath string) *memoryKeyring {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	ring, ok := memory[homePath]
+	if !ok {
+		ring = &memoryKeyring{items: map[string]keyring.Item{}}
+		memory[homePath] = ring
+	}
+	return ring
+}
+
+// memoryKeyring is an in memory keyring which is safe for concurrent use.
+type memoryKeyring struct {
+	mu    sync.Mutex
+	items map[string]keyring.Item
+}
+
+func (k *memoryKeyring) Get(key string) (keyring.Item, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	item, ok := k.items[key]
+	if !ok {
+		return keyring.Item{}, keyring.ErrKeyNotFound
+	}
+	return item, nil
+}
+
+func (k *memoryKeyring) GetMetadata(key string) (keyring.Metadata, error) {
+	return keyring.Metadata{}, keyring.ErrMetadataNotSupported
+}
+
+func (k *memoryKeyring) Set(item keyring.Item) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.items[item.Key] = item
+	return nil
+}
+
+func (k *memoryKeyring) Remove(key string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.items[key]; !ok {
+		return keyring.ErrKeyNotFound
+	}
+	delete(k.items, key)
+	return nil
+}
+
+func (k *memoryKeyring) Keys() ([]string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys := []string{}
+	for key := range k.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+var _ keyring.Keyring = (*memoryKeyring)(nil)
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/99designs/keyring"
+)
+
+func Test_KeyringBackends(t *testing.T) {
+	cases := []struct {
+		backends []string
+		expected []keyring.BackendType
+		err      bool
+	}{
+		{backends: nil, expected: nil},
+		{backends: []string{"memory"}, expected: []keyring.BackendType{MemoryBackend}},
+		{backends: []string{"File", " pass"}, expected: []keyring.BackendType{keyring.FileBackend, keyring.PassBackend}},
+		{backends: []string{"bogus"}, err: true},
+	}
+
+	for _, c := range cases {
+		backends, err := keyringBackends(KeyringConfig{Backends: c.backends})
+		if c.err {
+			if err == nil {
+				t.Fatalf("expected error for %v", c.backends)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backends) != len(c.expected) {
+			t.Fatalf("expected %v, got %v", c.expected, backends)
+		}
+		for n := range backends {
+			if backends[n] != c.expected[n] {
+				t.Fatalf("expected %v, got %v", c.expected, backends)
+			}
+		}
+	}
+}
+
+func Test_GetKeyringItem_MigratesLegacyPassword(t *testing.T) {
+	homePath := t.TempDir()
+
+	legacy, err := keyring.Open(keyring.Config{
+		ServiceName:      KeyringServiceName,
+		AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+		FileDir:          homePath,
+		FilePasswordFunc: keyring.FixedStringPrompt(legacyKeyringPassword),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.Set(keyring.Item{Key: TokenKey, Data: []byte("token: legacy")}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := KeyringConfig{Backends: []string{"file"}, Password: "secret"}
+	ring, err := openKeyring(cfg, homePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := getKeyringItem(ring, TokenKey, homePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "token: legacy" {
+		t.Fatalf("expected legacy token, got %s", item.Data)
+	}
+
+	// a fresh keyring can now read it with the new passphrase only.
+	ring, err = openKeyring(cfg, homePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.Get(TokenKey); err != nil {
+		t.Fatalf("expected token to be migrated, got %v", err)
+	}
+	if _, err := legacy.Get(TokenKey); err == nil {
+		t.Fatal("expected legacy password to no longer work")
+	}
+}
+
+func Test_MemoryKeyring(t *testing.T) {
+	home := t.TempDir()
+	ring, err := openKeyring(KeyringConfig{Backends: []string{"memory"}}, home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Set(keyring.Item{Key: "memory-test", Data: []byte("data")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the memory keyring is shared by the same home path.
+	other, err := openKeyring(KeyringConfig{Backends: []string{"memory"}}, home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := other.Get("memory-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(item.Data) != "data" {
+		t.Fatalf("expected data, got %s", item.Data)
+	}
+
+	// and kept apart from other home paths.
+	isolated, err := openKeyring(KeyringConfig{Backends: []string{"memory"}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := isolated.Get("memory-test"); err != keyring.ErrKeyNotFound {
+		t.Fatalf("expected %v, got %v", keyring.ErrKeyNotFound, err)
+	}
+
+	if err := other.Remove("memory-test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.Get("memory-test"); err != keyring.ErrKeyNotFound {
+		t.Fatalf("expected %v, got %v", keyring.ErrKeyNotFound, err)
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/getnoops/ops/pkg/util"
+)
+
+var lockPollInterval = 50 * time.Millisecond
+
+// lockFile takes an exclusive lock on the file at path, waiting until the
+// lock is free or the context is done. The returned func releases the lock.
+func lockFile(ctx context.Context, path string) (func() error, error) {
+	lockPath, err := util.ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		ok, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() error {
+		defer file.Close()
+		return unlock(file)
+	}, nil
+}
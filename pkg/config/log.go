@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// logLevel resolves the level from the settings, --verbose and --debug take
+// precedence over Log.Level.
+func logLevel(log LogConfig, global GlobalConfig) (slog.Level, error) {
+	if global.Debug {
+		return slog.LevelDebug, nil
+	}
+	if global.Verbose {
+		return slog.LevelInfo, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(log.Level))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q, expected one of: [debug,info,warn,error]", log.Level)
+	}
+	return level, nil
+}
+
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func Test_LogLevel(t *testing.T) {
+	cases := []struct {
+		name   string
+		level  string
+		global GlobalConfig
+		want   slog.Level
+		err    bool
+	}{
+		{name: "default", level: "warn", want: slog.LevelWarn},
+		{name: "settings", level: "DEBUG", want: slog.LevelDebug},
+		{name: "verbose", level: "warn", global: GlobalConfig{Verbose: true}, want: slog.LevelInfo},
+		{name: "debug wins", level: "error", global: GlobalConfig{Verbose: true, Debug: true}, want: slog.LevelDebug},
+		{name: "invalid", level: "loud", err: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			level, err := logLevel(LogConfig{Level: c.level}, c.global)
+			if c.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if level != c.want {
+				t.Fatalf("expected %s, got %s", c.want, level)
+			}
+		})
+	}
+}
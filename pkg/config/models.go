@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/zitadel/oidc/v2/pkg/oidc"
 )
@@ -12,16 +14,72 @@ type HomeConfig struct {
 type ApiConfig struct {
 	GraphQL string `default:"https://api.getnoops.com/graphql"`
 	Token   string `default:""`
+
+	Retries      int           `default:"3"`
+	RetryWait    time.Duration `default:"500ms"`
+	RetryMaxWait time.Duration `default:"10s"`
+
+	// CACertFile is a PEM bundle trusted along with the system roots, e.g.
+	// for a TLS inspecting proxy.
+	CACertFile string `default:""`
+	// ClientCert and ClientKey are a PEM key pair presented for mTLS.
+	ClientCert string `default:""`
+	ClientKey  string `default:""`
+	// InsecureSkipVerify disables certificate verification, development only.
+	InsecureSkipVerify bool `default:"false"`
+	// Proxy overrides HTTPS_PROXY, hosts in the comma separated NoProxy list
+	// are requested directly.
+	Proxy   string `default:""`
+	NoProxy string `default:""`
+
+	// Subscriptions streams deployment progress over a websocket, watching
+	// falls back to polling when it is disabled or blocked.
+	Subscriptions bool `default:"true"`
+	// SubscriptionsURL defaults to the GraphQL url with a ws or wss scheme.
+	SubscriptionsURL string `default:""`
+}
+
+// HttpConfig records the api requests to a file, or replays them from one,
+// a recording can be attached when reporting a bug.
+type HttpConfig struct {
+	Record string `default:""`
+	Replay string `default:""`
+}
+
+// WaitConfig is how --watch polls, --wait-interval and --wait-timeout
+// override it for a command.
+type WaitConfig struct {
+	Interval    time.Duration `default:"2s"`
+	Backoff     float64       `default:"1.5"`
+	MaxInterval time.Duration `default:"30s"`
+	// Timeout stops waiting, 0 waits until the command is cancelled.
+	Timeout time.Duration `default:"30m"`
+}
+
+type FederatedConfig struct {
+	Provider  string `default:""`
+	Audience  string `default:""`
+	TokenFile string `default:""`
+	TokenEnv  string `default:"NOOPS_ID_TOKEN"`
 }
 
 type AuthConfig struct {
 	Issuer   string   `default:"https://account.getnoops.com"`
 	ClientId string   `default:"ops"`
 	Scopes   []string `default:"openid,profile,email,groups,offline_access"`
+	// ClientSecret switches to the client credentials grant for machine users.
+	ClientSecret string `default:""`
+	Federated    FederatedConfig
+}
+
+type KeyringConfig struct {
+	Backends []string `default:""`
+	Password string   `default:""`
 }
 
 type LogConfig struct {
-	Level string `default:"info"`
+	// Level is one of debug, info, warn or error.
+	Level string `default:"warn"`
 }
 
 type Styles struct {
@@ -33,17 +91,26 @@ type Styles struct {
 type GlobalConfig struct {
 	Token        string `mapstructure:"token"`
 	Organisation string `mapstructure:"organisation"`
+	Profile      string `mapstructure:"profile"`
 	Format       string `mapstructure:"format" default:"table"`
+	Verbose      bool   `mapstructure:"verbose"`
+	Debug        bool   `mapstructure:"debug"`
+	// Timeout cancels the command's context, it is applied by the root command.
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
 type Config[C any] struct {
 	Organisation string `mapstructure:"organisation"`
 
 	Token   *oidc.Tokens[*oidc.IDTokenClaims]
+	Grant   oidc.GrantType `mapstructure:"grant"`
 	Command C
 	Global  GlobalConfig
 	Home    HomeConfig
 	Api     ApiConfig
+	Http    HttpConfig
+	Wait    WaitConfig
 	Auth    AuthConfig
+	Keyring KeyringConfig
 	Log     LogConfig
 }
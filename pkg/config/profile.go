@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/99designs/keyring"
+	"github.com/getnoops/ops/pkg/util"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ProfilesFilename = "profiles.yaml"
+	DefaultProfile   = "default"
+)
+
+type ProfileApi struct {
+	GraphQL string `yaml:"graphql,omitempty"`
+}
+
+type ProfileAuth struct {
+	Issuer   string   `yaml:"issuer,omitempty"`
+	ClientId string   `yaml:"clientid,omitempty"`
+	Scopes   []string `yaml:"scopes,omitempty"`
+}
+
+// Profile is a named settings block which is merged over the defaults
+// when it is active.
+type Profile struct {
+	Organisation string      `yaml:"organisation,omitempty"`
+	Api          ProfileApi  `yaml:"api,omitempty"`
+	Auth         ProfileAuth `yaml:"auth,omitempty"`
+}
+
+type Profiles struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// ProfileTokenKey is the keyring entry used to store the token for a profile.
+func ProfileTokenKey(profile string) string {
+	if len(profile) == 0 || profile == DefaultProfile {
+		return TokenKey
+	}
+	return TokenKey + "." + profile
+}
+
+// ProfileClientTokenKey is the keyring entry used to cache the token minted
+// with client credentials for a profile, it is kept apart from the token of an
+// interactive login.
+func ProfileClientTokenKey(profile string) string {
+	if len(profile) == 0 || profile == DefaultProfile {
+		return ClientTokenKey
+	}
+	return ClientTokenKey + "." + profile
+}
+
+// ProfileClientSecretKey is the keyring entry used to store the client secret
+// for a profile using client credentials.
+func ProfileClientSecretKey(profile string) string {
+	if len(profile) == 0 || profile == DefaultProfile {
+		return ClientSecretKey
+	}
+	return ClientSecretKey + "." + profile
+}
+
+func openProfiles(homePath string) (*os.File, error) {
+	profilesPath, err := util.ResolvePath(path.Join(homePath, ProfilesFilename))
+	if err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Dir(profilesPath)
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(profilesPath, os.O_RDWR|os.O_CREATE, 0600)
+}
+
+func readProfiles(homePath string) (*Profiles, error) {
+	file, err := openProfiles(homePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Profiles{}
+	if err := yaml.Unmarshal(raw, out); err != nil {
+		return nil, err
+	}
+	if out.Profiles == nil {
+		out.Profiles = map[string]*Profile{}
+	}
+	return out, nil
+}
+
+// activeProfile resolves the profile name to use, the flag takes
+// precedence over the current profile.
+func activeProfile(profiles *Profiles, name string) (string, *Profile, error) {
+	if len(name) == 0 {
+		name = profiles.Current
+	}
+	if len(name) == 0 || name == DefaultProfile {
+		return "", nil, nil
+	}
+
+	profile, ok := profiles.Profiles[name]
+	if !ok {
+		return "", nil, fmt.Errorf("profile %s not found", name)
+	}
+	return name, profile, nil
+}
+
+func (p *Profile) reader() (io.Reader, error) {
+	raw, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(raw), nil
+}
+
+// Profile returns the name of the active profile, empty when using the defaults.
+func (c *NoOps[C, T]) Profile() string {
+	return c.profile
+}
+
+func (c *NoOps[C, T]) GetProfiles() (*Profiles, error) {
+	return readProfiles(c.Home.Path)
+}
+
+func (c *NoOps[C, T]) StoreProfiles(profiles *Profiles) error {
+	file, err := openProfiles(c.Home.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	file.Truncate(0)
+	file.Seek(0, 0)
+
+	raw, err := yaml.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(raw); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteProfile removes the profile along with its keyring entry.
+func (c *NoOps[C, T]) DeleteProfile(name string) error {
+	profiles, err := c.GetProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles.Profiles[name]; !ok {
+		return fmt.Errorf("profile %s not found", name)
+	}
+
+	delete(profiles.Profiles, name)
+	if profiles.Current == name {
+		profiles.Current = ""
+	}
+	if err := c.StoreProfiles(profiles); err != nil {
+		return err
+	}
+
+	for _, key := range []string{ProfileTokenKey(name), ProfileClientTokenKey(name), ProfileClientSecretKey(name)} {
+		err = c.keyring.Remove(key)
+		if err != nil && !errors.Is(err, keyring.ErrKeyNotFound) && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import "testing"
+
+func Test_ActiveProfile(t *testing.T) {
+	profiles := &Profiles{
+		Current: "staging",
+		Profiles: map[string]*Profile{
+			"staging": {Organisation: "staging-org"},
+			"sandbox": {Organisation: "sandbox-org"},
+		},
+	}
+
+	cases := []struct {
+		flag     string
+		expected string
+		tokenKey string
+		err      bool
+	}{
+		{flag: "", expected: "staging", tokenKey: "token.staging"},
+		{flag: "sandbox", expected: "sandbox", tokenKey: "token.sandbox"},
+		{flag: "default", expected: "", tokenKey: "token"},
+		{flag: "missing", err: true},
+	}
+
+	for _, c := range cases {
+		name, profile, err := activeProfile(profiles, c.flag)
+		if c.err {
+			if err == nil {
+				t.Fatalf("expected error for %s", c.flag)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != c.expected {
+			t.Fatalf("expected %s, got %s", c.expected, name)
+		}
+		if len(name) > 0 && profile != profiles.Profiles[name] {
+			t.Fatalf("expected profile %s", name)
+		}
+		if key := ProfileTokenKey(name); key != c.tokenKey {
+			t.Fatalf("expected %s, got %s", c.tokenKey, key)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/99designs/keyring"
+	"github.com/zitadel/oidc/v2/pkg/client/rp"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"gopkg.in/yaml.v3"
+)
+
+// readToken reads the token for the active profile directly from the
+// keyring, it returns nil when there is no token stored.
+func (c *NoOps[C, T]) readToken() (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	token, _, err := c.readStoredToken(c.tokenKey)
+	return token, err
+}
+
+// readStoredToken reads the token under the keyring entry along with the
+// grant it was obtained with.
+func (c *NoOps[C, T]) readStoredToken(key string) (*oidc.Tokens[*oidc.IDTokenClaims], oidc.GrantType, error) {
+	item, err := c.keyring.Get(key)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	wrap := storedToken{}
+	if err := yaml.Unmarshal(item.Data, &wrap); err != nil {
+		return nil, "", err
+	}
+	return wrap.Token, wrap.Grant, nil
+}
+
+// lockToken takes the lock guarding the keyring entry.
+func (c *NoOps[C, T]) lockToken(ctx context.Context, key string) (func() error, error) {
+	return lockFile(ctx, path.Join(c.Home.Path, key+".lock"))
+}
+
+// refreshToken refreshes the token while holding a lock on the keyring entry
+// so concurrent ops processes do not race on the refresh token. Once the lock
+// is held the keyring is read again, if another process has already refreshed
+// the token it is reused rather than refreshed a second time.
+func (c *NoOps[C, T]) refreshToken(ctx context.Context, provider rp.RelyingParty) error {
+	unlock, err := c.lockToken(ctx, c.tokenKey)
+	if err != nil {
+		c.WriteStderr("failed to lock token")
+		return err
+	}
+	defer unlock()
+
+	stored, err := c.readToken()
+	if err != nil {
+		c.WriteStderr("failed to read token")
+		return err
+	}
+
+	if stored != nil && stored.Token != nil && stored.RefreshToken != c.Token.RefreshToken {
+		c.Token = stored
+
+		_, err := rp.VerifyTokens[*oidc.IDTokenClaims](ctx, stored.AccessToken, stored.IDToken, provider.IDTokenVerifier())
+		if err == nil {
+			c.Logger.Info("using token refreshed by another process")
+			return nil
+		}
+	}
+
+	c.Logger.Info("refreshing token", "issuer", c.Auth.Issuer)
+	newToken, err := rp.RefreshAccessToken(provider, c.Token.RefreshToken, "", "")
+	if err != nil {
+		c.WriteStderr("failed to refresh token")
+		return err
+	}
+
+	c.Token.Token = newToken
+
+	if idToken, ok := newToken.Extra("id_token").(string); ok && len(idToken) > 0 {
+		claims, err := rp.VerifyTokens[*oidc.IDTokenClaims](ctx, newToken.AccessToken, idToken, provider.IDTokenVerifier())
+		if err != nil {
+			c.WriteStderr("failed to verify refreshed token")
+			return err
+		}
+		c.Token.IDToken = idToken
+		c.Token.IDTokenClaims = claims
+	}
+
+	if err := c.StoreToken(c.Token); err != nil {
+		c.WriteStderr("failed to store token")
+		return err
+	}
+	return nil
+}
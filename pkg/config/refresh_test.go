@@ -0,0 +1,155 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/keyring"
+	"github.com/getnoops/ops/pkg/oidctest"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+func newTestNoOps(t *testing.T, homePath string, issuer *oidctest.Issuer) *NoOps[any, any] {
+	t.Helper()
+
+	ring, err := keyring.Open(keyring.Config{
+		ServiceName:      "No_Ops",
+		AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+		FileDir:          homePath,
+		FilePasswordFunc: keyring.FixedStringPrompt("test"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &NoOps[any, any]{
+		Config: Config[any]{
+			Home: HomeConfig{Path: homePath},
+			Auth: AuthConfig{
+				Issuer:   issuer.URL,
+				ClientId: issuer.ClientID,
+				Scopes:   []string{oidc.ScopeOpenID, oidc.ScopeOfflineAccess},
+			},
+		},
+		writerStderr:   os.Stderr,
+		writerStdout:   os.Stdout,
+		keyring:        ring,
+		tokenKey:       TokenKey,
+		clientTokenKey: ClientTokenKey,
+		Logger:         newLogger(os.Stderr, slog.LevelWarn),
+	}
+
+	token, err := c.readToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token = token
+	return c
+}
+
+func Test_GetToken_ConcurrentRefresh(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+	issuer.RefreshDelay = 100 * time.Millisecond
+
+	homePath := t.TempDir()
+
+	expired, err := issuer.NewTokens(time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestNoOps(t, homePath, issuer).StoreToken(expired); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 8
+
+	// each worker has its own keyring and config as a separate process would.
+	configs := make([]*NoOps[any, any], workers)
+	for n := range configs {
+		configs[n] = newTestNoOps(t, homePath, issuer)
+	}
+
+	var wg sync.WaitGroup
+	tokens := make([]string, workers)
+	errs := make([]error, workers)
+	for n, c := range configs {
+		wg.Add(1)
+		go func(n int, c *NoOps[any, any]) {
+			defer wg.Done()
+
+			token, err := c.getToken(context.Background())
+			if err != nil {
+				errs[n] = err
+				return
+			}
+			tokens[n] = token.AccessToken
+		}(n, c)
+	}
+	wg.Wait()
+
+	for n, err := range errs {
+		if err != nil {
+			t.Fatalf("worker %d failed: %v", n, err)
+		}
+	}
+
+	if calls := issuer.Calls(string(oidc.GrantTypeRefreshToken)); calls != 1 {
+		t.Fatalf("expected 1 refresh, got %d", calls)
+	}
+
+	for n, token := range tokens {
+		if token == expired.AccessToken {
+			t.Fatalf("worker %d did not refresh the token", n)
+		}
+		if token != tokens[0] {
+			t.Fatalf("worker %d got token %s, expected %s", n, token, tokens[0])
+		}
+	}
+
+	stored, err := configs[0].readToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AccessToken != tokens[0] {
+		t.Fatalf("expected stored token %s, got %s", tokens[0], stored.AccessToken)
+	}
+}
+
+func Test_GetToken_RefreshRotated(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+
+	homePath := t.TempDir()
+
+	expired, err := issuer.NewTokens(time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestNoOps(t, homePath, issuer).StoreToken(expired); err != nil {
+		t.Fatal(err)
+	}
+
+	// both load the expired token, the first to refresh rotates it.
+	first := newTestNoOps(t, homePath, issuer)
+	second := newTestNoOps(t, homePath, issuer)
+
+	if _, err := first.getToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	token, err := second.getToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.AccessToken != first.Token.AccessToken {
+		t.Fatalf("expected %s, got %s", first.Token.AccessToken, token.AccessToken)
+	}
+	if calls := issuer.Calls(string(oidc.GrantTypeRefreshToken)); calls != 1 {
+		t.Fatalf("expected 1 refresh, got %d", calls)
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/getnoops/ops/pkg/util"
+)
+
+// NewTransport builds the transport used for the api, the issuer and release
+// downloads from the proxy, CA bundle and client certificate settings.
+func NewTransport(api ApiConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig, err := newTLSConfig(api)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	proxy, err := newProxy(api)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+	return transport, nil
+}
+
+// HttpClient returns the client for calls outside the api, such as the
+// issuer, it is built once from the Api settings.
+func (c *NoOps[C, T]) HttpClient() (*http.Client, error) {
+	if c.httpClient != nil {
+		return c.httpClient, nil
+	}
+
+	transport, err := NewTransport(c.Api)
+	if err != nil {
+		return nil, err
+	}
+	if c.Api.InsecureSkipVerify {
+		c.Logger.Warn("tls certificate verification is disabled, only use Api.InsecureSkipVerify in development")
+	}
+
+	c.httpClient = &http.Client{Transport: transport}
+	return c.httpClient, nil
+}
+
+func newTLSConfig(api ApiConfig) (*tls.Config, error) {
+	out := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: api.InsecureSkipVerify,
+	}
+
+	if len(api.CACertFile) > 0 {
+		file, err := util.ResolvePath(api.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+		}
+
+		// the bundle is added to the system roots so public endpoints such
+		// as github keep working behind a proxy with its own CA.
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(raw) {
+			return nil, fmt.Errorf("no certificates found in ca bundle %s", api.CACertFile)
+		}
+		out.RootCAs = pool
+	}
+
+	if len(api.ClientCert) > 0 || len(api.ClientKey) > 0 {
+		if len(api.ClientCert) == 0 || len(api.ClientKey) == 0 {
+			return nil, errors.New("both Api.ClientCert and Api.ClientKey must be set")
+		}
+		certFile, err := util.ResolvePath(api.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := util.ResolvePath(api.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		out.Certificates = []tls.Certificate{cert}
+	}
+	return out, nil
+}
+
+// newProxy uses Api.Proxy when set, otherwise the HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY environment variables.
+func newProxy(api ApiConfig) (func(*http.Request) (*url.URL, error), error) {
+	if len(api.Proxy) == 0 {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyUrl, err := url.Parse(api.Proxy)
+	if err != nil || len(proxyUrl.Host) == 0 {
+		return nil, fmt.Errorf("invalid proxy %q", api.Proxy)
+	}
+
+	noProxy := []string{}
+	for _, host := range strings.Split(api.NoProxy, ",") {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if len(host) > 0 {
+			noProxy = append(noProxy, host)
+		}
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxyUrl, nil
+	}, nil
+}
+
+// bypassProxy matches the host against the NoProxy entries, an entry matches
+// the host and its subdomains and * matches every host.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range noProxy {
+		if entry == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.TrimPrefix(entry, "*")
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
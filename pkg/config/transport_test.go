@@ -0,0 +1,200 @@
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/oidctest"
+)
+
+func newTLSIssuer(t *testing.T, configure func(*tls.Config)) *oidctest.Issuer {
+	t.Helper()
+
+	issuer := oidctest.NewUnstartedIssuer("machine")
+	issuer.ClientSecret = "secret"
+	issuer.TLS = &tls.Config{}
+	if configure != nil {
+		configure(issuer.TLS)
+	}
+	issuer.StartTLS()
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func writePEM(t *testing.T, name string, blockType string, der []byte) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// newClientCert writes a self signed client certificate and its key.
+func newClientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ops"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDer)
+}
+
+func Test_Transport_CACertFile(t *testing.T) {
+	issuer := newTLSIssuer(t, nil)
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.ClientSecret = "secret"
+	if _, err := c.MintToken(context.Background()); err == nil {
+		t.Fatal("expected an untrusted certificate to fail")
+	}
+
+	c = newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.ClientSecret = "secret"
+	c.Api.CACertFile = writePEM(t, "ca.pem", "CERTIFICATE", issuer.Certificate().Raw)
+	if _, err := c.MintToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := c.NewRelyingPartyOIDC(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.OAuthConfig().Endpoint.TokenURL != issuer.URL+"/oauth/token" {
+		t.Fatalf("unexpected token url %s", provider.OAuthConfig().Endpoint.TokenURL)
+	}
+}
+
+func Test_Transport_InsecureSkipVerify(t *testing.T) {
+	issuer := newTLSIssuer(t, nil)
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.ClientSecret = "secret"
+	c.Api.InsecureSkipVerify = true
+	if _, err := c.MintToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Transport_ClientCert(t *testing.T) {
+	cert, certFile, keyFile := newClientCert(t)
+
+	issuer := newTLSIssuer(t, func(config *tls.Config) {
+		pool := x509.NewCertPool()
+		pool.AddCert(cert)
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	})
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", issuer.Certificate().Raw)
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.ClientSecret = "secret"
+	c.Api.CACertFile = caFile
+	if _, err := c.MintToken(context.Background()); err == nil {
+		t.Fatal("expected a missing client certificate to fail")
+	}
+
+	c = newTestNoOps(t, t.TempDir(), issuer)
+	c.Auth.ClientSecret = "secret"
+	c.Api.CACertFile = caFile
+	c.Api.ClientCert = certFile
+	c.Api.ClientKey = keyFile
+	if _, err := c.MintToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Api.ClientKey = ""
+	if _, err := NewTransport(c.Api); err == nil {
+		t.Fatal("expected a client certificate without a key to fail")
+	}
+}
+
+func Test_Transport_Proxy(t *testing.T) {
+	proxied := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String()+" "+r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer proxy.Close()
+
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+
+	c := newTestNoOps(t, t.TempDir(), issuer)
+	c.Api.Token = "api-token"
+	c.Api.Proxy = proxy.URL
+	c.Api.NoProxy = "localhost, .internal"
+
+	httpClient, err := c.NewHttpClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := httpClient.Post("http://api.example.com/graphql", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(proxied) != 1 || proxied[0] != "http://api.example.com/graphql Bearer api-token" {
+		t.Fatalf("expected the request to go through the proxy, got %v", proxied)
+	}
+
+	transport, err := NewTransport(c.Api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, want := range map[string]bool{
+		"api.example.com":  true,
+		"localhost":        false,
+		"api.internal":     false,
+		"internal.example": true,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "https://"+host+"/graphql", nil)
+		got, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got != nil) != want {
+			t.Fatalf("expected proxy %v for %s, got %v", want, host, got)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package diagnose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// FailureFlags are the options of commands which diagnose a failed
+// deployment, they are embedded in the command's config with
+// `mapstructure:",squash"`.
+type FailureFlags struct {
+	FailureLogs int `mapstructure:"failure-logs"`
+}
+
+// BindFlags adds --failure-logs to the command.
+func BindFlags(cmd *cobra.Command) {
+	util.BindIntFlag(cmd, "failure-logs", "The number of log lines shown when a watched deployment fails", 20)
+}
+
+// Report explains why a deployment failed.
+type Report struct {
+	Config       string                   `json:"config" yaml:"config"`
+	Environment  string                   `json:"environment" yaml:"environment"`
+	DeploymentId uuid.UUID                `json:"deployment_id" yaml:"deployment_id"`
+	RevisionId   uuid.UUID                `json:"revision_id" yaml:"revision_id"`
+	State        queries.StackState       `json:"state" yaml:"state"`
+	Stack        string                   `json:"stack,omitempty" yaml:"stack,omitempty"`
+	Region       string                   `json:"region,omitempty" yaml:"region,omitempty"`
+	Status       string                   `json:"status,omitempty" yaml:"status,omitempty"`
+	StatusReason string                   `json:"status_reason,omitempty" yaml:"status_reason,omitempty"`
+	Failed       []*queries.StackResource `json:"failed_resources" yaml:"failed_resources"`
+	LogGroup     string                   `json:"log_group,omitempty" yaml:"log_group,omitempty"`
+	Logs         []*queries.Log           `json:"logs" yaml:"logs"`
+	// Problems are the parts of the report which could not be collected.
+	Problems []string `json:"problems,omitempty" yaml:"problems,omitempty"`
+}
+
+// errStop ends the logs subscription once the last page is read.
+var errStop = errors.New("stop")
+
+// DeploymentRevision collects the failed resources of the revision's stack
+// and the last log lines since it started. Anything which cannot be
+// collected is noted in the report's problems rather than returned, so a
+// report is always written.
+func DeploymentRevision(ctx context.Context, q queries.Queries, organisationId uuid.UUID, id uuid.UUID, logs int) *Report {
+	report := &Report{RevisionId: id, Failed: []*queries.StackResource{}, Logs: []*queries.Log{}}
+
+	revision, err := q.GetDeploymentRevision(ctx, organisationId, id)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to get the deployment: %s", err))
+		return report
+	}
+	report.State = revision.State
+	if revision.Config != nil {
+		report.Config = revision.Config.Code
+	}
+
+	deployment := revision.Deployment
+	if deployment == nil {
+		report.Problems = append(report.Problems, "the revision has no deployment")
+		return report
+	}
+	report.DeploymentId = deployment.Id
+
+	environment := revision.Environment
+	if environment == nil {
+		environment = deployment.Environment
+	}
+	if environment != nil {
+		report.Environment = environment.Code
+		if len(environment.Regions) > 0 {
+			report.Region = environment.Regions[0]
+		}
+	}
+
+	var outputs []*queries.StackOutput
+	if deployment.Stack != nil {
+		outputs = deployment.Stack.Outputs
+		report.Stack = deployment.Stack.Name
+		report.Status = deployment.Stack.Status
+		report.Failed = FailedResources(deployment.Stack.Resources)
+	}
+	if len(report.Stack) > 0 && len(report.Region) > 0 && environment != nil {
+		// the stack details have the reason the stack failed, and the resources
+		// when the deployment does not include them.
+		details, err := q.GetStackInfo(ctx, &queries.StackSetInstanceInput{
+			Organisation_id: organisationId,
+			Environment_id:  environment.Id,
+			Aws_region:      report.Region,
+			Stack_set_name:  report.Stack,
+		})
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to get stack %s: %s", report.Stack, err))
+		} else {
+			report.Status = details.Status
+			report.StatusReason = details.Status_reason
+			if failed := FailedResources(details.Resources); len(failed) > 0 {
+				report.Failed = failed
+			}
+		}
+	}
+
+	if logs <= 0 {
+		return report
+	}
+	logGroup, ok := LogGroup(outputs)
+	if !ok || len(report.Region) == 0 {
+		report.Problems = append(report.Problems, "no log group found for the deployment")
+		return report
+	}
+	report.LogGroup = logGroup
+
+	lines, err := lastLogs(ctx, q, organisationId, deployment.Id, report.Region, logGroup, revision.Created_at, logs)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to get logs: %s", err))
+		return report
+	}
+	report.Logs = lines
+	return report
+}
+
+// lastLogs reads the logs since the revision started and keeps the last n.
+func lastLogs(ctx context.Context, q queries.Queries, organisationId uuid.UUID, deploymentId uuid.UUID, region string, logGroup string, since time.Time, n int) ([]*queries.Log, error) {
+	now := time.Now()
+	if since.IsZero() || since.After(now) {
+		since = now.Add(-time.Hour)
+	}
+	// allow for the clocks of the api and the logs differing.
+	since = since.Add(-time.Minute)
+	startTime, endTime := since.UnixMilli(), now.UnixMilli()
+
+	out := []*queries.Log{}
+	err := q.SubscribeLogs(ctx, &queries.LogsSubscriptionInput{
+		Id:              uuid.New(),
+		Organisation_id: organisationId,
+		Deployment_id:   deploymentId,
+		Region:          region,
+		Log_group:       logGroup,
+		Start_time:      &startTime,
+		End_time:        &endTime,
+	}, func(page *queries.LogsOutput) error {
+		out = append(out, page.Logs...)
+		if len(out) > n {
+			out = out[len(out)-n:]
+		}
+		if page.Next_token == nil {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogGroup returns the log group in the outputs of a deployment's stack.
+func LogGroup(outputs []*queries.StackOutput) (string, bool) {
+	for _, output := range outputs {
+		key := strings.ToLower(output.Output_key)
+		if strings.HasSuffix(key, "loggroup") || strings.HasSuffix(key, "loggroupname") {
+			return output.Output_value, true
+		}
+	}
+	return "", false
+}
+
+// IsFailedResource reports whether the CloudFormation status of a resource
+// is a failure, e.g. CREATE_FAILED.
+func IsFailedResource(status string) bool {
+	return strings.HasSuffix(status, "_FAILED")
+}
+
+// FailedResources returns the resources which failed, the oldest first as
+// later failures are usually caused by the first one.
+func FailedResources(resources []*queries.StackResource) []*queries.StackResource {
+	out := []*queries.StackResource{}
+	for _, resource := range resources {
+		if IsFailedResource(resource.Resource_status) {
+			out = append(out, resource)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
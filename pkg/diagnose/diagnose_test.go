@@ -0,0 +1,102 @@
+package diagnose_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getnoops/ops/pkg/diagnose"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func Test_DeploymentRevision(t *testing.T) {
+	cases := []struct {
+		name     string
+		errors   map[string]error
+		logs     int
+		problems []string
+		failed   int
+	}{
+		{name: "complete", logs: 20, failed: 1},
+		{name: "no logs", logs: 0, failed: 1},
+		{
+			// the failed resources come from the revision's stack.
+			name:     "stack info unavailable",
+			errors:   map[string]error{"GetStackInfo": queries.ErrForbidden},
+			logs:     20,
+			failed:   1,
+			problems: []string{"failed to get stack noops-api-dev"},
+		},
+		{
+			name:     "logs unavailable",
+			errors:   map[string]error{"Logs": queries.ErrSubscriptionsUnavailable},
+			logs:     20,
+			failed:   1,
+			problems: []string{"failed to get logs"},
+		},
+		{
+			name:     "revision not found",
+			errors:   map[string]error{"GetDeploymentRevision": queries.ErrNotFound},
+			problems: []string{"failed to get the deployment"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := queriestest.New()
+			fake.DeploymentState = queries.StackStateFailed
+			org := fake.AddOrganisation("noops", "NoOps")
+			env := fake.AddEnvironment(org.Id, "dev")
+			config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+			revision := fake.AddRevision(config, "1.0.0")
+
+			deploymentId, revisionId := uuid.New(), uuid.New()
+			if _, err := fake.NewDeployment(context.Background(), org.Id, deploymentId, env.Id, config.Id, revision.Id, revisionId); err != nil {
+				t.Fatal(err)
+			}
+			fake.SetStack(deploymentId, &queries.StackDetails{
+				Status: "CREATE_FAILED",
+				Resources: []*queries.StackResource{
+					{Logical_resource_id: "Service", Resource_status: "CREATE_FAILED", Resource_status_reason: "Circuit breaker triggered"},
+				},
+			})
+			for operation, err := range c.errors {
+				fake.Errors[operation] = err
+			}
+
+			report := diagnose.DeploymentRevision(context.Background(), fake.Client("noops"), org.Id, revisionId, c.logs)
+			if len(report.Failed) != c.failed {
+				t.Fatalf("expected %d failed resources, got %+v", c.failed, report.Failed)
+			}
+			if len(report.Problems) != len(c.problems) {
+				t.Fatalf("expected problems %v, got %v", c.problems, report.Problems)
+			}
+			for i, problem := range c.problems {
+				if !strings.HasPrefix(report.Problems[i], problem) {
+					t.Fatalf("expected problem %q, got %q", problem, report.Problems[i])
+				}
+			}
+			if logs := len(fake.LogsInputs()); (c.logs > 0 && c.errors["Logs"] == nil && logs != 1) || (c.logs == 0 && logs != 0) {
+				t.Fatalf("unexpected logs calls %d", logs)
+			}
+
+			// a report is written whatever could be collected.
+			var out bytes.Buffer
+			if err := report.Write(&out, "json"); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(out.Bytes(), &diagnose.Report{}); err != nil {
+				t.Fatalf("expected json, got %q", out.String())
+			}
+			out.Reset()
+			report.Write(&out, "table")
+			if !strings.Contains(out.String(), "failed") {
+				t.Fatalf("unexpected report %q", out.String())
+			}
+		})
+	}
+}
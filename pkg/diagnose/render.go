@@ -0,0 +1,63 @@
+package diagnose
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Write writes the report as a json line for json, yaml for yaml and a
+// short text summary otherwise.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return json.NewEncoder(w).Encode(r)
+	case "yaml":
+		out, err := yaml.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+
+	name := "Deployment"
+	if len(r.Config) > 0 && len(r.Environment) > 0 {
+		name = fmt.Sprintf("Deployment of %s to %s", r.Config, r.Environment)
+	}
+	fmt.Fprintf(w, "%s failed\n", name)
+
+	if len(r.Stack) > 0 {
+		status := r.Status
+		if len(status) == 0 {
+			status = string(r.State)
+		}
+		fmt.Fprintf(w, "Stack %s in %s: %s\n", r.Stack, r.Region, status)
+		if len(r.StatusReason) > 0 {
+			fmt.Fprintf(w, "  %s\n", r.StatusReason)
+		}
+	}
+
+	if len(r.Failed) > 0 {
+		fmt.Fprintln(w, "Failed resources:")
+		for _, resource := range r.Failed {
+			fmt.Fprintf(w, "  %s %s (%s) %s: %s\n", resource.Timestamp.Local().Format(time.RFC3339), resource.Logical_resource_id, resource.Resource_type, resource.Resource_status, resource.Resource_status_reason)
+		}
+	}
+
+	if len(r.Logs) > 0 {
+		fmt.Fprintf(w, "Last log lines of %s:\n", r.LogGroup)
+		for _, log := range r.Logs {
+			fmt.Fprintf(w, "  %s %s %s\n", time.UnixMilli(log.Timestamp).Format(time.RFC3339), log.Log_stream_name, strings.TrimRight(log.Message, "\n"))
+		}
+	}
+
+	for _, problem := range r.Problems {
+		fmt.Fprintf(w, "Not shown, %s\n", problem)
+	}
+	return nil
+}
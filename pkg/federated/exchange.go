@@ -0,0 +1,73 @@
+package federated
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/zitadel/oidc/v2/pkg/client"
+	"github.com/zitadel/oidc/v2/pkg/client/tokenexchange"
+	httphelper "github.com/zitadel/oidc/v2/pkg/http"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"golang.org/x/oauth2"
+)
+
+type exchanger struct {
+	httpClient    *http.Client
+	tokenEndpoint string
+	clientID      string
+}
+
+func (e *exchanger) TokenEndpoint() string {
+	return e.tokenEndpoint
+}
+
+func (e *exchanger) HttpClient() *http.Client {
+	return e.httpClient
+}
+
+func (e *exchanger) AuthFn() (any, error) {
+	return httphelper.FormAuthorization(func(values url.Values) {
+		values.Set("client_id", e.clientID)
+	}), nil
+}
+
+// Exchange performs an RFC 8693 token exchange of the subject token for an
+// access token issued by the issuer.
+func Exchange(httpClient *http.Client, issuer string, clientID string, subjectToken string, scopes []string) (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	if httpClient == nil {
+		httpClient = httphelper.DefaultHTTPClient
+	}
+
+	discovery, err := client.Discover(issuer, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	te := &exchanger{
+		httpClient:    httpClient,
+		tokenEndpoint: discovery.TokenEndpoint,
+		clientID:      clientID,
+	}
+
+	resp, err := tokenexchange.ExchangeToken(te, subjectToken, oidc.JWTTokenType, "", "", nil, nil, scopes, oidc.AccessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenType := resp.TokenType
+	if len(tokenType) == 0 {
+		tokenType = oidc.BearerToken
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  resp.AccessToken,
+		TokenType:    tokenType,
+		RefreshToken: resp.RefreshToken,
+	}
+	if resp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	return &oidc.Tokens[*oidc.IDTokenClaims]{Token: token}, nil
+}
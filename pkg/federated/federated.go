@@ -0,0 +1,105 @@
+package federated
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultTokenEnv is the environment variable the CI job's OIDC token is
+// read from when no other location is configured.
+const DefaultTokenEnv = "NOOPS_ID_TOKEN"
+
+// Options configures where providers look for the CI job's OIDC token.
+type Options struct {
+	// Audience is requested by providers which mint the token on demand.
+	Audience string
+	// TokenFile is a path the token is read from.
+	TokenFile string
+	// TokenEnv is an environment variable the token is read from.
+	TokenEnv string
+	// HttpClient is used for providers which request the token, it defaults
+	// to http.DefaultClient.
+	HttpClient *http.Client
+}
+
+// Provider discovers the OIDC token issued to a CI job.
+type Provider interface {
+	// Name is used to select the provider with --provider.
+	Name() string
+	// Detect returns true when the provider can issue a token in the current
+	// environment.
+	Detect(opts Options) bool
+	// Token returns the OIDC token for the job.
+	Token(ctx context.Context, opts Options) (string, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers []Provider
+)
+
+// Register adds a provider, providers are detected in the order they are
+// registered.
+func Register(provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for n, p := range providers {
+		if p.Name() == provider.Name() {
+			providers[n] = provider
+			return
+		}
+	}
+	providers = append(providers, provider)
+}
+
+// Names returns the names of the registered providers.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return namesLocked()
+}
+
+// Lookup returns the provider with the given name, when the name is empty
+// the first provider detected in the environment is returned.
+func Lookup(name string, opts Options) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if len(name) > 0 {
+		for _, p := range providers {
+			if strings.EqualFold(p.Name(), name) {
+				return p, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown federated provider %s, should be one of: [%s]", name, strings.Join(namesLocked(), ","))
+	}
+
+	for _, p := range providers {
+		if p.Detect(opts) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no federated provider detected, use --provider to select one of: [%s]", strings.Join(namesLocked(), ","))
+}
+
+func namesLocked() []string {
+	names := []string{}
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(&fileProvider{})
+	Register(&githubProvider{})
+	Register(&gitlabProvider{})
+	Register(&envProvider{})
+}
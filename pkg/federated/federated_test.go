@@ -0,0 +1,119 @@
+package federated
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getnoops/ops/pkg/oidctest"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+)
+
+func Test_Providers(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"value":"github-` + r.URL.Query().Get("audience") + `"}`))
+	}))
+	defer github.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		env      map[string]string
+		opts     Options
+		provider string
+		expected string
+	}{
+		{
+			name:     "file",
+			opts:     Options{TokenFile: tokenFile},
+			provider: "file",
+			expected: "file-token",
+		},
+		{
+			name:     "env",
+			env:      map[string]string{"CUSTOM_TOKEN": "env-token"},
+			opts:     Options{TokenEnv: "CUSTOM_TOKEN"},
+			provider: "env",
+			expected: "env-token",
+		},
+		{
+			name: "github",
+			env: map[string]string{
+				"ACTIONS_ID_TOKEN_REQUEST_URL":   github.URL + "?api-version=2.0",
+				"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "request-token",
+			},
+			opts:     Options{Audience: "ops"},
+			provider: "github",
+			expected: "github-ops",
+		},
+		{
+			name:     "gitlab",
+			env:      map[string]string{"GITLAB_CI": "true", "GITLAB_TOKEN_TEST": "gitlab-token"},
+			opts:     Options{TokenEnv: "GITLAB_TOKEN_TEST"},
+			provider: "gitlab",
+			expected: "gitlab-token",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for key, val := range c.env {
+				t.Setenv(key, val)
+			}
+
+			provider, err := Lookup("", c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if provider.Name() != c.provider {
+				t.Fatalf("expected %s provider, got %s", c.provider, provider.Name())
+			}
+
+			token, err := provider.Token(context.Background(), c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, token)
+			}
+		})
+	}
+}
+
+func Test_Lookup_Unknown(t *testing.T) {
+	if _, err := Lookup("bogus", Options{}); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func Test_Exchange(t *testing.T) {
+	issuer := oidctest.NewIssuer("ops")
+	defer issuer.Close()
+	issuer.SubjectToken = "ci-token"
+
+	token, err := Exchange(nil, issuer.URL, "ops", "ci-token", []string{oidc.ScopeOpenID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token.AccessToken) == 0 || !token.Valid() {
+		t.Fatalf("expected a valid access token, got %v", token.Token)
+	}
+
+	if _, err := Exchange(nil, issuer.URL, "ops", "wrong-token", nil); err == nil {
+		t.Fatal("expected exchange of an unknown subject token to fail")
+	}
+	if _, err := Exchange(nil, issuer.URL, "other", "ci-token", nil); err == nil {
+		t.Fatal("expected exchange for an unknown client to fail")
+	}
+}
@@ -0,0 +1,151 @@
+package federated
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// fileProvider reads the token from a file, such as a projected service
+// account token.
+type fileProvider struct{}
+
+func (p *fileProvider) Name() string {
+	return "file"
+}
+
+func (p *fileProvider) Detect(opts Options) bool {
+	return len(opts.TokenFile) > 0
+}
+
+func (p *fileProvider) Token(ctx context.Context, opts Options) (string, error) {
+	if len(opts.TokenFile) == 0 {
+		return "", errors.New("no token file set")
+	}
+
+	raw, err := os.ReadFile(opts.TokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(raw))
+	if len(token) == 0 {
+		return "", fmt.Errorf("token file %s is empty", opts.TokenFile)
+	}
+	return token, nil
+}
+
+// envProvider reads the token from an environment variable.
+type envProvider struct{}
+
+func (p *envProvider) Name() string {
+	return "env"
+}
+
+func (p *envProvider) Detect(opts Options) bool {
+	return len(os.Getenv(tokenEnv(opts))) > 0
+}
+
+func (p *envProvider) Token(ctx context.Context, opts Options) (string, error) {
+	name := tokenEnv(opts)
+	token := strings.TrimSpace(os.Getenv(name))
+	if len(token) == 0 {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+	return token, nil
+}
+
+func tokenEnv(opts Options) string {
+	if len(opts.TokenEnv) > 0 {
+		return opts.TokenEnv
+	}
+	return DefaultTokenEnv
+}
+
+// githubProvider requests a token from GitHub Actions, the workflow needs
+// the id-token: write permission.
+type githubProvider struct{}
+
+func (p *githubProvider) Name() string {
+	return "github"
+}
+
+func (p *githubProvider) Detect(opts Options) bool {
+	return len(os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")) > 0 && len(os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")) > 0
+}
+
+func (p *githubProvider) Token(ctx context.Context, opts Options) (string, error) {
+	requestUrl := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if len(requestUrl) == 0 || len(requestToken) == 0 {
+		return "", errors.New("ACTIONS_ID_TOKEN_REQUEST_URL is not set, does the workflow have the id-token: write permission?")
+	}
+
+	u, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", err
+	}
+	if len(opts.Audience) > 0 {
+		q := u.Query()
+		q.Set("audience", opts.Audience)
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := opts.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request github token: %s", resp.Status)
+	}
+
+	out := struct {
+		Value string `json:"value"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if len(out.Value) == 0 {
+		return "", errors.New("github returned an empty token")
+	}
+	return out.Value, nil
+}
+
+// gitlabProvider reads the token GitLab CI exposes through id_tokens, the
+// job should declare it using the NOOPS_ID_TOKEN name.
+type gitlabProvider struct{}
+
+func (p *gitlabProvider) Name() string {
+	return "gitlab"
+}
+
+func (p *gitlabProvider) Detect(opts Options) bool {
+	return os.Getenv("GITLAB_CI") == "true"
+}
+
+func (p *gitlabProvider) Token(ctx context.Context, opts Options) (string, error) {
+	token := strings.TrimSpace(os.Getenv(tokenEnv(opts)))
+	if len(token) == 0 {
+		return "", fmt.Errorf("%s is not set, add it to the job's id_tokens", tokenEnv(opts))
+	}
+	return token, nil
+}
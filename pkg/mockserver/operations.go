@@ -0,0 +1,195 @@
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/google/uuid"
+)
+
+type operation struct {
+	// field is the top level field of the operation, errors are reported at it.
+	field    string
+	mutation bool
+	handle   func(ctx context.Context, s *Server, vars json.RawMessage) (any, error)
+}
+
+// vars are the variables used across the operations in query.graphql.
+type vars struct {
+	OrganisationId   uuid.UUID                  `json:"organisationId"`
+	AggregateId      uuid.UUID                  `json:"aggregateId"`
+	Id               uuid.UUID                  `json:"id"`
+	ConfigId         uuid.UUID                  `json:"configId"`
+	EnvironmentId    uuid.UUID                  `json:"environmentId"`
+	ConfigRevisionId uuid.UUID                  `json:"configRevisionId"`
+	RevisionId       uuid.UUID                  `json:"revisionId"`
+	Code             string                     `json:"code"`
+	Codes            []string                   `json:"codes"`
+	Class            queries.ConfigClass        `json:"class"`
+	Classes          []queries.ConfigClass      `json:"classes"`
+	States           []queries.StackState       `json:"states"`
+	Name             string                     `json:"name"`
+	Value            string                     `json:"value"`
+	Page             int                        `json:"page"`
+	PageSize         int                        `json:"pageSize"`
+	Input            *queries.UpdateConfigInput `json:"input"`
+}
+
+func query(field string, handle func(ctx context.Context, s *Server, v *vars) (any, error)) *operation {
+	return &operation{
+		field: field,
+		handle: func(ctx context.Context, s *Server, raw json.RawMessage) (any, error) {
+			var v vars
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, &queries.Error{Kind: queries.ErrValidation, Message: err.Error()}
+			}
+			return handle(ctx, s, &v)
+		},
+	}
+}
+
+func mutation(field string, handle func(ctx context.Context, s *Server, v *vars) (any, error)) *operation {
+	op := query(field, handle)
+	op.mutation = true
+	return op
+}
+
+var operations = map[string]*operation{
+	"GetMemberOrganisations": query("memberOrganisations", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetMemberOrganisations(ctx, v.Page, v.PageSize)
+		return &queries.GetMemberOrganisationsResponse{MemberOrganisations: out}, err
+	}),
+	"GetEnvironments": query("environments", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetEnvironments(ctx, v.OrganisationId, v.Codes, v.States, v.Page, v.PageSize)
+		return &queries.GetEnvironmentsResponse{Environments: out}, err
+	}),
+	"GetConfigs": query("configs", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetConfigs(ctx, v.OrganisationId, v.Classes, v.Page, v.PageSize)
+		return &queries.GetConfigsResponse{Configs: out}, err
+	}),
+	"GetConfig": query("config", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetConfig(ctx, v.OrganisationId, v.Code)
+		return &queries.GetConfigResponse{Config: out}, err
+	}),
+	"GetApiKeys": query("apiKeys", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetApiKeys(ctx, v.OrganisationId, v.Page, v.PageSize)
+		return &queries.GetApiKeysResponse{ApiKeys: out}, err
+	}),
+	"GetDeployment": query("deployment", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetDeployment(ctx, v.OrganisationId, v.AggregateId)
+		return &queries.GetDeploymentResponse{Deployment: out}, err
+	}),
+	"GetDeploymentRevision": query("deploymentRevision", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetDeploymentRevision(ctx, v.OrganisationId, v.AggregateId)
+		return &queries.GetDeploymentRevisionResponse{DeploymentRevision: out}, err
+	}),
+	"GetDeployments": query("deployments", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.GetDeployments(ctx, v.OrganisationId, v.ConfigId, v.Page, v.PageSize)
+		return &queries.GetDeploymentsResponse{Deployments: out}, err
+	}),
+	"GetMetrics": {
+		field: "metrics",
+		handle: func(ctx context.Context, s *Server, raw json.RawMessage) (any, error) {
+			// the input is a MetricsInput rather than the UpdateConfigInput in vars.
+			var v struct {
+				Input *queries.MetricsInput `json:"input"`
+			}
+			if err := json.Unmarshal(raw, &v); err != nil || v.Input == nil {
+				return nil, &queries.Error{Kind: queries.ErrValidation, Message: "input is required"}
+			}
+			out, err := s.fake.GetMetrics(ctx, v.Input)
+			return &queries.GetMetricsResponse{Metrics: out}, err
+		},
+	},
+	"GetStackInfo": {
+		field: "stackInfo",
+		handle: func(ctx context.Context, s *Server, raw json.RawMessage) (any, error) {
+			var v struct {
+				Input *queries.StackSetInstanceInput `json:"input"`
+			}
+			if err := json.Unmarshal(raw, &v); err != nil || v.Input == nil {
+				return nil, &queries.Error{Kind: queries.ErrValidation, Message: "input is required"}
+			}
+			out, err := s.fake.GetStackInfo(ctx, v.Input)
+			return &queries.GetStackInfoResponse{StackInfo: out}, err
+		},
+	},
+
+	"CreateConfig": mutation("createConfig", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.CreateConfig(ctx, v.OrganisationId, v.AggregateId, v.Name, v.Code, v.Class)
+		return &queries.CreateConfigResponse{CreateConfig: deref(out)}, err
+	}),
+	"UpdateConfig": mutation("updateConfig", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		if v.Input == nil {
+			return nil, &queries.Error{Kind: queries.ErrValidation, Message: "input is required"}
+		}
+		out, err := s.fake.UpdateConfig(ctx, v.Input)
+		return &queries.UpdateConfigResponse{UpdateConfig: deref(out)}, err
+	}),
+	"CreateContainerRepository": mutation("createContainerRepository", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.CreateContainerRepository(ctx, v.OrganisationId, v.AggregateId, v.ConfigId, v.Code)
+		return &queries.CreateContainerRepositoryResponse{CreateContainerRepository: deref(out)}, err
+	}),
+	"DeleteContainerRepository": mutation("deleteContainerRepository", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.DeleteContainerRepository(ctx, v.OrganisationId, v.Id)
+		return &queries.DeleteContainerRepositoryResponse{DeleteContainerRepository: deref(out)}, err
+	}),
+	"LoginContainerRepository": mutation("loginContainerRepository", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.LoginContainerRepository(ctx, v.OrganisationId)
+		return &queries.LoginContainerRepositoryResponse{LoginContainerRepository: out}, err
+	}),
+	"CreateSecret": mutation("createSecret", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.CreateSecret(ctx, v.OrganisationId, v.AggregateId, v.ConfigId, v.EnvironmentId, v.Code, v.Value)
+		return &queries.CreateSecretResponse{CreateSecret: deref(out)}, err
+	}),
+	"UpdateSecret": mutation("updateSecret", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.UpdateSecret(ctx, v.OrganisationId, v.AggregateId, v.ConfigId, v.EnvironmentId, v.Code, v.Value)
+		return &queries.UpdateSecretResponse{UpdateSecret: deref(out)}, err
+	}),
+	"RestoreSecret": mutation("restoreSecret", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.RestoreSecret(ctx, v.OrganisationId, v.Id)
+		return &queries.RestoreSecretResponse{RestoreSecret: deref(out)}, err
+	}),
+	"DeleteSecret": mutation("deleteSecret", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.DeleteSecret(ctx, v.OrganisationId, v.Id)
+		return &queries.DeleteSecretResponse{DeleteSecret: deref(out)}, err
+	}),
+	"CreateApiKey": mutation("createApiKey", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.CreateApiKey(ctx, v.OrganisationId)
+		return &queries.CreateApiKeyResponse{CreateApiKey: out}, err
+	}),
+	"UpdateApiKey": mutation("updateApiKey", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.UpdateApiKey(ctx, v.AggregateId)
+		return &queries.UpdateApiKeyResponse{UpdateApiKey: out}, err
+	}),
+	"DeleteApiKey": mutation("deleteApiKey", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.DeleteApiKey(ctx, v.AggregateId)
+		return &queries.DeleteApiKeyResponse{DeleteApiKey: deref(out)}, err
+	}),
+	"NewDeployment": mutation("newDeployment", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.NewDeployment(ctx, v.OrganisationId, v.AggregateId, v.EnvironmentId, v.ConfigId, v.ConfigRevisionId, v.RevisionId)
+		if err == nil {
+			s.schedule(v.RevisionId)
+		}
+		return &queries.NewDeploymentResponse{NewDeployment: deref(out)}, err
+	}),
+	"DeleteDeployment": mutation("deleteDeployment", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.DeleteDeployment(ctx, v.OrganisationId, v.Id)
+		return &queries.DeleteDeploymentResponse{DeleteDeployment: deref(out)}, err
+	}),
+	"CancelDeployment": mutation("cancelDeployment", func(ctx context.Context, s *Server, v *vars) (any, error) {
+		out, err := s.fake.CancelDeployment(ctx, v.OrganisationId, v.Id)
+		if err == nil {
+			s.unschedule(v.Id)
+		}
+		return &queries.CancelDeploymentResponse{CancelDeployment: deref(out)}, err
+	}),
+}
+
+func deref(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
@@ -0,0 +1,315 @@
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+// DefaultOrganisation is the organisation a new mock server starts with.
+var DefaultOrganisation = "noops"
+
+// DefaultEnvironments are the environments a new mock server starts with.
+var DefaultEnvironments = []string{"dev", "prod"}
+
+type Options struct {
+	// File is where the state is kept between runs, the state is only held
+	// in memory when empty.
+	File string
+	// DeployDelay is how long a deployment stays creating.
+	DeployDelay time.Duration
+	// FailDeployments makes every deployment end failed.
+	FailDeployments bool
+	// Failures maps an operation name to the graphql error code it returns,
+	// e.g. CreateSecret=CONFLICT.
+	Failures map[string]string
+}
+
+// Server serves the operations in query.graphql from a queriestest.Fake.
+type Server struct {
+	opts Options
+
+	// mu serializes requests so responses are not encoded while a deployment
+	// changes state.
+	mu     sync.Mutex
+	fake   *queriestest.Fake
+	timers map[uuid.UUID]*time.Timer
+}
+
+// New creates a server with the state read from the file, a new state is
+// seeded with the default organisation and environments.
+func New(opts Options) (*Server, error) {
+	fake := queriestest.New()
+	fake.DeploymentState = queries.StackStateCreating
+
+	s := &Server{
+		opts:   opts,
+		fake:   fake,
+		timers: map[uuid.UUID]*time.Timer{},
+	}
+
+	loaded, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if !loaded {
+		org := fake.AddOrganisation(DefaultOrganisation, "NoOps")
+		for _, env := range DefaultEnvironments {
+			fake.AddEnvironment(org.Id, env)
+		}
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	// deployments left in progress by the last run carry on.
+	for _, revision := range fake.DeploymentRevisions() {
+		if revision.State == queries.StackStateCreating {
+			s.schedule(revision.Id)
+		}
+	}
+	return s, nil
+}
+
+// Fake returns the state of the server.
+func (s *Server) Fake() *queriestest.Fake {
+	return s.fake
+}
+
+// Close stops the pending deployment transitions.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, id)
+	}
+}
+
+func (s *Server) load() (bool, error) {
+	if len(s.opts.File) == 0 {
+		return false, nil
+	}
+
+	file, err := os.Open(s.opts.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	if err := s.fake.Load(file); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", s.opts.File, err)
+	}
+	return true, nil
+}
+
+// save writes the state to a temporary file which replaces the file.
+func (s *Server) save() error {
+	if len(s.opts.File) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.opts.File), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.opts.File), filepath.Base(s.opts.File)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.fake.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.opts.File)
+}
+
+// schedule moves the deployment revision to its final state after the delay.
+func (s *Server) schedule(deploymentRevisionId uuid.UUID) {
+	if _, ok := s.timers[deploymentRevisionId]; ok {
+		return
+	}
+
+	state := queries.StackStateCreated
+	if s.opts.FailDeployments {
+		state = queries.StackStateFailed
+	}
+
+	s.timers[deploymentRevisionId] = time.AfterFunc(s.opts.DeployDelay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// the transition was stopped while waiting for the lock.
+		if _, ok := s.timers[deploymentRevisionId]; !ok {
+			return
+		}
+		delete(s.timers, deploymentRevisionId)
+		if err := s.fake.SetDeploymentState(deploymentRevisionId, state); err != nil {
+			return
+		}
+		s.save()
+	})
+}
+
+// unschedule stops the pending transitions of the revisions of the
+// deployment, e.g. once it is cancelled.
+func (s *Server) unschedule(deploymentId uuid.UUID) {
+	for _, revision := range s.fake.DeploymentRevisions() {
+		if revision.Deployment == nil || revision.Deployment.Id != deploymentId {
+			continue
+		}
+		if timer, ok := s.timers[revision.Id]; ok {
+			timer.Stop()
+			delete(s.timers, revision.Id)
+		}
+	}
+}
+
+type request struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+}
+
+type responseError struct {
+	Message    string         `json:"message"`
+	Path       []string       `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+type response struct {
+	Data   any              `json:"data"`
+	Errors []*responseError `json:"errors,omitempty"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "", "GRAPHQL_PARSE_FAILED", err.Error())
+		return
+	}
+
+	op, ok := operations[req.OperationName]
+	if !ok {
+		writeError(w, req.OperationName, "GRAPHQL_VALIDATION_FAILED", fmt.Sprintf("operation %q is not supported by the mock server", req.OperationName))
+		return
+	}
+	if code, ok := s.opts.Failures[req.OperationName]; ok {
+		writeError(w, op.field, code, "injected failure")
+		return
+	}
+	if len(req.Variables) == 0 {
+		req.Variables = json.RawMessage("{}")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := op.handle(r.Context(), s, req.Variables)
+	if err != nil {
+		writeError(w, op.field, errorCode(err), errorMessage(err))
+		return
+	}
+
+	if op.mutation {
+		if err := s.save(); err != nil {
+			writeError(w, op.field, "INTERNAL_SERVER_ERROR", err.Error())
+			return
+		}
+	}
+	writeJSON(w, &response{Data: data})
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, queries.ErrNotFound):
+		return "NOT_FOUND"
+	case errors.Is(err, queries.ErrUnauthenticated):
+		return "UNAUTHENTICATED"
+	case errors.Is(err, queries.ErrForbidden):
+		return "FORBIDDEN"
+	case errors.Is(err, queries.ErrValidation):
+		return "BAD_USER_INPUT"
+	case errors.Is(err, queries.ErrConflict):
+		return "CONFLICT"
+	default:
+		return "INTERNAL_SERVER_ERROR"
+	}
+}
+
+func errorMessage(err error) string {
+	var qerr *queries.Error
+	if errors.As(err, &qerr) && len(qerr.Message) > 0 {
+		return qerr.Message
+	}
+	return err.Error()
+}
+
+func writeError(w http.ResponseWriter, field string, code string, message string) {
+	resErr := &responseError{
+		Message:    message,
+		Extensions: map[string]any{"code": strings.ToUpper(code)},
+	}
+	if len(field) > 0 {
+		resErr.Path = []string{field}
+	}
+	writeJSON(w, &response{Errors: []*responseError{resErr}})
+}
+
+func writeJSON(w http.ResponseWriter, res *response) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// Handler returns the server mounted at /graphql.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", s)
+	return mux
+}
+
+// ListenAndServe serves the api on the address until the context is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: s.Handler(),
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdown)
+	}
+}
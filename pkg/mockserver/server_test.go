@@ -0,0 +1,177 @@
+package mockserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func newClient(t *testing.T, opts Options) (*Server, graphql.Client) {
+	t.Helper()
+
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	return s, graphql.NewClient(srv.URL+"/graphql", srv.Client())
+}
+
+func deploy(t *testing.T, client graphql.Client) (uuid.UUID, uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+
+	orgs, err := queries.GetMemberOrganisations(ctx, client, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orgs.MemberOrganisations.Items) != 1 || orgs.MemberOrganisations.Items[0].Code != DefaultOrganisation {
+		t.Fatalf("expected the default organisation, got %v", orgs.MemberOrganisations.Items)
+	}
+	org := orgs.MemberOrganisations.Items[0]
+
+	envs, err := queries.GetEnvironments(ctx, client, org.Id, []string{"dev"}, nil, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := envs.Environments.Items[0]
+
+	configId := uuid.New()
+	if _, err := queries.CreateConfig(ctx, client, org.Id, configId, "api", queries.ConfigClassCompute, "Api"); err != nil {
+		t.Fatal(err)
+	}
+
+	revisionId := uuid.New()
+	if _, err := queries.UpdateConfig(ctx, client, &queries.UpdateConfigInput{
+		Organisation_id: org.Id,
+		Aggregate_id:    configId,
+		Name:            "Api",
+		Version_number:  "0.0.1",
+		Revision_id:     revisionId,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	deploymentRevisionId := uuid.New()
+	if _, err := queries.NewDeployment(ctx, client, org.Id, uuid.New(), env.Id, configId, revisionId, deploymentRevisionId); err != nil {
+		t.Fatal(err)
+	}
+	return org.Id, deploymentRevisionId
+}
+
+func waitState(t *testing.T, client graphql.Client, organisationId uuid.UUID, deploymentRevisionId uuid.UUID, state queries.StackState) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := queries.GetDeploymentRevision(context.Background(), client, organisationId, deploymentRevisionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.DeploymentRevision.State == state {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected state %s, got %s", state, resp.DeploymentRevision.State)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_Server_Deploy(t *testing.T) {
+	cases := []struct {
+		name  string
+		fail  bool
+		state queries.StackState
+	}{
+		{name: "created", state: queries.StackStateCreated},
+		{name: "failed", fail: true, state: queries.StackStateFailed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, client := newClient(t, Options{DeployDelay: 50 * time.Millisecond, FailDeployments: c.fail})
+
+			orgId, deploymentRevisionId := deploy(t, client)
+			waitState(t, client, orgId, deploymentRevisionId, queries.StackStateCreating)
+			waitState(t, client, orgId, deploymentRevisionId, c.state)
+		})
+	}
+}
+
+func Test_Server_Cancel(t *testing.T) {
+	_, client := newClient(t, Options{DeployDelay: 50 * time.Millisecond})
+
+	orgId, deploymentRevisionId := deploy(t, client)
+	resp, err := queries.GetDeploymentRevision(context.Background(), client, orgId, deploymentRevisionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.CancelDeployment(context.Background(), client, orgId, resp.DeploymentRevision.Deployment.Id); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, client, orgId, deploymentRevisionId, queries.StackStateFailed)
+
+	// the deployment is not created once the delay has passed.
+	time.Sleep(100 * time.Millisecond)
+	waitState(t, client, orgId, deploymentRevisionId, queries.StackStateFailed)
+}
+
+func Test_Server_Persists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mock.json")
+
+	first, client := newClient(t, Options{File: file, DeployDelay: time.Hour})
+	orgId, deploymentRevisionId := deploy(t, client)
+	first.Close()
+
+	// the deployment carries on after a restart.
+	_, client = newClient(t, Options{File: file, DeployDelay: 10 * time.Millisecond})
+
+	resp, err := queries.GetConfig(context.Background(), client, orgId, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Config.Version_number != "0.0.1" || len(resp.Config.Revisions) != 1 || len(resp.Config.Deployments) != 1 {
+		t.Fatalf("expected config to be restored, got %+v", resp.Config)
+	}
+	waitState(t, client, orgId, deploymentRevisionId, queries.StackStateCreated)
+}
+
+func Test_Server_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts Options
+		code string
+	}{
+		{name: "not found", code: "NOT_FOUND"},
+		{name: "injected", opts: Options{Failures: map[string]string{"GetConfig": "forbidden"}}, code: "FORBIDDEN"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, client := newClient(t, c.opts)
+
+			_, err := queries.GetConfig(context.Background(), client, uuid.New(), "api")
+
+			var list gqlerror.List
+			if !errors.As(err, &list) || len(list) != 1 {
+				t.Fatalf("expected graphql error, got %v", err)
+			}
+			if code := list[0].Extensions["code"]; code != c.code {
+				t.Fatalf("expected code %s, got %v", c.code, code)
+			}
+		})
+	}
+}
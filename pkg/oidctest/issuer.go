@@ -0,0 +1,329 @@
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v2/pkg/crypto"
+	"github.com/zitadel/oidc/v2/pkg/oidc"
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const keyId = "oidctest"
+
+// Issuer is a minimal OpenID provider served over httptest, it is used
+// to stand in for Auth.Issuer when testing the login flows.
+type Issuer struct {
+	*httptest.Server
+
+	ClientID string
+	// ClientSecret is required by the client credentials grant.
+	ClientSecret string
+	Subject      string
+	Email        string
+	Groups       []string
+	TokenTTL     time.Duration
+
+	// DevicePending is the number of authorization_pending responses
+	// returned before a device code is approved.
+	DevicePending int
+	// DeviceSlowDown is the number of slow_down responses returned before
+	// any authorization_pending responses.
+	DeviceSlowDown  int
+	DeviceExpiresIn int
+	DeviceInterval  int
+
+	// RefreshDelay is how long the token endpoint waits before answering a
+	// refresh_token grant, it widens the window for concurrent refreshes.
+	RefreshDelay time.Duration
+
+	// SubjectToken is the only subject token accepted by the token exchange
+	// grant, any token is accepted when empty.
+	SubjectToken string
+
+	mu            sync.Mutex
+	key           *rsa.PrivateKey
+	signer        jose.Signer
+	calls         map[string]int
+	revoked       map[string]bool
+	refreshTokens map[string]bool
+}
+
+// NewIssuer starts a new issuer for the given client id.
+func NewIssuer(clientID string) *Issuer {
+	i := NewUnstartedIssuer(clientID)
+	i.Start()
+	return i
+}
+
+// NewUnstartedIssuer returns an issuer which is not yet listening, e.g. to
+// configure TLS before calling StartTLS.
+func NewUnstartedIssuer(clientID string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key: jose.JSONWebKey{
+			Key:   key,
+			KeyID: keyId,
+		},
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	i := &Issuer{
+		ClientID:        clientID,
+		Subject:         uuid.NewString(),
+		Email:           "ops@getnoops.com",
+		Groups:          []string{"developers"},
+		TokenTTL:        time.Hour,
+		DeviceExpiresIn: 60,
+		DeviceInterval:  1,
+		key:             key,
+		signer:          signer,
+		calls:           map[string]int{},
+		revoked:         map[string]bool{},
+		refreshTokens:   map[string]bool{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidc.DiscoveryEndpoint, i.discovery)
+	mux.HandleFunc("/keys", i.keys)
+	mux.HandleFunc("/oauth/device_authorization", i.deviceAuthorization)
+	mux.HandleFunc("/oauth/token", i.token)
+	mux.HandleFunc("/oauth/revoke", i.revoke)
+
+	i.Server = httptest.NewUnstartedServer(mux)
+	return i
+}
+
+// Calls returns the number of requests made for a grant type or endpoint.
+func (i *Issuer) Calls(name string) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.calls[name]
+}
+
+// Revoked returns true if the token was sent to the revocation endpoint.
+func (i *Issuer) Revoked(token string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.revoked[token]
+}
+
+func (i *Issuer) count(name string) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.calls[name]++
+	return i.calls[name]
+}
+
+// NewTokens mints a signed set of tokens which expire at the given time.
+// The refresh token can be used once, refreshing rotates it.
+func (i *Issuer) NewTokens(expiry time.Time) (*oidc.Tokens[*oidc.IDTokenClaims], error) {
+	claims := oidc.NewIDTokenClaims(i.URL, i.Subject, []string{i.ClientID}, expiry, time.Now(), "", "", nil, i.ClientID, 0)
+	claims.Email = i.Email
+	claims.EmailVerified = true
+	claims.Claims = map[string]any{
+		"groups": i.Groups,
+	}
+
+	idToken, err := crypto.Sign(claims, i.signer)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  uuid.NewString(),
+		TokenType:    oidc.BearerToken,
+		RefreshToken: uuid.NewString(),
+		Expiry:       expiry,
+	}
+
+	i.mu.Lock()
+	i.refreshTokens[token.RefreshToken] = true
+	i.mu.Unlock()
+
+	return &oidc.Tokens[*oidc.IDTokenClaims]{
+		Token:         token,
+		IDTokenClaims: claims,
+		IDToken:       idToken,
+	}, nil
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &oidc.DiscoveryConfiguration{
+		Issuer:                      i.URL,
+		AuthorizationEndpoint:       i.URL + "/oauth/authorize",
+		TokenEndpoint:               i.URL + "/oauth/token",
+		DeviceAuthorizationEndpoint: i.URL + "/oauth/device_authorization",
+		RevocationEndpoint:          i.URL + "/oauth/revoke",
+		JwksURI:                     i.URL + "/keys",
+		GrantTypesSupported: []oidc.GrantType{
+			oidc.GrantTypeCode,
+			oidc.GrantTypeRefreshToken,
+			oidc.GrantTypeDeviceCode,
+			oidc.GrantTypeTokenExchange,
+			oidc.GrantTypeClientCredentials,
+		},
+		IDTokenSigningAlgValuesSupported: []string{string(jose.RS256)},
+	})
+}
+
+func (i *Issuer) keys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{{
+			Key:       &i.key.PublicKey,
+			KeyID:     keyId,
+			Algorithm: string(jose.RS256),
+			Use:       oidc.KeyUseSignature,
+		}},
+	})
+}
+
+func (i *Issuer) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	i.count("device_authorization")
+
+	writeJSON(w, http.StatusOK, &oidc.DeviceAuthorizationResponse{
+		DeviceCode:              uuid.NewString(),
+		UserCode:                "ABCD-EFGH",
+		VerificationURI:         i.URL + "/device",
+		VerificationURIComplete: i.URL + "/device?user_code=ABCD-EFGH",
+		ExpiresIn:               i.DeviceExpiresIn,
+		Interval:                i.DeviceInterval,
+	})
+}
+
+func (i *Issuer) revoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, oidc.ErrInvalidRequest().WithDescription(err.Error()))
+		return
+	}
+
+	i.count("revoke")
+
+	i.mu.Lock()
+	i.revoked[r.PostForm.Get("token")] = true
+	i.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, oidc.ErrInvalidRequest().WithDescription(err.Error()))
+		return
+	}
+
+	grantType := oidc.GrantType(r.PostForm.Get("grant_type"))
+	calls := i.count(string(grantType))
+
+	switch grantType {
+	case oidc.GrantTypeDeviceCode:
+		if calls <= i.DeviceSlowDown {
+			writeError(w, oidc.ErrSlowDown())
+			return
+		}
+		if calls <= i.DeviceSlowDown+i.DevicePending {
+			writeError(w, oidc.ErrAuthorizationPending())
+			return
+		}
+		i.writeTokens(w)
+	case oidc.GrantTypeRefreshToken:
+		time.Sleep(i.RefreshDelay)
+
+		refreshToken := r.PostForm.Get("refresh_token")
+
+		i.mu.Lock()
+		valid := i.refreshTokens[refreshToken]
+		delete(i.refreshTokens, refreshToken)
+		i.mu.Unlock()
+
+		if !valid {
+			writeError(w, oidc.ErrInvalidGrant().WithDescription("refresh token is invalid"))
+			return
+		}
+		i.writeTokens(w)
+	case oidc.GrantTypeTokenExchange:
+		subjectToken := r.PostForm.Get("subject_token")
+		if len(subjectToken) == 0 || len(r.PostForm.Get("subject_token_type")) == 0 {
+			writeError(w, oidc.ErrInvalidRequest().WithDescription("subject_token is required"))
+			return
+		}
+		if r.PostForm.Get("client_id") != i.ClientID {
+			writeError(w, oidc.ErrInvalidClient())
+			return
+		}
+		if len(i.SubjectToken) > 0 && subjectToken != i.SubjectToken {
+			writeError(w, oidc.ErrInvalidGrant().WithDescription("subject token is invalid"))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, &oidc.TokenExchangeResponse{
+			AccessToken:     uuid.NewString(),
+			IssuedTokenType: oidc.AccessTokenType,
+			TokenType:       oidc.BearerToken,
+			ExpiresIn:       uint64(i.TokenTTL.Seconds()),
+		})
+	case oidc.GrantTypeClientCredentials:
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if len(i.ClientSecret) == 0 || clientID != i.ClientID || clientSecret != i.ClientSecret {
+			writeError(w, oidc.ErrInvalidClient())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, &oidc.AccessTokenResponse{
+			AccessToken: uuid.NewString(),
+			TokenType:   oidc.BearerToken,
+			ExpiresIn:   uint64(i.TokenTTL.Seconds()),
+		})
+	default:
+		writeError(w, oidc.ErrUnsupportedGrantType())
+	}
+}
+
+func (i *Issuer) writeTokens(w http.ResponseWriter) {
+	tokens, err := i.NewTokens(time.Now().Add(i.TokenTTL))
+	if err != nil {
+		writeError(w, oidc.ErrServerError().WithDescription(err.Error()))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &oidc.AccessTokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokens.TokenType,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    uint64(i.TokenTTL.Seconds()),
+		IDToken:      tokens.IDToken,
+	})
+}
+
+func writeError(w http.ResponseWriter, err *oidc.Error) {
+	writeJSON(w, http.StatusBadRequest, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		panic(fmt.Errorf("failed to encode response: %w", err))
+	}
+}
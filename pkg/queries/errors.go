@@ -0,0 +1,242 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// The kinds of error returned by the api, use errors.Is to check the kind of
+// an *Error.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+	ErrValidation      = errors.New("validation failed")
+	ErrConflict        = errors.New("conflict")
+	ErrTransport       = errors.New("transport error")
+)
+
+// errorCodes maps the graphql extensions.code to the kind of error.
+var errorCodes = map[string]error{
+	"NOT_FOUND":                 ErrNotFound,
+	"UNAUTHENTICATED":           ErrUnauthenticated,
+	"UNAUTHORIZED":              ErrUnauthenticated,
+	"AUTH_NOT_AUTHENTICATED":    ErrUnauthenticated,
+	"FORBIDDEN":                 ErrForbidden,
+	"AUTH_NOT_AUTHORIZED":       ErrForbidden,
+	"BAD_USER_INPUT":            ErrValidation,
+	"BAD_REQUEST":               ErrValidation,
+	"VALIDATION":                ErrValidation,
+	"VALIDATION_ERROR":          ErrValidation,
+	"GRAPHQL_VALIDATION_FAILED": ErrValidation,
+	"GRAPHQL_PARSE_FAILED":      ErrValidation,
+	"CONFLICT":                  ErrConflict,
+	"ALREADY_EXISTS":            ErrConflict,
+	"INTERNAL_SERVER_ERROR":     ErrTransport,
+	"SERVICE_UNAVAILABLE":       ErrTransport,
+}
+
+// mutations change state in the api, when one is cancelled after the request
+// was sent the change may have been applied.
+var mutations = map[string]bool{
+	"CreateConfig":              true,
+	"UpdateConfig":              true,
+	"CreateContainerRepository": true,
+	"DeleteContainerRepository": true,
+	"CreateSecret":              true,
+	"UpdateSecret":              true,
+	"RestoreSecret":             true,
+	"DeleteSecret":              true,
+	"CreateApiKey":              true,
+	"UpdateApiKey":              true,
+	"DeleteApiKey":              true,
+	"NewDeployment":             true,
+	"DeleteDeployment":          true,
+	"CancelDeployment":          true,
+}
+
+// Error is returned by every operation in Queries.
+type Error struct {
+	// Kind is one of the Err kinds, it is nil when the error is not known.
+	Kind error
+	// Operation is the graphql operation name.
+	Operation string
+	// Code is the extensions.code of the first graphql error.
+	Code string
+	// Path is the path of the first graphql error.
+	Path    string
+	Message string
+	// MaybeApplied is set when a mutation was cancelled before the api
+	// responded, the change may or may not have been applied.
+	MaybeApplied bool
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Operation)
+	if e.Kind != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Kind.Error())
+	}
+	if len(e.Message) > 0 {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	if len(e.Path) > 0 {
+		b.WriteString(" at ")
+		b.WriteString(e.Path)
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// HTTPError is returned when the api responds without a 200 status.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("returned error %s: %s", e.Status, e.Body)
+}
+
+// newError classifies the error returned by a graphql operation.
+func newError(operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var existing *Error
+	if errors.As(err, &existing) {
+		return err
+	}
+
+	out := &Error{
+		Operation: operation,
+		Message:   err.Error(),
+		Err:       err,
+	}
+
+	var list gqlerror.List
+	var gqlErr *gqlerror.Error
+	var httpErr *HTTPError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		// a query is reported as is, a mutation may have reached the api.
+		if mutations[operation] {
+			out.MaybeApplied = true
+			out.Message = fmt.Sprintf("%s, the change may or may not have been applied, check its state before retrying", cancelReason(err))
+		}
+	case errors.As(err, &list) && len(list) > 0:
+		out.Message = joinMessages(list)
+		out.Path = list[0].Path.String()
+		for _, item := range list {
+			code, _ := item.Extensions["code"].(string)
+			if kind, ok := errorCodes[strings.ToUpper(code)]; ok {
+				out.Code = code
+				out.Kind = kind
+				break
+			}
+		}
+		if len(out.Code) == 0 {
+			out.Code, _ = list[0].Extensions["code"].(string)
+		}
+	case errors.As(err, &gqlErr):
+		out.Message = gqlErr.Message
+		out.Path = gqlErr.Path.String()
+		out.Code, _ = gqlErr.Extensions["code"].(string)
+		out.Kind = errorCodes[strings.ToUpper(out.Code)]
+	case errors.As(err, &httpErr):
+		out.Kind = statusKind(httpErr.StatusCode)
+	case errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		out.Kind = ErrTransport
+	}
+	return out
+}
+
+func cancelReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out"
+	}
+	return "cancelled"
+}
+
+// notFound is returned when an operation succeeds without returning the item.
+func notFound(operation string, format string, args ...any) error {
+	return &Error{
+		Kind:      ErrNotFound,
+		Operation: operation,
+		Message:   fmt.Sprintf(format, args...),
+	}
+}
+
+func statusKind(status int) error {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrUnauthenticated
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusConflict:
+		return ErrConflict
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return ErrValidation
+	default:
+		return ErrTransport
+	}
+}
+
+func joinMessages(list gqlerror.List) string {
+	messages := []string{}
+	for _, item := range list {
+		messages = append(messages, item.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// statusDoer returns an *HTTPError for responses without a 200 status so the
+// status code can be classified.
+type statusDoer struct {
+	client *http.Client
+}
+
+func (d *statusDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		body = []byte(fmt.Sprintf("<unreadable: %v>", err))
+	}
+	return nil, &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
+
+var _ graphql.Doer = (*statusDoer)(nil)
@@ -0,0 +1,134 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func Test_NewError(t *testing.T) {
+	gqlErr := func(code string) gqlerror.List {
+		return gqlerror.List{{
+			Message:    "failed",
+			Path:       ast.Path{ast.PathName("config")},
+			Extensions: map[string]interface{}{"code": code},
+		}}
+	}
+
+	cases := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "not found", err: gqlErr("NOT_FOUND"), expected: ErrNotFound},
+		{name: "unauthenticated", err: gqlErr("UNAUTHENTICATED"), expected: ErrUnauthenticated},
+		{name: "forbidden", err: gqlErr("AUTH_NOT_AUTHORIZED"), expected: ErrForbidden},
+		{name: "validation", err: gqlErr("BAD_USER_INPUT"), expected: ErrValidation},
+		{name: "conflict", err: gqlErr("conflict"), expected: ErrConflict},
+		{name: "unknown code", err: gqlErr("TEAPOT"), expected: nil},
+		{name: "http 401", err: &HTTPError{StatusCode: http.StatusUnauthorized}, expected: ErrUnauthenticated},
+		{name: "http 502", err: &HTTPError{StatusCode: http.StatusBadGateway}, expected: ErrTransport},
+		{name: "eof", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), expected: ErrTransport},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := newError("GetConfig", c.err)
+
+			var out *Error
+			if !errors.As(err, &out) {
+				t.Fatalf("expected *Error, got %T", err)
+			}
+			if out.Kind != c.expected {
+				t.Fatalf("expected kind %v, got %v", c.expected, out.Kind)
+			}
+			if c.expected != nil && !errors.Is(err, c.expected) {
+				t.Fatalf("expected errors.Is(%v)", c.expected)
+			}
+			if out.Operation != "GetConfig" {
+				t.Fatalf("expected operation GetConfig, got %s", out.Operation)
+			}
+		})
+	}
+}
+
+func Test_NewError_Path(t *testing.T) {
+	err := newError("GetConfig", gqlerror.List{{
+		Message:    "config missing",
+		Path:       ast.Path{ast.PathName("config"), ast.PathIndex(0)},
+		Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+	}})
+
+	var out *Error
+	if !errors.As(err, &out) {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if out.Path != "config[0]" || out.Code != "NOT_FOUND" {
+		t.Fatalf("expected path config[0] and code NOT_FOUND, got %s %s", out.Path, out.Code)
+	}
+	if err.Error() != "GetConfig: not found: config missing at config[0]" {
+		t.Fatalf("unexpected message %s", err.Error())
+	}
+}
+
+func Test_StatusDoer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("denied"))
+	}))
+	defer server.Close()
+
+	client := graphql.NewClient(server.URL, &statusDoer{client: server.Client()})
+	err := client.MakeRequest(context.Background(), &graphql.Request{OpName: "GetConfig", Query: "query GetConfig { config }"}, &graphql.Response{})
+
+	err = newError("GetConfig", err)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected %v, got %v", ErrForbidden, err)
+	}
+}
+
+func Test_NewError_Cancelled(t *testing.T) {
+	// the api never answers, the handler returns once the test is done.
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := graphql.NewClient(server.URL, &statusDoer{client: server.Client()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := DeleteSecret(ctx, client, uuid.New(), uuid.New())
+	err = newError("DeleteSecret", err)
+
+	var out *Error
+	if !errors.As(err, &out) || !out.MaybeApplied {
+		t.Fatalf("expected the mutation to maybe be applied, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if !strings.HasPrefix(err.Error(), "DeleteSecret: timed out, the change may or may not have been applied") {
+		t.Fatalf("unexpected message %s", err.Error())
+	}
+
+	_, err = GetConfig(ctx, client, uuid.New(), "api")
+	err = newError("GetConfig", err)
+	if !errors.As(err, &out) || out.MaybeApplied {
+		t.Fatalf("expected a query to be reported as is, got %v", err)
+	}
+}
@@ -78,6 +78,14 @@ func (v *AuthContainerRepository) GetPassword() string { return v.Password }
 // GetRegistry_url returns AuthContainerRepository.Registry_url, and is useful for accessing the field via an interface.
 func (v *AuthContainerRepository) GetRegistry_url() string { return v.Registry_url }
 
+// CancelDeploymentResponse is returned by CancelDeployment on success.
+type CancelDeploymentResponse struct {
+	CancelDeployment uuid.UUID `json:"cancelDeployment"`
+}
+
+// GetCancelDeployment returns CancelDeploymentResponse.CancelDeployment, and is useful for accessing the field via an interface.
+func (v *CancelDeploymentResponse) GetCancelDeployment() uuid.UUID { return v.CancelDeployment }
+
 // Config includes the requested fields of the GraphQL type Config.
 type Config struct {
 	Id                    uuid.UUID                  `json:"id"`
@@ -326,11 +334,12 @@ func (v *DeleteSecretResponse) GetDeleteSecret() uuid.UUID { return v.DeleteSecr
 
 // Deployment includes the requested fields of the GraphQL type Deployment.
 type Deployment struct {
-	Id          uuid.UUID    `json:"id"`
-	State       StackState   `json:"state"`
-	Environment *Environment `json:"environment"`
-	Created_at  time.Time    `json:"created_at"`
-	Updated_at  time.Time    `json:"updated_at"`
+	Id          uuid.UUID        `json:"id"`
+	State       StackState       `json:"state"`
+	Environment *Environment     `json:"environment"`
+	Stack       *DeploymentStack `json:"stack"`
+	Created_at  time.Time        `json:"created_at"`
+	Updated_at  time.Time        `json:"updated_at"`
 }
 
 // GetId returns Deployment.Id, and is useful for accessing the field via an interface.
@@ -342,21 +351,108 @@ func (v *Deployment) GetState() StackState { return v.State }
 // GetEnvironment returns Deployment.Environment, and is useful for accessing the field via an interface.
 func (v *Deployment) GetEnvironment() *Environment { return v.Environment }
 
+// GetStack returns Deployment.Stack, and is useful for accessing the field via an interface.
+func (v *Deployment) GetStack() *DeploymentStack { return v.Stack }
+
 // GetCreated_at returns Deployment.Created_at, and is useful for accessing the field via an interface.
 func (v *Deployment) GetCreated_at() time.Time { return v.Created_at }
 
 // GetUpdated_at returns Deployment.Updated_at, and is useful for accessing the field via an interface.
 func (v *Deployment) GetUpdated_at() time.Time { return v.Updated_at }
 
+// DeploymentEnvironment includes the requested fields of the GraphQL type DeploymentEnvironment.
+type DeploymentEnvironment struct {
+	Id         uuid.UUID `json:"id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Created_at time.Time `json:"created_at"`
+	Updated_at time.Time `json:"updated_at"`
+}
+
+// GetId returns DeploymentEnvironment.Id, and is useful for accessing the field via an interface.
+func (v *DeploymentEnvironment) GetId() uuid.UUID { return v.Id }
+
+// GetCode returns DeploymentEnvironment.Code, and is useful for accessing the field via an interface.
+func (v *DeploymentEnvironment) GetCode() string { return v.Code }
+
+// GetName returns DeploymentEnvironment.Name, and is useful for accessing the field via an interface.
+func (v *DeploymentEnvironment) GetName() string { return v.Name }
+
+// GetCreated_at returns DeploymentEnvironment.Created_at, and is useful for accessing the field via an interface.
+func (v *DeploymentEnvironment) GetCreated_at() time.Time { return v.Created_at }
+
+// GetUpdated_at returns DeploymentEnvironment.Updated_at, and is useful for accessing the field via an interface.
+func (v *DeploymentEnvironment) GetUpdated_at() time.Time { return v.Updated_at }
+
+// DeploymentItem includes the requested fields of the GraphQL type DeploymentItem.
+type DeploymentItem struct {
+	Deployment_id          uuid.UUID                  `json:"deployment_id"`
+	Version_number         string                     `json:"version_number"`
+	Deployment_revision_id uuid.UUID                  `json:"deployment_revision_id"`
+	Config_revision_id     uuid.UUID                  `json:"config_revision_id"`
+	Environment            *DeploymentItemEnvironment `json:"environment"`
+	Exists                 bool                       `json:"exists"`
+	Active                 bool                       `json:"active"`
+	Previous               bool                       `json:"previous"`
+	State                  StackState                 `json:"state"`
+	Updated_at             time.Time                  `json:"updated_at"`
+}
+
+// GetDeployment_id returns DeploymentItem.Deployment_id, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetDeployment_id() uuid.UUID { return v.Deployment_id }
+
+// GetVersion_number returns DeploymentItem.Version_number, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetVersion_number() string { return v.Version_number }
+
+// GetDeployment_revision_id returns DeploymentItem.Deployment_revision_id, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetDeployment_revision_id() uuid.UUID { return v.Deployment_revision_id }
+
+// GetConfig_revision_id returns DeploymentItem.Config_revision_id, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetConfig_revision_id() uuid.UUID { return v.Config_revision_id }
+
+// GetEnvironment returns DeploymentItem.Environment, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetEnvironment() *DeploymentItemEnvironment { return v.Environment }
+
+// GetExists returns DeploymentItem.Exists, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetExists() bool { return v.Exists }
+
+// GetActive returns DeploymentItem.Active, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetActive() bool { return v.Active }
+
+// GetPrevious returns DeploymentItem.Previous, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetPrevious() bool { return v.Previous }
+
+// GetState returns DeploymentItem.State, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetState() StackState { return v.State }
+
+// GetUpdated_at returns DeploymentItem.Updated_at, and is useful for accessing the field via an interface.
+func (v *DeploymentItem) GetUpdated_at() time.Time { return v.Updated_at }
+
+// DeploymentItemEnvironment includes the requested fields of the GraphQL type Environment.
+type DeploymentItemEnvironment struct {
+	Id   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+	Name string    `json:"name"`
+}
+
+// GetId returns DeploymentItemEnvironment.Id, and is useful for accessing the field via an interface.
+func (v *DeploymentItemEnvironment) GetId() uuid.UUID { return v.Id }
+
+// GetCode returns DeploymentItemEnvironment.Code, and is useful for accessing the field via an interface.
+func (v *DeploymentItemEnvironment) GetCode() string { return v.Code }
+
+// GetName returns DeploymentItemEnvironment.Name, and is useful for accessing the field via an interface.
+func (v *DeploymentItemEnvironment) GetName() string { return v.Name }
+
 // DeploymentRevision includes the requested fields of the GraphQL type DeploymentRevision.
 type DeploymentRevision struct {
-	Id          uuid.UUID    `json:"id"`
-	State       StackState   `json:"state"`
-	Deployment  *Deployment  `json:"deployment"`
-	Environment *Environment `json:"environment"`
-	Config      *ConfigItem  `json:"config"`
-	Created_at  time.Time    `json:"created_at"`
-	Updated_at  time.Time    `json:"updated_at"`
+	Id          uuid.UUID           `json:"id"`
+	State       StackState          `json:"state"`
+	Deployment  *RevisionDeployment `json:"deployment"`
+	Environment *Environment        `json:"environment"`
+	Config      *ConfigItem         `json:"config"`
+	Created_at  time.Time           `json:"created_at"`
+	Updated_at  time.Time           `json:"updated_at"`
 }
 
 // GetId returns DeploymentRevision.Id, and is useful for accessing the field via an interface.
@@ -366,7 +462,7 @@ func (v *DeploymentRevision) GetId() uuid.UUID { return v.Id }
 func (v *DeploymentRevision) GetState() StackState { return v.State }
 
 // GetDeployment returns DeploymentRevision.Deployment, and is useful for accessing the field via an interface.
-func (v *DeploymentRevision) GetDeployment() *Deployment { return v.Deployment }
+func (v *DeploymentRevision) GetDeployment() *RevisionDeployment { return v.Deployment }
 
 // GetEnvironment returns DeploymentRevision.Environment, and is useful for accessing the field via an interface.
 func (v *DeploymentRevision) GetEnvironment() *Environment { return v.Environment }
@@ -380,6 +476,54 @@ func (v *DeploymentRevision) GetCreated_at() time.Time { return v.Created_at }
 // GetUpdated_at returns DeploymentRevision.Updated_at, and is useful for accessing the field via an interface.
 func (v *DeploymentRevision) GetUpdated_at() time.Time { return v.Updated_at }
 
+// DeploymentStack includes the requested fields of the GraphQL type Stack.
+type DeploymentStack struct {
+	Name    string         `json:"name"`
+	Outputs []*StackOutput `json:"outputs"`
+}
+
+// GetName returns DeploymentStack.Name, and is useful for accessing the field via an interface.
+func (v *DeploymentStack) GetName() string { return v.Name }
+
+// GetOutputs returns DeploymentStack.Outputs, and is useful for accessing the field via an interface.
+func (v *DeploymentStack) GetOutputs() []*StackOutput { return v.Outputs }
+
+// DeploymentVersion includes the requested fields of the GraphQL type DeploymentVersion.
+type DeploymentVersion struct {
+	Version_number string            `json:"version_number"`
+	State          ConfigState       `json:"state"`
+	Created_at     time.Time         `json:"created_at"`
+	Updated_at     time.Time         `json:"updated_at"`
+	Items          []*DeploymentItem `json:"items"`
+}
+
+// GetVersion_number returns DeploymentVersion.Version_number, and is useful for accessing the field via an interface.
+func (v *DeploymentVersion) GetVersion_number() string { return v.Version_number }
+
+// GetState returns DeploymentVersion.State, and is useful for accessing the field via an interface.
+func (v *DeploymentVersion) GetState() ConfigState { return v.State }
+
+// GetCreated_at returns DeploymentVersion.Created_at, and is useful for accessing the field via an interface.
+func (v *DeploymentVersion) GetCreated_at() time.Time { return v.Created_at }
+
+// GetUpdated_at returns DeploymentVersion.Updated_at, and is useful for accessing the field via an interface.
+func (v *DeploymentVersion) GetUpdated_at() time.Time { return v.Updated_at }
+
+// GetItems returns DeploymentVersion.Items, and is useful for accessing the field via an interface.
+func (v *DeploymentVersion) GetItems() []*DeploymentItem { return v.Items }
+
+// Deployments includes the requested fields of the GraphQL type Deployments.
+type Deployments struct {
+	Versions     []*DeploymentVersion     `json:"versions"`
+	Environments []*DeploymentEnvironment `json:"environments"`
+}
+
+// GetVersions returns Deployments.Versions, and is useful for accessing the field via an interface.
+func (v *Deployments) GetVersions() []*DeploymentVersion { return v.Versions }
+
+// GetEnvironments returns Deployments.Environments, and is useful for accessing the field via an interface.
+func (v *Deployments) GetEnvironments() []*DeploymentEnvironment { return v.Environments }
+
 // Environment includes the requested fields of the GraphQL type Environment.
 type Environment struct {
 	Id         uuid.UUID       `json:"id"`
@@ -387,6 +531,8 @@ type Environment struct {
 	State      StackState      `json:"state"`
 	Code       string          `json:"code"`
 	Name       string          `json:"name"`
+	Regions    []string        `json:"regions"`
+	Sort_order int             `json:"sort_order"`
 	Created_at time.Time       `json:"created_at"`
 	Updated_at time.Time       `json:"updated_at"`
 }
@@ -406,6 +552,12 @@ func (v *Environment) GetCode() string { return v.Code }
 // GetName returns Environment.Name, and is useful for accessing the field via an interface.
 func (v *Environment) GetName() string { return v.Name }
 
+// GetRegions returns Environment.Regions, and is useful for accessing the field via an interface.
+func (v *Environment) GetRegions() []string { return v.Regions }
+
+// GetSort_order returns Environment.Sort_order, and is useful for accessing the field via an interface.
+func (v *Environment) GetSort_order() int { return v.Sort_order }
+
 // GetCreated_at returns Environment.Created_at, and is useful for accessing the field via an interface.
 func (v *Environment) GetCreated_at() time.Time { return v.Created_at }
 
@@ -510,6 +662,14 @@ func (v *GetDeploymentRevisionResponse) GetDeploymentRevision() *DeploymentRevis
 	return v.DeploymentRevision
 }
 
+// GetDeploymentsResponse is returned by GetDeployments on success.
+type GetDeploymentsResponse struct {
+	Deployments *Deployments `json:"deployments"`
+}
+
+// GetDeployments returns GetDeploymentsResponse.Deployments, and is useful for accessing the field via an interface.
+func (v *GetDeploymentsResponse) GetDeployments() *Deployments { return v.Deployments }
+
 // GetEnvironmentsEnvironmentsPagedEnvironmentsOutput includes the requested fields of the GraphQL type PagedEnvironmentsOutput.
 type GetEnvironmentsEnvironmentsPagedEnvironmentsOutput struct {
 	Items       []*Environment `json:"items"`
@@ -594,6 +754,22 @@ func (v *GetMemberOrganisationsResponse) GetMemberOrganisations() *GetMemberOrga
 	return v.MemberOrganisations
 }
 
+// GetMetricsResponse is returned by GetMetrics on success.
+type GetMetricsResponse struct {
+	Metrics *Metrics `json:"metrics"`
+}
+
+// GetMetrics returns GetMetricsResponse.Metrics, and is useful for accessing the field via an interface.
+func (v *GetMetricsResponse) GetMetrics() *Metrics { return v.Metrics }
+
+// GetStackInfoResponse is returned by GetStackInfo on success.
+type GetStackInfoResponse struct {
+	StackInfo *StackDetails `json:"stackInfo"`
+}
+
+// GetStackInfo returns GetStackInfoResponse.StackInfo, and is useful for accessing the field via an interface.
+func (v *GetStackInfoResponse) GetStackInfo() *StackDetails { return v.StackInfo }
+
 // IdWithToken includes the requested fields of the GraphQL type IdWithToken.
 type IdWithToken struct {
 	Id    uuid.UUID `json:"id"`
@@ -616,6 +792,132 @@ func (v *LoginContainerRepositoryResponse) GetLoginContainerRepository() *AuthCo
 	return v.LoginContainerRepository
 }
 
+// MessageData includes the requested fields of the GraphQL type MessageData.
+type MessageData struct {
+	Code  string `json:"code"`
+	Value string `json:"value"`
+}
+
+// GetCode returns MessageData.Code, and is useful for accessing the field via an interface.
+func (v *MessageData) GetCode() string { return v.Code }
+
+// GetValue returns MessageData.Value, and is useful for accessing the field via an interface.
+func (v *MessageData) GetValue() string { return v.Value }
+
+// MetricDataResult includes the requested fields of the GraphQL type MetricDataResult.
+type MetricDataResult struct {
+	Id          string           `json:"id"`
+	Label       string           `json:"label"`
+	Status_code MetricStatusCode `json:"status_code"`
+	Timestamps  []time.Time      `json:"timestamps"`
+	Values      []float64        `json:"values"`
+}
+
+// GetId returns MetricDataResult.Id, and is useful for accessing the field via an interface.
+func (v *MetricDataResult) GetId() string { return v.Id }
+
+// GetLabel returns MetricDataResult.Label, and is useful for accessing the field via an interface.
+func (v *MetricDataResult) GetLabel() string { return v.Label }
+
+// GetStatus_code returns MetricDataResult.Status_code, and is useful for accessing the field via an interface.
+func (v *MetricDataResult) GetStatus_code() MetricStatusCode { return v.Status_code }
+
+// GetTimestamps returns MetricDataResult.Timestamps, and is useful for accessing the field via an interface.
+func (v *MetricDataResult) GetTimestamps() []time.Time { return v.Timestamps }
+
+// GetValues returns MetricDataResult.Values, and is useful for accessing the field via an interface.
+func (v *MetricDataResult) GetValues() []float64 { return v.Values }
+
+type MetricDimension struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GetName returns MetricDimension.Name, and is useful for accessing the field via an interface.
+func (v *MetricDimension) GetName() string { return v.Name }
+
+// GetValue returns MetricDimension.Value, and is useful for accessing the field via an interface.
+func (v *MetricDimension) GetValue() string { return v.Value }
+
+type MetricQueryInput struct {
+	Id               string             `json:"id"`
+	Label            string             `json:"label"`
+	Metric_statistic string             `json:"metric_statistic"`
+	Metric_name      string             `json:"metric_name"`
+	Period           int                `json:"period"`
+	Dimensions       []*MetricDimension `json:"dimensions,omitempty"`
+}
+
+// GetId returns MetricQueryInput.Id, and is useful for accessing the field via an interface.
+func (v *MetricQueryInput) GetId() string { return v.Id }
+
+// GetLabel returns MetricQueryInput.Label, and is useful for accessing the field via an interface.
+func (v *MetricQueryInput) GetLabel() string { return v.Label }
+
+// GetMetric_statistic returns MetricQueryInput.Metric_statistic, and is useful for accessing the field via an interface.
+func (v *MetricQueryInput) GetMetric_statistic() string { return v.Metric_statistic }
+
+// GetMetric_name returns MetricQueryInput.Metric_name, and is useful for accessing the field via an interface.
+func (v *MetricQueryInput) GetMetric_name() string { return v.Metric_name }
+
+// GetPeriod returns MetricQueryInput.Period, and is useful for accessing the field via an interface.
+func (v *MetricQueryInput) GetPeriod() int { return v.Period }
+
+// GetDimensions returns MetricQueryInput.Dimensions, and is useful for accessing the field via an interface.
+func (v *MetricQueryInput) GetDimensions() []*MetricDimension { return v.Dimensions }
+
+type MetricStatusCode string
+
+const (
+	MetricStatusCodeComplete      MetricStatusCode = "Complete"
+	MetricStatusCodeInternalerror MetricStatusCode = "InternalError"
+	MetricStatusCodePartialdata   MetricStatusCode = "PartialData"
+	MetricStatusCodeForbidden     MetricStatusCode = "Forbidden"
+)
+
+// Metrics includes the requested fields of the GraphQL type MetricsOutput.
+type Metrics struct {
+	Metric_data_results []*MetricDataResult `json:"metric_data_results"`
+	Messages            []*MessageData      `json:"messages"`
+}
+
+// GetMetric_data_results returns Metrics.Metric_data_results, and is useful for accessing the field via an interface.
+func (v *Metrics) GetMetric_data_results() []*MetricDataResult { return v.Metric_data_results }
+
+// GetMessages returns Metrics.Messages, and is useful for accessing the field via an interface.
+func (v *Metrics) GetMessages() []*MessageData { return v.Messages }
+
+type MetricsInput struct {
+	Organisation_id  uuid.UUID           `json:"organisation_id"`
+	Deployment_id    uuid.UUID           `json:"deployment_id"`
+	Region           string              `json:"region"`
+	Start_time       int                 `json:"start_time"`
+	End_time         int                 `json:"end_time"`
+	Metric_queries   []*MetricQueryInput `json:"metric_queries,omitempty"`
+	Metric_namespace string              `json:"metric_namespace"`
+}
+
+// GetOrganisation_id returns MetricsInput.Organisation_id, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetOrganisation_id() uuid.UUID { return v.Organisation_id }
+
+// GetDeployment_id returns MetricsInput.Deployment_id, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetDeployment_id() uuid.UUID { return v.Deployment_id }
+
+// GetRegion returns MetricsInput.Region, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetRegion() string { return v.Region }
+
+// GetStart_time returns MetricsInput.Start_time, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetStart_time() int { return v.Start_time }
+
+// GetEnd_time returns MetricsInput.End_time, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetEnd_time() int { return v.End_time }
+
+// GetMetric_queries returns MetricsInput.Metric_queries, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetMetric_queries() []*MetricQueryInput { return v.Metric_queries }
+
+// GetMetric_namespace returns MetricsInput.Metric_namespace, and is useful for accessing the field via an interface.
+func (v *MetricsInput) GetMetric_namespace() string { return v.Metric_namespace }
+
 // NewDeploymentResponse is returned by NewDeployment on success.
 type NewDeploymentResponse struct {
 	NewDeployment uuid.UUID `json:"newDeployment"`
@@ -750,6 +1052,34 @@ type RestoreSecretResponse struct {
 // GetRestoreSecret returns RestoreSecretResponse.RestoreSecret, and is useful for accessing the field via an interface.
 func (v *RestoreSecretResponse) GetRestoreSecret() uuid.UUID { return v.RestoreSecret }
 
+// RevisionDeployment includes the requested fields of the GraphQL type Deployment.
+type RevisionDeployment struct {
+	Id          uuid.UUID      `json:"id"`
+	State       StackState     `json:"state"`
+	Environment *Environment   `json:"environment"`
+	Stack       *RevisionStack `json:"stack"`
+	Created_at  time.Time      `json:"created_at"`
+	Updated_at  time.Time      `json:"updated_at"`
+}
+
+// GetId returns RevisionDeployment.Id, and is useful for accessing the field via an interface.
+func (v *RevisionDeployment) GetId() uuid.UUID { return v.Id }
+
+// GetState returns RevisionDeployment.State, and is useful for accessing the field via an interface.
+func (v *RevisionDeployment) GetState() StackState { return v.State }
+
+// GetEnvironment returns RevisionDeployment.Environment, and is useful for accessing the field via an interface.
+func (v *RevisionDeployment) GetEnvironment() *Environment { return v.Environment }
+
+// GetStack returns RevisionDeployment.Stack, and is useful for accessing the field via an interface.
+func (v *RevisionDeployment) GetStack() *RevisionStack { return v.Stack }
+
+// GetCreated_at returns RevisionDeployment.Created_at, and is useful for accessing the field via an interface.
+func (v *RevisionDeployment) GetCreated_at() time.Time { return v.Created_at }
+
+// GetUpdated_at returns RevisionDeployment.Updated_at, and is useful for accessing the field via an interface.
+func (v *RevisionDeployment) GetUpdated_at() time.Time { return v.Updated_at }
+
 // RevisionItem includes the requested fields of the GraphQL type ConfigRevision.
 type RevisionItem struct {
 	Id             uuid.UUID   `json:"id"`
@@ -774,6 +1104,26 @@ func (v *RevisionItem) GetCreated_at() time.Time { return v.Created_at }
 // GetUpdated_at returns RevisionItem.Updated_at, and is useful for accessing the field via an interface.
 func (v *RevisionItem) GetUpdated_at() time.Time { return v.Updated_at }
 
+// RevisionStack includes the requested fields of the GraphQL type Stack.
+type RevisionStack struct {
+	Name      string           `json:"name"`
+	Status    string           `json:"status"`
+	Resources []*StackResource `json:"resources"`
+	Outputs   []*StackOutput   `json:"outputs"`
+}
+
+// GetName returns RevisionStack.Name, and is useful for accessing the field via an interface.
+func (v *RevisionStack) GetName() string { return v.Name }
+
+// GetStatus returns RevisionStack.Status, and is useful for accessing the field via an interface.
+func (v *RevisionStack) GetStatus() string { return v.Status }
+
+// GetResources returns RevisionStack.Resources, and is useful for accessing the field via an interface.
+func (v *RevisionStack) GetResources() []*StackResource { return v.Resources }
+
+// GetOutputs returns RevisionStack.Outputs, and is useful for accessing the field via an interface.
+func (v *RevisionStack) GetOutputs() []*StackOutput { return v.Outputs }
+
 // SecretItem includes the requested fields of the GraphQL type Secret.
 type SecretItem struct {
 	Id          uuid.UUID        `json:"id"`
@@ -826,6 +1176,101 @@ func (v *SecretItemStackOutputsStackOutput) GetOutput_key() string { return v.Ou
 // GetOutput_value returns SecretItemStackOutputsStackOutput.Output_value, and is useful for accessing the field via an interface.
 func (v *SecretItemStackOutputsStackOutput) GetOutput_value() string { return v.Output_value }
 
+// StackDetails includes the requested fields of the GraphQL type StackDetails.
+type StackDetails struct {
+	Status        string                `json:"status"`
+	Status_reason string                `json:"status_reason"`
+	Resources     []*StackResource      `json:"resources"`
+	Outputs       []*StackDetailsOutput `json:"outputs"`
+}
+
+// GetStatus returns StackDetails.Status, and is useful for accessing the field via an interface.
+func (v *StackDetails) GetStatus() string { return v.Status }
+
+// GetStatus_reason returns StackDetails.Status_reason, and is useful for accessing the field via an interface.
+func (v *StackDetails) GetStatus_reason() string { return v.Status_reason }
+
+// GetResources returns StackDetails.Resources, and is useful for accessing the field via an interface.
+func (v *StackDetails) GetResources() []*StackResource { return v.Resources }
+
+// GetOutputs returns StackDetails.Outputs, and is useful for accessing the field via an interface.
+func (v *StackDetails) GetOutputs() []*StackDetailsOutput { return v.Outputs }
+
+// StackDetailsOutput includes the requested fields of the GraphQL type StackOutput.
+type StackDetailsOutput struct {
+	Description  string `json:"description"`
+	Output_key   string `json:"output_key"`
+	Output_value string `json:"output_value"`
+}
+
+// GetDescription returns StackDetailsOutput.Description, and is useful for accessing the field via an interface.
+func (v *StackDetailsOutput) GetDescription() string { return v.Description }
+
+// GetOutput_key returns StackDetailsOutput.Output_key, and is useful for accessing the field via an interface.
+func (v *StackDetailsOutput) GetOutput_key() string { return v.Output_key }
+
+// GetOutput_value returns StackDetailsOutput.Output_value, and is useful for accessing the field via an interface.
+func (v *StackDetailsOutput) GetOutput_value() string { return v.Output_value }
+
+// StackOutput includes the requested fields of the GraphQL type StackOutput.
+type StackOutput struct {
+	Output_key   string `json:"output_key"`
+	Output_value string `json:"output_value"`
+}
+
+// GetOutput_key returns StackOutput.Output_key, and is useful for accessing the field via an interface.
+func (v *StackOutput) GetOutput_key() string { return v.Output_key }
+
+// GetOutput_value returns StackOutput.Output_value, and is useful for accessing the field via an interface.
+func (v *StackOutput) GetOutput_value() string { return v.Output_value }
+
+// StackResource includes the requested fields of the GraphQL type StackResource.
+type StackResource struct {
+	Physical_resource_id   string    `json:"physical_resource_id"`
+	Logical_resource_id    string    `json:"logical_resource_id"`
+	Resource_type          string    `json:"resource_type"`
+	Resource_status        string    `json:"resource_status"`
+	Resource_status_reason string    `json:"resource_status_reason"`
+	Timestamp              time.Time `json:"timestamp"`
+}
+
+// GetPhysical_resource_id returns StackResource.Physical_resource_id, and is useful for accessing the field via an interface.
+func (v *StackResource) GetPhysical_resource_id() string { return v.Physical_resource_id }
+
+// GetLogical_resource_id returns StackResource.Logical_resource_id, and is useful for accessing the field via an interface.
+func (v *StackResource) GetLogical_resource_id() string { return v.Logical_resource_id }
+
+// GetResource_type returns StackResource.Resource_type, and is useful for accessing the field via an interface.
+func (v *StackResource) GetResource_type() string { return v.Resource_type }
+
+// GetResource_status returns StackResource.Resource_status, and is useful for accessing the field via an interface.
+func (v *StackResource) GetResource_status() string { return v.Resource_status }
+
+// GetResource_status_reason returns StackResource.Resource_status_reason, and is useful for accessing the field via an interface.
+func (v *StackResource) GetResource_status_reason() string { return v.Resource_status_reason }
+
+// GetTimestamp returns StackResource.Timestamp, and is useful for accessing the field via an interface.
+func (v *StackResource) GetTimestamp() time.Time { return v.Timestamp }
+
+type StackSetInstanceInput struct {
+	Organisation_id uuid.UUID `json:"organisation_id"`
+	Environment_id  uuid.UUID `json:"environment_id"`
+	Aws_region      string    `json:"aws_region"`
+	Stack_set_name  string    `json:"stack_set_name"`
+}
+
+// GetOrganisation_id returns StackSetInstanceInput.Organisation_id, and is useful for accessing the field via an interface.
+func (v *StackSetInstanceInput) GetOrganisation_id() uuid.UUID { return v.Organisation_id }
+
+// GetEnvironment_id returns StackSetInstanceInput.Environment_id, and is useful for accessing the field via an interface.
+func (v *StackSetInstanceInput) GetEnvironment_id() uuid.UUID { return v.Environment_id }
+
+// GetAws_region returns StackSetInstanceInput.Aws_region, and is useful for accessing the field via an interface.
+func (v *StackSetInstanceInput) GetAws_region() string { return v.Aws_region }
+
+// GetStack_set_name returns StackSetInstanceInput.Stack_set_name, and is useful for accessing the field via an interface.
+func (v *StackSetInstanceInput) GetStack_set_name() string { return v.Stack_set_name }
+
 type StackState string
 
 const (
@@ -895,6 +1340,18 @@ type UpdateSecretResponse struct {
 // GetUpdateSecret returns UpdateSecretResponse.UpdateSecret, and is useful for accessing the field via an interface.
 func (v *UpdateSecretResponse) GetUpdateSecret() uuid.UUID { return v.UpdateSecret }
 
+// __CancelDeploymentInput is used internally by genqlient
+type __CancelDeploymentInput struct {
+	OrganisationId uuid.UUID `json:"organisationId"`
+	Id             uuid.UUID `json:"id"`
+}
+
+// GetOrganisationId returns __CancelDeploymentInput.OrganisationId, and is useful for accessing the field via an interface.
+func (v *__CancelDeploymentInput) GetOrganisationId() uuid.UUID { return v.OrganisationId }
+
+// GetId returns __CancelDeploymentInput.Id, and is useful for accessing the field via an interface.
+func (v *__CancelDeploymentInput) GetId() uuid.UUID { return v.Id }
+
 // __CreateApiKeyInput is used internally by genqlient
 type __CreateApiKeyInput struct {
 	AggregateId    uuid.UUID `json:"aggregateId"`
@@ -1095,6 +1552,26 @@ func (v *__GetDeploymentRevisionInput) GetOrganisationId() uuid.UUID { return v.
 // GetAggregateId returns __GetDeploymentRevisionInput.AggregateId, and is useful for accessing the field via an interface.
 func (v *__GetDeploymentRevisionInput) GetAggregateId() uuid.UUID { return v.AggregateId }
 
+// __GetDeploymentsInput is used internally by genqlient
+type __GetDeploymentsInput struct {
+	OrganisationId uuid.UUID `json:"organisationId"`
+	ConfigId       uuid.UUID `json:"configId"`
+	Page           int       `json:"page"`
+	PageSize       int       `json:"pageSize"`
+}
+
+// GetOrganisationId returns __GetDeploymentsInput.OrganisationId, and is useful for accessing the field via an interface.
+func (v *__GetDeploymentsInput) GetOrganisationId() uuid.UUID { return v.OrganisationId }
+
+// GetConfigId returns __GetDeploymentsInput.ConfigId, and is useful for accessing the field via an interface.
+func (v *__GetDeploymentsInput) GetConfigId() uuid.UUID { return v.ConfigId }
+
+// GetPage returns __GetDeploymentsInput.Page, and is useful for accessing the field via an interface.
+func (v *__GetDeploymentsInput) GetPage() int { return v.Page }
+
+// GetPageSize returns __GetDeploymentsInput.PageSize, and is useful for accessing the field via an interface.
+func (v *__GetDeploymentsInput) GetPageSize() int { return v.PageSize }
+
 // __GetEnvironmentsInput is used internally by genqlient
 type __GetEnvironmentsInput struct {
 	OrganisationId uuid.UUID    `json:"organisationId"`
@@ -1131,6 +1608,22 @@ func (v *__GetMemberOrganisationsInput) GetPage() int { return v.Page }
 // GetPageSize returns __GetMemberOrganisationsInput.PageSize, and is useful for accessing the field via an interface.
 func (v *__GetMemberOrganisationsInput) GetPageSize() int { return v.PageSize }
 
+// __GetMetricsInput is used internally by genqlient
+type __GetMetricsInput struct {
+	Input *MetricsInput `json:"input,omitempty"`
+}
+
+// GetInput returns __GetMetricsInput.Input, and is useful for accessing the field via an interface.
+func (v *__GetMetricsInput) GetInput() *MetricsInput { return v.Input }
+
+// __GetStackInfoInput is used internally by genqlient
+type __GetStackInfoInput struct {
+	Input *StackSetInstanceInput `json:"input,omitempty"`
+}
+
+// GetInput returns __GetStackInfoInput.Input, and is useful for accessing the field via an interface.
+func (v *__GetStackInfoInput) GetInput() *StackSetInstanceInput { return v.Input }
+
 // __LoginContainerRepositoryInput is used internally by genqlient
 type __LoginContainerRepositoryInput struct {
 	OrganisationId uuid.UUID `json:"organisationId"`
@@ -1223,6 +1716,41 @@ func (v *__UpdateSecretInput) GetCode() string { return v.Code }
 // GetValue returns __UpdateSecretInput.Value, and is useful for accessing the field via an interface.
 func (v *__UpdateSecretInput) GetValue() string { return v.Value }
 
+// The query or mutation executed by CancelDeployment.
+const CancelDeployment_Operation = `
+mutation CancelDeployment ($organisationId: UUID!, $id: UUID!) {
+	cancelDeployment(input: {organisation_id:$organisationId,id:$id})
+}
+`
+
+func CancelDeployment(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	organisationId uuid.UUID,
+	id uuid.UUID,
+) (*CancelDeploymentResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "CancelDeployment",
+		Query:  CancelDeployment_Operation,
+		Variables: &__CancelDeploymentInput{
+			OrganisationId: organisationId,
+			Id:             id,
+		},
+	}
+	var err_ error
+
+	var data_ CancelDeploymentResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
 // The query or mutation executed by CreateApiKey.
 const CreateApiKey_Operation = `
 mutation CreateApiKey ($aggregateId: UUID!, $organisationId: UUID!) {
@@ -1621,6 +2149,8 @@ query GetConfig ($organisationId: UUID!, $code: String!) {
 				state
 				code
 				name
+				regions
+				sort_order
 				created_at
 				updated_at
 			}
@@ -1643,9 +2173,18 @@ query GetConfig ($organisationId: UUID!, $code: String!) {
 				state
 				code
 				name
+				regions
+				sort_order
 				created_at
 				updated_at
 			}
+			stack {
+				name
+				outputs {
+					output_key
+					output_value
+				}
+			}
 			created_at
 			updated_at
 		}
@@ -1752,9 +2291,18 @@ query GetDeployment ($organisationId: UUID!, $aggregateId: UUID!) {
 			state
 			code
 			name
+			regions
+			sort_order
 			created_at
 			updated_at
 		}
+		stack {
+			name
+			outputs {
+				output_key
+				output_value
+			}
+		}
 		created_at
 		updated_at
 	}
@@ -1804,9 +2352,27 @@ query GetDeploymentRevision ($organisationId: UUID!, $aggregateId: UUID!) {
 				state
 				code
 				name
+				regions
+				sort_order
 				created_at
 				updated_at
 			}
+			stack {
+				name
+				status
+				resources {
+					physical_resource_id
+					logical_resource_id
+					resource_type
+					resource_status
+					resource_status_reason
+					timestamp
+				}
+				outputs {
+					output_key
+					output_value
+				}
+			}
 			created_at
 			updated_at
 		}
@@ -1816,6 +2382,8 @@ query GetDeploymentRevision ($organisationId: UUID!, $aggregateId: UUID!) {
 			state
 			code
 			name
+			regions
+			sort_order
 			created_at
 			updated_at
 		}
@@ -1862,6 +2430,75 @@ func GetDeploymentRevision(
 	return &data_, err_
 }
 
+// The query or mutation executed by GetDeployments.
+const GetDeployments_Operation = `
+query GetDeployments ($organisationId: UUID!, $configId: UUID!, $page: Int!, $pageSize: Int!) {
+	deployments(input: {organisation_id:$organisationId,config_id:$configId,page:$page,page_size:$pageSize}) {
+		versions {
+			version_number
+			state
+			created_at
+			updated_at
+			items {
+				deployment_id
+				version_number
+				deployment_revision_id
+				config_revision_id
+				environment {
+					id
+					code
+					name
+				}
+				exists
+				active
+				previous
+				state
+				updated_at
+			}
+		}
+		environments {
+			id
+			code
+			name
+			created_at
+			updated_at
+		}
+	}
+}
+`
+
+func GetDeployments(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	organisationId uuid.UUID,
+	configId uuid.UUID,
+	page int,
+	pageSize int,
+) (*GetDeploymentsResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetDeployments",
+		Query:  GetDeployments_Operation,
+		Variables: &__GetDeploymentsInput{
+			OrganisationId: organisationId,
+			ConfigId:       configId,
+			Page:           page,
+			PageSize:       pageSize,
+		},
+	}
+	var err_ error
+
+	var data_ GetDeploymentsResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
 // The query or mutation executed by GetEnvironments.
 const GetEnvironments_Operation = `
 query GetEnvironments ($organisationId: UUID!, $codes: [String!], $states: [StackState!], $page: Int, $pageSize: Int) {
@@ -1872,6 +2509,8 @@ query GetEnvironments ($organisationId: UUID!, $codes: [String!], $states: [Stac
 			state
 			code
 			name
+			regions
+			sort_order
 			created_at
 			updated_at
 		}
@@ -1966,6 +2605,100 @@ func GetMemberOrganisations(
 	return &data_, err_
 }
 
+// The query or mutation executed by GetMetrics.
+const GetMetrics_Operation = `
+query GetMetrics ($input: MetricsInput!) {
+	metrics(input: $input) {
+		metric_data_results {
+			id
+			label
+			status_code
+			timestamps
+			values
+		}
+		messages {
+			code
+			value
+		}
+	}
+}
+`
+
+func GetMetrics(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	input *MetricsInput,
+) (*GetMetricsResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetMetrics",
+		Query:  GetMetrics_Operation,
+		Variables: &__GetMetricsInput{
+			Input: input,
+		},
+	}
+	var err_ error
+
+	var data_ GetMetricsResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
+// The query or mutation executed by GetStackInfo.
+const GetStackInfo_Operation = `
+query GetStackInfo ($input: StackSetInstanceInput!) {
+	stackInfo(input: $input) {
+		status
+		status_reason
+		resources {
+			physical_resource_id
+			logical_resource_id
+			resource_type
+			resource_status
+			resource_status_reason
+			timestamp
+		}
+		outputs {
+			description
+			output_key
+			output_value
+		}
+	}
+}
+`
+
+func GetStackInfo(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	input *StackSetInstanceInput,
+) (*GetStackInfoResponse, error) {
+	req_ := &graphql.Request{
+		OpName: "GetStackInfo",
+		Query:  GetStackInfo_Operation,
+		Variables: &__GetStackInfoInput{
+			Input: input,
+		},
+	}
+	var err_ error
+
+	var data_ GetStackInfoResponse
+	resp_ := &graphql.Response{Data: &data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return &data_, err_
+}
+
 // The query or mutation executed by LoginContainerRepository.
 const LoginContainerRepository_Operation = `
 mutation LoginContainerRepository ($organisationId: UUID!) {
@@ -0,0 +1,110 @@
+package queries
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type logTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+// NewLogTransport logs every graphql request at debug level with its
+// operation name, variables, latency and response status. Secret values in
+// the variables are masked like in a recording.
+func NewLogTransport(next http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if logger == nil {
+		return next
+	}
+	return &logTransport{next: next, logger: logger}
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.logger.Enabled(ctx, slog.LevelDebug) {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded := newRecordedRequest(req, body)
+	t.logger.DebugContext(ctx, "graphql request",
+		"operation", recorded.OperationName,
+		"url", recorded.URL,
+		"variables", compactJSON(recorded.Variables),
+	)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+	if err != nil {
+		t.logger.DebugContext(ctx, "graphql request failed", "operation", recorded.OperationName, "latency", latency, "error", err)
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	t.logger.DebugContext(ctx, "graphql response",
+		"operation", recorded.OperationName,
+		"status", resp.StatusCode,
+		"latency", latency,
+		"bytes", len(respBody),
+		"errors", graphqlErrors(respBody),
+	)
+	return resp, nil
+}
+
+// graphqlErrors returns the messages of the errors in the response.
+func graphqlErrors(body []byte) []string {
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+
+	out := []string{}
+	for _, e := range resp.Errors {
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+func operationName(body []byte) string {
+	var req graphqlRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.OperationName
+}
+
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
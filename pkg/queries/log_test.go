@@ -0,0 +1,53 @@
+package queries
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/google/uuid"
+)
+
+func Test_LogTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors":[{"message":"secret exists"}]}`))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name     string
+		level    slog.Level
+		contains []string
+	}{
+		{name: "debug", level: slog.LevelDebug, contains: []string{"operation=CreateSecret", "DB_PASSWORD", `\"value\":\"REDACTED\"`, "status=200", "latency=", "secret exists"}},
+		{name: "info", level: slog.LevelInfo},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: c.level}))
+
+			client := graphql.NewClient(srv.URL, &http.Client{Transport: NewLogTransport(nil, logger)})
+			CreateSecret(context.Background(), client, uuid.New(), uuid.New(), uuid.New(), uuid.New(), "DB_PASSWORD", "hunter2")
+
+			if strings.Contains(out.String(), "hunter2") {
+				t.Fatalf("expected the secret to be masked:\n%s", out)
+			}
+			if len(c.contains) == 0 && out.Len() > 0 {
+				t.Fatalf("expected nothing to be logged, got:\n%s", out)
+			}
+			for _, s := range c.contains {
+				if !strings.Contains(out.String(), s) {
+					t.Fatalf("expected %s in:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	DefaultPageSize    = 50
+	DefaultConcurrency = 4
+
+	// errStop is returned by a callback to stop iterating without an error.
+	errStop = errors.New("stop iteration")
+)
+
+// PageOptions controls how a paged query is followed to the end.
+type PageOptions struct {
+	// PageSize is the number of items requested in each page.
+	PageSize int
+	// Limit stops iterating once this many items have been visited, 0 visits
+	// every item.
+	Limit int
+	// Concurrency is the number of pages requested at once after the first
+	// page, 0 or 1 requests the pages one at a time.
+	Concurrency int
+}
+
+type pageResult[T any] struct {
+	items []T
+	err   error
+}
+
+// paginate follows a paged query calling fn for each item in order, the first
+// page is requested on its own to find the total number of pages.
+func paginate[T any](ctx context.Context, opts PageOptions, fetch func(ctx context.Context, page int, pageSize int) ([]T, int, error), fn func(T) error) error {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	visited := 0
+	visit := func(items []T) error {
+		for _, item := range items {
+			if opts.Limit > 0 && visited >= opts.Limit {
+				return errStop
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+			visited++
+		}
+		if opts.Limit > 0 && visited >= opts.Limit {
+			return errStop
+		}
+		return nil
+	}
+
+	items, totalPages, err := fetch(ctx, 1, pageSize)
+	if err != nil {
+		return err
+	}
+	if err := visit(items); err != nil {
+		return ignoreStop(err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if opts.Limit > 0 {
+		needed := (opts.Limit + pageSize - 1) / pageSize
+		if needed < totalPages {
+			totalPages = needed
+		}
+	}
+
+	for first := 2; first <= totalPages; first += concurrency {
+		last := first + concurrency - 1
+		if last > totalPages {
+			last = totalPages
+		}
+
+		results := make([]pageResult[T], last-first+1)
+
+		var wg sync.WaitGroup
+		for page := first; page <= last; page++ {
+			wg.Add(1)
+			go func(page int) {
+				defer wg.Done()
+
+				items, _, err := fetch(ctx, page, pageSize)
+				results[page-first] = pageResult[T]{items: items, err: err}
+			}(page)
+		}
+		wg.Wait()
+
+		for _, result := range results {
+			if result.err != nil {
+				return result.err
+			}
+			if err := visit(result.items); err != nil {
+				return ignoreStop(err)
+			}
+			if len(result.items) == 0 {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func ignoreStop(err error) error {
+	if errors.Is(err, errStop) {
+		return nil
+	}
+	return err
+}
+
+func (q *queries) EachMemberOrganisation(ctx context.Context, opts PageOptions, fn func(*Organisation) error) error {
+	return paginate(ctx, opts, func(ctx context.Context, page int, pageSize int) ([]*Organisation, int, error) {
+		out, err := q.GetMemberOrganisations(ctx, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.Items, out.Total_pages, nil
+	}, fn)
+}
+
+func (q *queries) EachEnvironment(ctx context.Context, organisationId uuid.UUID, codes []string, states []StackState, opts PageOptions, fn func(*Environment) error) error {
+	return paginate(ctx, opts, func(ctx context.Context, page int, pageSize int) ([]*Environment, int, error) {
+		out, err := q.GetEnvironments(ctx, organisationId, codes, states, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.Items, out.Total_pages, nil
+	}, fn)
+}
+
+func (q *queries) EachConfig(ctx context.Context, organisationId uuid.UUID, classes []ConfigClass, opts PageOptions, fn func(*ConfigItem) error) error {
+	return paginate(ctx, opts, func(ctx context.Context, page int, pageSize int) ([]*ConfigItem, int, error) {
+		out, err := q.GetConfigs(ctx, organisationId, classes, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.Items, out.Total_pages, nil
+	}, fn)
+}
+
+func (q *queries) EachApiKey(ctx context.Context, organisationId uuid.UUID, opts PageOptions, fn func(*ApiKey) error) error {
+	return paginate(ctx, opts, func(ctx context.Context, page int, pageSize int) ([]*ApiKey, int, error) {
+		out, err := q.GetApiKeys(ctx, organisationId, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.Items, out.Total_pages, nil
+	}, fn)
+}
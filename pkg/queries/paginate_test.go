@@ -0,0 +1,80 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func Test_Paginate(t *testing.T) {
+	cases := []struct {
+		name        string
+		total       int
+		opts        PageOptions
+		expected    int
+		maxRequests int
+	}{
+		{name: "single page", total: 5, opts: PageOptions{PageSize: 10}, expected: 5, maxRequests: 1},
+		{name: "all pages", total: 25, opts: PageOptions{PageSize: 10}, expected: 25, maxRequests: 3},
+		{name: "concurrent", total: 95, opts: PageOptions{PageSize: 10, Concurrency: 4}, expected: 95, maxRequests: 10},
+		{name: "limit", total: 95, opts: PageOptions{PageSize: 10, Limit: 15, Concurrency: 4}, expected: 15, maxRequests: 2},
+		{name: "empty", total: 0, opts: PageOptions{PageSize: 10}, expected: 0, maxRequests: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests int
+			)
+			fetch := func(ctx context.Context, page int, pageSize int) ([]int, int, error) {
+				mu.Lock()
+				requests++
+				mu.Unlock()
+
+				items := []int{}
+				for n := (page - 1) * pageSize; n < page*pageSize && n < c.total; n++ {
+					items = append(items, n)
+				}
+				return items, (c.total + pageSize - 1) / pageSize, nil
+			}
+
+			out := []int{}
+			err := paginate(context.Background(), c.opts, fetch, func(n int) error {
+				out = append(out, n)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(out) != c.expected {
+				t.Fatalf("expected %d items, got %d", c.expected, len(out))
+			}
+			for n, item := range out {
+				if item != n {
+					t.Fatalf("expected items in order, got %v", out)
+				}
+			}
+			if requests > c.maxRequests {
+				t.Fatalf("expected at most %d requests, got %d", c.maxRequests, requests)
+			}
+		})
+	}
+}
+
+func Test_Paginate_Error(t *testing.T) {
+	failed := errors.New("failed")
+	fetch := func(ctx context.Context, page int, pageSize int) ([]int, int, error) {
+		if page == 3 {
+			return nil, 0, failed
+		}
+		return []int{page}, 5, nil
+	}
+
+	err := paginate(context.Background(), PageOptions{PageSize: 1, Concurrency: 2}, fetch, func(int) error { return nil })
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected %v, got %v", failed, err)
+	}
+}
@@ -3,7 +3,7 @@ package queries
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/Khan/genqlient/graphql"
@@ -13,10 +13,13 @@ import (
 
 type Queries interface {
 	GetMemberOrganisations(ctx context.Context, page int, pageSize int) (*GetMemberOrganisationsMemberOrganisationsPagedOrganisationsOutput, error)
+	EachMemberOrganisation(ctx context.Context, opts PageOptions, fn func(*Organisation) error) error
 	GetCurrentOrganisation(ctx context.Context) (*Organisation, error)
 	GetEnvironments(ctx context.Context, organisationId uuid.UUID, codes []string, states []StackState, page int, pageSize int) (*GetEnvironmentsEnvironmentsPagedEnvironmentsOutput, error)
+	EachEnvironment(ctx context.Context, organisationId uuid.UUID, codes []string, states []StackState, opts PageOptions, fn func(*Environment) error) error
 
 	GetConfigs(ctx context.Context, organisationId uuid.UUID, classes []ConfigClass, page int, pageSize int) (*GetConfigsConfigsPagedConfigsOutput, error)
+	EachConfig(ctx context.Context, organisationId uuid.UUID, classes []ConfigClass, opts PageOptions, fn func(*ConfigItem) error) error
 	GetConfig(ctx context.Context, organisationId uuid.UUID, code string) (*Config, error)
 	CreateConfig(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, name string, code string, class ConfigClass) (*uuid.UUID, error)
 	UpdateConfig(ctx context.Context, input *UpdateConfigInput) (*uuid.UUID, error)
@@ -31,44 +34,69 @@ type Queries interface {
 	DeleteSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error)
 
 	GetApiKeys(ctx context.Context, organisationId uuid.UUID, page int, pageSize int) (*GetApiKeysApiKeysPagedApiKeysOutput, error)
+	EachApiKey(ctx context.Context, organisationId uuid.UUID, opts PageOptions, fn func(*ApiKey) error) error
 	CreateApiKey(ctx context.Context, organisationId uuid.UUID) (*IdWithToken, error)
 	UpdateApiKey(ctx context.Context, id uuid.UUID) (*IdWithToken, error)
 	DeleteApiKey(ctx context.Context, id uuid.UUID) (*uuid.UUID, error)
 
 	NewDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID, environmentId uuid.UUID, configId uuid.UUID, configRevisionId uuid.UUID, revisionId uuid.UUID) (*uuid.UUID, error)
 	DeleteDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*uuid.UUID, error)
+	CancelDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*uuid.UUID, error)
 	GetDeploymentRevision(ctx context.Context, organisationId uuid.UUID, deploymentRevisionId uuid.UUID) (*DeploymentRevision, error)
 	GetDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*Deployment, error)
+	GetDeployments(ctx context.Context, organisationId uuid.UUID, configId uuid.UUID, page int, pageSize int) (*Deployments, error)
+
+	GetMetrics(ctx context.Context, input *MetricsInput) (*Metrics, error)
+	GetStackInfo(ctx context.Context, input *StackSetInstanceInput) (*StackDetails, error)
+
+	SubscribeNotifications(ctx context.Context, organisationId uuid.UUID, fn func(*Notification) error) error
+	SubscribeLogs(ctx context.Context, input *LogsSubscriptionInput, fn func(*LogsOutput) error) error
 }
 
 type queries struct {
 	organisationCode string
 	client           graphql.Client
+	// subscriptions is nil when they are disabled or replaying.
+	subscriptions *SubscriptionClient
 }
 
 func (q *queries) GetMemberOrganisations(ctx context.Context, page int, pageSize int) (*GetMemberOrganisationsMemberOrganisationsPagedOrganisationsOutput, error) {
 	resp, err := GetMemberOrganisations(ctx, q.client, page, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("GetMemberOrganisations unexpected response: %v", err)
+		return nil, newError("GetMemberOrganisations", err)
 	}
 	return resp.MemberOrganisations, nil
 }
 
 func (q *queries) GetCurrentOrganisation(ctx context.Context) (*Organisation, error) {
-	orgs, err := q.GetMemberOrganisations(ctx, 1, 999)
+	var (
+		first *Organisation
+		found *Organisation
+		count int
+	)
+	err := q.EachMemberOrganisation(ctx, PageOptions{PageSize: DefaultPageSize}, func(org *Organisation) error {
+		count++
+		if first == nil {
+			first = org
+		}
+		if len(q.organisationCode) > 0 && strings.EqualFold(org.Code, q.organisationCode) {
+			found = org
+			return errStop
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("GetCurrentOrganisation unexpected response: %v", err)
-
+		return nil, newError("GetCurrentOrganisation", err)
 	}
 
-	if len(q.organisationCode) == 0 && len(orgs.Items) == 1 {
-		return orgs.Items[0], nil
+	if found != nil {
+		return found, nil
 	}
-
-	for _, org := range orgs.Items {
-		if strings.EqualFold(org.Code, q.organisationCode) {
-			return org, nil
-		}
+	if len(q.organisationCode) > 0 {
+		return nil, notFound("GetCurrentOrganisation", "organisation %s not found", q.organisationCode)
+	}
+	if count == 1 {
+		return first, nil
 	}
 
 	return nil, config.ErrNoOrganisation
@@ -77,8 +105,7 @@ func (q *queries) GetCurrentOrganisation(ctx context.Context) (*Organisation, er
 func (q *queries) GetEnvironments(ctx context.Context, organisationId uuid.UUID, codes []string, states []StackState, page int, pageSize int) (*GetEnvironmentsEnvironmentsPagedEnvironmentsOutput, error) {
 	resp, err := GetEnvironments(ctx, q.client, organisationId, codes, states, page, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("GetEnvironments unexpected response: %v", err)
-
+		return nil, newError("GetEnvironments", err)
 	}
 	return resp.Environments, nil
 }
@@ -86,8 +113,7 @@ func (q *queries) GetEnvironments(ctx context.Context, organisationId uuid.UUID,
 func (q *queries) GetConfigs(ctx context.Context, organisationId uuid.UUID, classes []ConfigClass, page int, pageSize int) (*GetConfigsConfigsPagedConfigsOutput, error) {
 	resp, err := GetConfigs(ctx, q.client, organisationId, classes, page, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("GetConfigs unexpected response: %v", err)
-
+		return nil, newError("GetConfigs", err)
 	}
 	return resp.Configs, nil
 }
@@ -95,17 +121,18 @@ func (q *queries) GetConfigs(ctx context.Context, organisationId uuid.UUID, clas
 func (q *queries) GetConfig(ctx context.Context, organisationId uuid.UUID, code string) (*Config, error) {
 	resp, err := GetConfig(ctx, q.client, organisationId, code)
 	if err != nil {
-		return nil, fmt.Errorf("GetConfig unexpected response: %v", err)
-
+		return nil, newError("GetConfig", err)
+	}
+	if resp.Config == nil {
+		return nil, notFound("GetConfig", "config %s not found", code)
 	}
 	return resp.Config, nil
 }
 
 func (q *queries) CreateConfig(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, name string, code string, class ConfigClass) (*uuid.UUID, error) {
-	resp, err := CreateConfig(ctx, q.client, organisationId, id, code, class, name)
+	resp, err := CreateConfig(idempotent(ctx), q.client, organisationId, id, code, class, name)
 	if err != nil {
-		return nil, fmt.Errorf("CreateConfig unexpected response: %v", err)
-
+		return nil, newError("CreateConfig", err)
 	}
 	return &resp.CreateConfig, nil
 
@@ -114,17 +141,15 @@ func (q *queries) CreateConfig(ctx context.Context, organisationId uuid.UUID, id
 func (q *queries) UpdateConfig(ctx context.Context, input *UpdateConfigInput) (*uuid.UUID, error) {
 	resp, err := UpdateConfig(ctx, q.client, input)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateConfig unexpected response: %v", err)
-
+		return nil, newError("UpdateConfig", err)
 	}
 	return &resp.UpdateConfig, nil
 }
 
 func (q *queries) CreateContainerRepository(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, configId uuid.UUID, code string) (*uuid.UUID, error) {
-	resp, err := CreateContainerRepository(ctx, q.client, organisationId, id, configId, code)
+	resp, err := CreateContainerRepository(idempotent(ctx), q.client, organisationId, id, configId, code)
 	if err != nil {
-		return nil, fmt.Errorf("CreateContainerRepository unexpected response: %v", err)
-
+		return nil, newError("CreateContainerRepository", err)
 	}
 	return &resp.CreateContainerRepository, nil
 }
@@ -132,8 +157,7 @@ func (q *queries) CreateContainerRepository(ctx context.Context, organisationId
 func (q *queries) DeleteContainerRepository(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error) {
 	resp, err := DeleteContainerRepository(ctx, q.client, organisationId, id)
 	if err != nil {
-		return nil, fmt.Errorf("DeleteContainerRepository unexpected response: %v", err)
-
+		return nil, newError("DeleteContainerRepository", err)
 	}
 	return &resp.DeleteContainerRepository, nil
 }
@@ -141,17 +165,15 @@ func (q *queries) DeleteContainerRepository(ctx context.Context, organisationId
 func (q *queries) LoginContainerRepository(ctx context.Context, organisationId uuid.UUID) (*AuthContainerRepository, error) {
 	resp, err := LoginContainerRepository(ctx, q.client, organisationId)
 	if err != nil {
-		return nil, fmt.Errorf("LoginContainerRepository unexpected response: %v", err)
-
+		return nil, newError("LoginContainerRepository", err)
 	}
 	return resp.LoginContainerRepository, nil
 }
 
 func (q *queries) CreateSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, configId uuid.UUID, environmentId uuid.UUID, code string, value string) (*uuid.UUID, error) {
-	resp, err := CreateSecret(ctx, q.client, organisationId, id, configId, environmentId, code, value)
+	resp, err := CreateSecret(idempotent(ctx), q.client, organisationId, id, configId, environmentId, code, value)
 	if err != nil {
-		return nil, fmt.Errorf("CreateSecret unexpected response: %v", err)
-
+		return nil, newError("CreateSecret", err)
 	}
 	return &resp.CreateSecret, nil
 }
@@ -159,7 +181,7 @@ func (q *queries) CreateSecret(ctx context.Context, organisationId uuid.UUID, id
 func (q *queries) UpdateSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, configId uuid.UUID, environmentId uuid.UUID, code string, value string) (*uuid.UUID, error) {
 	resp, err := UpdateSecret(ctx, q.client, organisationId, id, configId, environmentId, code, value)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateSecret unexpected response: %v", err)
+		return nil, newError("UpdateSecret", err)
 	}
 	return &resp.UpdateSecret, nil
 }
@@ -167,8 +189,7 @@ func (q *queries) UpdateSecret(ctx context.Context, organisationId uuid.UUID, id
 func (q *queries) RestoreSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error) {
 	resp, err := RestoreSecret(ctx, q.client, organisationId, id)
 	if err != nil {
-		return nil, fmt.Errorf("RestoreSecret unexpected response: %v", err)
-
+		return nil, newError("RestoreSecret", err)
 	}
 	return &resp.RestoreSecret, nil
 }
@@ -176,8 +197,7 @@ func (q *queries) RestoreSecret(ctx context.Context, organisationId uuid.UUID, i
 func (q *queries) DeleteSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error) {
 	resp, err := DeleteSecret(ctx, q.client, organisationId, id)
 	if err != nil {
-		return nil, fmt.Errorf("DeleteSecret unexpected response: %v", err)
-
+		return nil, newError("DeleteSecret", err)
 	}
 	return &resp.DeleteSecret, nil
 }
@@ -185,18 +205,16 @@ func (q *queries) DeleteSecret(ctx context.Context, organisationId uuid.UUID, id
 func (q *queries) GetApiKeys(ctx context.Context, organisationId uuid.UUID, page int, pageSize int) (*GetApiKeysApiKeysPagedApiKeysOutput, error) {
 	resp, err := GetApiKeys(ctx, q.client, organisationId, page, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("GetApiKeys unexpected response: %v", err)
-
+		return nil, newError("GetApiKeys", err)
 	}
 	return resp.ApiKeys, nil
 }
 
 func (q *queries) CreateApiKey(ctx context.Context, organisationId uuid.UUID) (*IdWithToken, error) {
 	id := uuid.New()
-	resp, err := CreateApiKey(ctx, q.client, id, organisationId)
+	resp, err := CreateApiKey(idempotent(ctx), q.client, id, organisationId)
 	if err != nil {
-		return nil, fmt.Errorf("CreateApiKey unexpected response: %v", err)
-
+		return nil, newError("CreateApiKey", err)
 	}
 	return resp.CreateApiKey, nil
 }
@@ -204,8 +222,7 @@ func (q *queries) CreateApiKey(ctx context.Context, organisationId uuid.UUID) (*
 func (q *queries) UpdateApiKey(ctx context.Context, id uuid.UUID) (*IdWithToken, error) {
 	resp, err := UpdateApiKey(ctx, q.client, id)
 	if err != nil {
-		return nil, fmt.Errorf("UpdateApiKey unexpected response: %v", err)
-
+		return nil, newError("UpdateApiKey", err)
 	}
 	return resp.UpdateApiKey, nil
 }
@@ -213,17 +230,15 @@ func (q *queries) UpdateApiKey(ctx context.Context, id uuid.UUID) (*IdWithToken,
 func (q *queries) DeleteApiKey(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
 	resp, err := DeleteApiKey(ctx, q.client, id)
 	if err != nil {
-		return nil, fmt.Errorf("DeleteApiKey unexpected response: %v", err)
-
+		return nil, newError("DeleteApiKey", err)
 	}
 	return &resp.DeleteApiKey, nil
 }
 
 func (q *queries) NewDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID, environmentId uuid.UUID, configId uuid.UUID, configRevisionId uuid.UUID, revisionId uuid.UUID) (*uuid.UUID, error) {
-	resp, err := NewDeployment(ctx, q.client, organisationId, deploymentId, environmentId, configId, configRevisionId, revisionId)
+	resp, err := NewDeployment(idempotent(ctx), q.client, organisationId, deploymentId, environmentId, configId, configRevisionId, revisionId)
 	if err != nil {
-		return nil, fmt.Errorf("NewDeployment unexpected response: %v", err)
-
+		return nil, newError("NewDeployment", err)
 	}
 	return &resp.NewDeployment, nil
 }
@@ -231,17 +246,26 @@ func (q *queries) NewDeployment(ctx context.Context, organisationId uuid.UUID, d
 func (q *queries) DeleteDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*uuid.UUID, error) {
 	resp, err := DeleteDeployment(ctx, q.client, organisationId, deploymentId)
 	if err != nil {
-		return nil, fmt.Errorf("DeleteDeployment unexpected response: %v", err)
-
+		return nil, newError("DeleteDeployment", err)
 	}
 	return &resp.DeleteDeployment, nil
 }
 
+func (q *queries) CancelDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*uuid.UUID, error) {
+	resp, err := CancelDeployment(ctx, q.client, organisationId, deploymentId)
+	if err != nil {
+		return nil, newError("CancelDeployment", err)
+	}
+	return &resp.CancelDeployment, nil
+}
+
 func (q *queries) GetDeploymentRevision(ctx context.Context, organisationId uuid.UUID, deploymentRevisionId uuid.UUID) (*DeploymentRevision, error) {
 	resp, err := GetDeploymentRevision(ctx, q.client, organisationId, deploymentRevisionId)
 	if err != nil {
-		return nil, fmt.Errorf("GetDeploymentRevision unexpected response: %v", err)
-
+		return nil, newError("GetDeploymentRevision", err)
+	}
+	if resp.DeploymentRevision == nil {
+		return nil, notFound("GetDeploymentRevision", "deployment revision %s not found", deploymentRevisionId)
 	}
 	return resp.DeploymentRevision, nil
 }
@@ -249,13 +273,68 @@ func (q *queries) GetDeploymentRevision(ctx context.Context, organisationId uuid
 func (q *queries) GetDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*Deployment, error) {
 	resp, err := GetDeployment(ctx, q.client, organisationId, deploymentId)
 	if err != nil {
-		return nil, fmt.Errorf("GetDeployment unexpected response: %v", err)
-
+		return nil, newError("GetDeployment", err)
+	}
+	if resp.Deployment == nil {
+		return nil, notFound("GetDeployment", "deployment %s not found", deploymentId)
 	}
 	return resp.Deployment, nil
 }
 
+func (q *queries) GetDeployments(ctx context.Context, organisationId uuid.UUID, configId uuid.UUID, page int, pageSize int) (*Deployments, error) {
+	resp, err := GetDeployments(ctx, q.client, organisationId, configId, page, pageSize)
+	if err != nil {
+		return nil, newError("GetDeployments", err)
+	}
+	return resp.Deployments, nil
+}
+
+func (q *queries) GetMetrics(ctx context.Context, input *MetricsInput) (*Metrics, error) {
+	resp, err := GetMetrics(ctx, q.client, input)
+	if err != nil {
+		return nil, newError("GetMetrics", err)
+	}
+	return resp.Metrics, nil
+}
+
+func (q *queries) GetStackInfo(ctx context.Context, input *StackSetInstanceInput) (*StackDetails, error) {
+	resp, err := GetStackInfo(ctx, q.client, input)
+	if err != nil {
+		return nil, newError("GetStackInfo", err)
+	}
+	return resp.StackInfo, nil
+}
+
+// Factory creates the Queries for the organisation code, it replaces the api
+// client when set on the context, e.g. with an in-memory fake in tests.
+type Factory func(ctx context.Context, organisationCode string) (Queries, error)
+
+type factoryKey struct{}
+
+// WithFactory returns a context which makes New use the factory.
+func WithFactory(ctx context.Context, factory Factory) context.Context {
+	return context.WithValue(ctx, factoryKey{}, factory)
+}
+
 func New[C any, T any](ctx context.Context, cfg *config.NoOps[C, T]) (Queries, error) {
+	if factory, ok := ctx.Value(factoryKey{}).(Factory); ok {
+		return factory(ctx, cfg.GetOrganisationCode())
+	}
+
+	// a replay does not need to be logged in.
+	if len(cfg.Http.Replay) > 0 {
+		recording, err := ReadRecording(cfg.Http.Replay)
+		if err != nil {
+			return nil, err
+		}
+
+		httpClient := &http.Client{Transport: NewLogTransport(NewReplayTransport(recording), cfg.Logger)}
+		return &queries{
+			organisationCode: cfg.GetOrganisationCode(),
+			client:           graphql.NewClient(cfg.Api.GraphQL, &statusDoer{client: httpClient}),
+		}, nil
+	}
+
 	httpClient, err := cfg.NewHttpClient(ctx)
 	if err != nil {
 		return nil, err
@@ -263,9 +342,34 @@ func New[C any, T any](ctx context.Context, cfg *config.NoOps[C, T]) (Queries, e
 
 	organisationCode := cfg.GetOrganisationCode()
 
-	client := graphql.NewClient(cfg.Api.GraphQL, httpClient)
+	// each attempt is logged, the retries wrap them.
+	httpClient.Transport = NewLogTransport(httpClient.Transport, cfg.Logger)
+	httpClient.Transport = NewRetryTransport(httpClient.Transport, RetryOptions{
+		Retries: cfg.Api.Retries,
+		Wait:    cfg.Api.RetryWait,
+		MaxWait: cfg.Api.RetryMaxWait,
+		Logger:  cfg.Logger,
+	})
+	if len(cfg.Http.Record) > 0 {
+		httpClient.Transport, err = NewRecordTransport(httpClient.Transport, cfg.Http.Record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	baseClient, err := cfg.HttpClient()
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := newSubscriptionClient(cfg, baseClient)
+	if err != nil {
+		return nil, err
+	}
+
+	client := graphql.NewClient(cfg.Api.GraphQL, &statusDoer{client: httpClient})
 	return &queries{
 		organisationCode: organisationCode,
 		client:           client,
+		subscriptions:    subscriptions,
 	}, nil
 }
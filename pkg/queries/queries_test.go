@@ -0,0 +1,47 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/getnoops/ops/pkg/config"
+)
+
+func Test_GetCurrentOrganisation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"memberOrganisations":{"items":[{"code":"noops"}],"page":1,"page_size":50,"total_items":1,"total_pages":1}}}`))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name string
+		code string
+		err  error
+	}{
+		{name: "only organisation", code: ""},
+		{name: "by code", code: "NOOPS"},
+		{name: "not a member", code: "other", err: ErrNotFound},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			q := &queries{organisationCode: c.code, client: graphql.NewClient(server.URL, server.Client())}
+
+			org, err := q.GetCurrentOrganisation(context.Background())
+			if c.err != nil {
+				if !errors.Is(err, c.err) || errors.Is(err, config.ErrNoOrganisation) {
+					t.Fatalf("expected %v, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil || org.Code != "noops" {
+				t.Fatalf("expected noops, got %v %v", org, err)
+			}
+		})
+	}
+}
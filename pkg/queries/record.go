@@ -0,0 +1,345 @@
+package queries
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces tokens and secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+// redactedHeaders are never written to a recording.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// redactedKeys are the json keys whose values are redacted in request
+// variables and response data, e.g. api key tokens and secret values.
+var redactedKeys = map[string]bool{
+	"token":         true,
+	"password":      true,
+	"value":         true,
+	"secret_string": true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"client_secret": true,
+}
+
+// Recording is the file written by the record transport, each interaction is
+// a graphql request with the response it received.
+type Recording struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Header        map[string][]string `json:"header,omitempty"`
+	OperationName string              `json:"operationName,omitempty"`
+	Variables     json.RawMessage     `json:"variables,omitempty"`
+	Query         string              `json:"query,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int                 `json:"status"`
+	Header     map[string][]string `json:"header,omitempty"`
+	// Body is the json response, Text holds any other response.
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// ReadRecording reads a recording written by the record transport.
+func ReadRecording(file string) (*Recording, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Recording{}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return nil, fmt.Errorf("failed to read recording %s: %w", file, err)
+	}
+	return out, nil
+}
+
+// WriteFile writes the recording, replacing the file.
+func (r *Recording) WriteFile(file string) error {
+	raw, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(raw, '\n'), 0600)
+}
+
+type graphqlRequest struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+}
+
+func newRecordedRequest(req *http.Request, body []byte) *RecordedRequest {
+	out := &RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: redactHeader(req.Header),
+	}
+
+	var gql graphqlRequest
+	if err := json.Unmarshal(body, &gql); err == nil {
+		out.OperationName = gql.OperationName
+		out.Query = gql.Query
+		out.Variables = redactJSON(gql.Variables)
+	}
+	return out
+}
+
+// key is what a request is matched on when replaying, the host is left out
+// so a recording can be replayed against any api.
+func (r *RecordedRequest) key() string {
+	return r.OperationName + " " + compactJSON(r.Variables)
+}
+
+func redactHeader(header http.Header) map[string][]string {
+	out := map[string][]string{}
+	for key, values := range header {
+		out[key] = values
+	}
+	for _, key := range redactedHeaders {
+		if _, ok := out[key]; ok {
+			out[key] = []string{Redacted}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// redactJSON replaces the values of the redacted keys at any depth.
+func redactJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return raw
+	}
+
+	out, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if redactedKeys[strings.ToLower(key)] {
+				if _, ok := item.(string); ok {
+					v[key] = Redacted
+					continue
+				}
+			}
+			v[key] = redactValue(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+func compactJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "{}"
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+type recordTransport struct {
+	next http.RoundTripper
+	file string
+
+	mu        sync.Mutex
+	recording *Recording
+}
+
+// NewRecordTransport appends every request made through the transport to the
+// recording in the file along with its response, a new recording is started
+// when the file does not exist. Tokens and secrets are redacted.
+func NewRecordTransport(next http.RoundTripper, file string) (http.RoundTripper, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	recording, err := ReadRecording(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		recording = &Recording{}
+	} else if err != nil {
+		return nil, err
+	}
+	return &recordTransport{next: next, file: file, recording: recording}, nil
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     redactHeader(resp.Header),
+	}
+	if json.Valid(respBody) {
+		recorded.Body = redactJSON(respBody)
+	} else {
+		recorded.Text = string(respBody)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.recording.Interactions = append(t.recording.Interactions, &Interaction{
+		Request:  newRecordedRequest(req, body),
+		Response: recorded,
+	})
+	if err := t.recording.WriteFile(t.file); err != nil {
+		return nil, fmt.Errorf("failed to write recording %s: %w", t.file, err)
+	}
+	return resp, nil
+}
+
+type replayTransport struct {
+	mu     sync.Mutex
+	byKey  map[string][]*Interaction
+	byName map[string][]*Interaction
+	used   map[string]int
+}
+
+// NewReplayTransport serves the responses in the recording. Requests are
+// matched on the operation name and variables, falling back to the operation
+// name alone as mutations carry client generated ids. Repeated requests get
+// the recorded responses in order and then the last one again, e.g. when
+// polling.
+func NewReplayTransport(recording *Recording) http.RoundTripper {
+	t := &replayTransport{
+		byKey:  map[string][]*Interaction{},
+		byName: map[string][]*Interaction{},
+		used:   map[string]int{},
+	}
+	for _, interaction := range recording.Interactions {
+		key := interaction.Request.key()
+		t.byKey[key] = append(t.byKey[key], interaction)
+
+		name := interaction.Request.OperationName
+		t.byName[name] = append(t.byName[name], interaction)
+	}
+	return t
+}
+
+// next returns the next interaction for the key in the index.
+func (t *replayTransport) next(index map[string][]*Interaction, prefix string, key string) *Interaction {
+	interactions := index[key]
+	if len(interactions) == 0 {
+		return nil
+	}
+
+	i := t.used[prefix+key]
+	if i >= len(interactions) {
+		i = len(interactions) - 1
+	}
+	t.used[prefix+key] = i + 1
+	return interactions[i]
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recorded := newRecordedRequest(req, body)
+
+	t.mu.Lock()
+	interaction := t.next(t.byKey, "key:", recorded.key())
+	if interaction == nil {
+		interaction = t.next(t.byName, "name:", recorded.OperationName)
+	}
+	t.mu.Unlock()
+
+	if interaction == nil {
+		return nil, fmt.Errorf("no recorded response for %s", recorded.OperationName)
+	}
+
+	res := interaction.Response
+	respBody := []byte(res.Text)
+	if len(res.Body) > 0 {
+		respBody = res.Body
+	}
+
+	header := http.Header{}
+	for key, values := range res.Header {
+		header[key] = values
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,228 @@
+package queries_test
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/getnoops/ops/pkg/mockserver"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/google/uuid"
+)
+
+var update = flag.Bool("update", false, "record the golden files against the mock server")
+
+const goldenFile = "testdata/deploy.json"
+
+var (
+	configId             = uuid.MustParse("6f0e5d2c-7a53-4d8e-9b1f-1d3c2f6a9e01")
+	revisionId           = uuid.MustParse("6f0e5d2c-7a53-4d8e-9b1f-1d3c2f6a9e02")
+	secretId             = uuid.MustParse("6f0e5d2c-7a53-4d8e-9b1f-1d3c2f6a9e03")
+	deploymentId         = uuid.MustParse("6f0e5d2c-7a53-4d8e-9b1f-1d3c2f6a9e04")
+	deploymentRevisionId = uuid.MustParse("6f0e5d2c-7a53-4d8e-9b1f-1d3c2f6a9e05")
+)
+
+// deployScenario creates a config with a secret and deploys it to dev.
+func deployScenario(t *testing.T, client graphql.Client) *queries.Config {
+	t.Helper()
+	ctx := context.Background()
+
+	orgs, err := queries.GetMemberOrganisations(ctx, client, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	org := orgs.MemberOrganisations.Items[0]
+
+	envs, err := queries.GetEnvironments(ctx, client, org.Id, []string{"dev"}, []queries.StackState{queries.StackStateCreated}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := envs.Environments.Items[0]
+
+	if _, err := queries.CreateConfig(ctx, client, org.Id, configId, "api", queries.ConfigClassCompute, "Api"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.UpdateConfig(ctx, client, &queries.UpdateConfigInput{
+		Organisation_id: org.Id,
+		Aggregate_id:    configId,
+		Name:            "Api",
+		Resources: []*queries.ResourceInput{{
+			Code: "web",
+			Type: queries.ResourceTypeContainer,
+			Data: map[string]interface{}{"image": "nginx"},
+		}},
+		Version_number: "0.0.1",
+		Revision_id:    revisionId,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.CreateSecret(ctx, client, org.Id, secretId, configId, env.Id, "DB_PASSWORD", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.NewDeployment(ctx, client, org.Id, deploymentId, env.Id, configId, revisionId, deploymentRevisionId); err != nil {
+		t.Fatal(err)
+	}
+
+	// the recording has every poll, the replay ends on the same state.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		revision, err := queries.GetDeploymentRevision(ctx, client, org.Id, deploymentRevisionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revision.DeploymentRevision.State == queries.StackStateCreated {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected deployment created, got %s", revision.DeploymentRevision.State)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	config, err := queries.GetConfig(ctx, client, org.Id, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Config
+}
+
+func Test_Replay_Golden(t *testing.T) {
+	if *update {
+		server, err := mockserver.New(mockserver.Options{DeployDelay: 50 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer server.Close()
+
+		srv := httptest.NewServer(server.Handler())
+		defer srv.Close()
+
+		os.Remove(goldenFile)
+		recorder, err := queries.NewRecordTransport(nil, goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpClient := &http.Client{Transport: recorder}
+		deployScenario(t, graphql.NewClient(srv.URL+"/graphql", httpClient))
+	}
+
+	recording, err := queries.ReadRecording(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := &http.Client{Transport: queries.NewReplayTransport(recording)}
+	config := deployScenario(t, graphql.NewClient("http://replay.invalid/graphql", httpClient))
+
+	if config.Id != configId || config.Version_number != "0.0.1" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	if len(config.Resources) != 1 || config.Resources[0].Data["image"] != "nginx" {
+		t.Fatalf("unexpected resources %v", config.Resources)
+	}
+	if len(config.Secrets) != 1 || config.Secrets[0].Environment.Code != "dev" {
+		t.Fatalf("unexpected secrets %v", config.Secrets)
+	}
+	if len(config.Deployments) != 1 || config.Deployments[0].Id != deploymentId {
+		t.Fatalf("unexpected deployments %v", config.Deployments)
+	}
+}
+
+func Test_Record_Redacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"createApiKey":{"id":"` + secretId.String() + `","token":"api-key-token"}}}`))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "trace.json")
+
+	ctx := context.Background()
+	recorder, err := queries.NewRecordTransport(nil, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: headerTransport{next: recorder, token: "Bearer access-token"}}
+	client := graphql.NewClient(srv.URL, httpClient)
+
+	if _, err := queries.CreateSecret(ctx, client, uuid.New(), secretId, configId, uuid.New(), "DB_PASSWORD", "hunter2"); err != nil {
+		// the response does not match the operation, only the recording matters.
+		t.Log(err)
+	}
+	recorded, err := queries.CreateApiKey(ctx, client, secretId, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded.CreateApiKey.Token != "api-key-token" {
+		t.Fatalf("expected the caller to get the token, got %s", recorded.CreateApiKey.Token)
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"access-token", "hunter2", "api-key-token"} {
+		if strings.Contains(string(raw), secret) {
+			t.Fatalf("expected %s to be redacted:\n%s", secret, raw)
+		}
+	}
+
+	recording, err := queries.ReadRecording(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Interactions) != 2 {
+		t.Fatalf("expected 2 interactions, got %d", len(recording.Interactions))
+	}
+
+	// new ids are matched on the operation name.
+	replay := graphql.NewClient("http://replay.invalid", &http.Client{Transport: queries.NewReplayTransport(recording)})
+	replayed, err := queries.CreateApiKey(ctx, replay, uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.CreateApiKey.Id != secretId || replayed.CreateApiKey.Token != queries.Redacted {
+		t.Fatalf("unexpected replay %+v", replayed.CreateApiKey)
+	}
+
+	if _, err := queries.DeleteApiKey(ctx, replay, uuid.New()); err == nil || !strings.Contains(err.Error(), "no recorded response for DeleteApiKey") {
+		t.Fatalf("expected no recorded response, got %v", err)
+	}
+}
+
+func Test_Record_InvalidRecording(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.json")
+	if err := os.WriteFile(file, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// an existing recording is never replaced by a new one.
+	if _, err := queries.NewRecordTransport(nil, file); err == nil {
+		t.Fatal("expected an error for an invalid recording")
+	}
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "not json" {
+		t.Fatalf("expected the recording to be kept, got %s", raw)
+	}
+}
+
+type headerTransport struct {
+	next  http.RoundTripper
+	token string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", t.token)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,179 @@
+package queries
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type idempotentKey struct{}
+
+// idempotent marks a mutation as safe to retry, it is only used for mutations
+// which carry a client generated aggregate id.
+func idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(ctx context.Context) bool {
+	ok, _ := ctx.Value(idempotentKey{}).(bool)
+	return ok
+}
+
+// RetryOptions configures the retry transport.
+type RetryOptions struct {
+	// Retries is the number of times a request is retried, 0 disables retries.
+	Retries int
+	// Wait is the backoff before the first retry, it doubles on each retry.
+	Wait time.Duration
+	// MaxWait caps the backoff between retries, including waits requested by
+	// Retry-After.
+	MaxWait time.Duration
+	// Logger logs each retry, nothing is logged when nil.
+	Logger *slog.Logger
+}
+
+type retryTransport struct {
+	next http.RoundTripper
+	opts RetryOptions
+}
+
+// NewRetryTransport wraps the transport with exponential backoff and jitter.
+// Queries and idempotent mutations are retried on connection errors and 5xx
+// responses, every request is retried on 429 honouring Retry-After.
+func NewRetryTransport(next http.RoundTripper, opts RetryOptions) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryTransport{next: next, opts: opts}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.Retries <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	retryable := !isMutation(body) || isIdempotent(req.Context())
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(req.Context())
+		attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+		attemptReq.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= t.opts.Retries {
+			return resp, err
+		}
+
+		wait, retry := t.backoff(resp, err, retryable, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if t.opts.Logger != nil {
+			t.opts.Logger.Info("retrying request", "operation", operationName(body), "attempt", attempt+1, "wait", wait, "status", statusOf(resp), "error", err)
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns how long to wait before the next attempt and whether the
+// request should be retried.
+func (t *retryTransport) backoff(resp *http.Response, err error, retryable bool, attempt int) (time.Duration, bool) {
+	if err != nil {
+		return t.jitter(attempt), retryable
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return t.clamp(wait), true
+		}
+		return t.jitter(attempt), true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !retryable {
+			return 0, false
+		}
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return t.clamp(wait), true
+		}
+		return t.jitter(attempt), true
+	}
+	return 0, false
+}
+
+// clamp caps a server requested wait at MaxWait so a large Retry-After cannot
+// stall the command.
+func (t *retryTransport) clamp(wait time.Duration) time.Duration {
+	if t.opts.MaxWait > 0 && wait > t.opts.MaxWait {
+		return t.opts.MaxWait
+	}
+	return wait
+}
+
+func (t *retryTransport) jitter(attempt int) time.Duration {
+	wait := t.opts.Wait << attempt
+	if wait <= 0 || (t.opts.MaxWait > 0 && wait > t.opts.MaxWait) {
+		wait = t.opts.MaxWait
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	// equal jitter, wait at least half of the backoff.
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func retryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func isMutation(body []byte) bool {
+	req := struct {
+		Query string `json:"query"`
+	}{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		// if we cannot tell, assume it is not safe to retry.
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(req.Query), "mutation")
+}
@@ -0,0 +1,127 @@
+package queries
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RetryTransport(t *testing.T) {
+	query := `{"query":"query GetConfigs { configs { id } }","operationName":"GetConfigs"}`
+	mutation := `{"query":"mutation CreateConfig { createConfig }","operationName":"CreateConfig"}`
+
+	cases := []struct {
+		name       string
+		body       string
+		idempotent bool
+		statuses   []int
+		expected   int
+		attempts   int32
+	}{
+		{name: "query retried on 502", body: query, statuses: []int{502, 503, 200}, expected: 200, attempts: 3},
+		{name: "query gives up", body: query, statuses: []int{502, 502, 502, 502, 502}, expected: 502, attempts: 4},
+		{name: "query not retried on 400", body: query, statuses: []int{400, 200}, expected: 400, attempts: 1},
+		{name: "mutation not retried on 502", body: mutation, statuses: []int{502, 200}, expected: 502, attempts: 1},
+		{name: "idempotent mutation retried on 502", body: mutation, idempotent: true, statuses: []int{502, 200}, expected: 200, attempts: 2},
+		{name: "mutation retried on 429", body: mutation, statuses: []int{429, 200}, expected: 200, attempts: 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				status := c.statuses[len(c.statuses)-1]
+				if int(n) <= len(c.statuses) {
+					status = c.statuses[n-1]
+				}
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: NewRetryTransport(nil, RetryOptions{
+				Retries: 3,
+				Wait:    time.Millisecond,
+				MaxWait: 5 * time.Millisecond,
+			})}
+
+			ctx := context.Background()
+			if c.idempotent {
+				ctx = idempotent(ctx)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, bytes.NewBufferString(c.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != c.expected {
+				t.Fatalf("expected status %d, got %d", c.expected, resp.StatusCode)
+			}
+			if attempts != c.attempts {
+				t.Fatalf("expected %d attempts, got %d", c.attempts, attempts)
+			}
+		})
+	}
+}
+
+func Test_RetryAfter(t *testing.T) {
+	if wait, ok := retryAfter("2"); !ok || wait != 2*time.Second {
+		t.Fatalf("expected 2s, got %v", wait)
+	}
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if wait, ok := retryAfter(at); !ok || wait <= 0 || wait > time.Minute {
+		t.Fatalf("expected wait up to 1m, got %v", wait)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Fatal("expected invalid Retry-After to be ignored")
+	}
+}
+
+func Test_RetryTransport_RetryAfterCapped(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryOptions{
+		Retries: 3,
+		Wait:    time.Millisecond,
+		MaxWait: 5 * time.Millisecond,
+	})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, bytes.NewBufferString(`{"query":"query GetConfigs { configs { id } }"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected Retry-After to be capped at MaxWait, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || attempts != 2 {
+		t.Fatalf("expected 200 after 2 attempts, got %d after %d", resp.StatusCode, attempts)
+	}
+}
@@ -0,0 +1,455 @@
+package queries
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/net/websocket"
+	"golang.org/x/oauth2"
+)
+
+// GraphQLWSProtocol is the graphql-ws websocket subprotocol.
+const GraphQLWSProtocol = "graphql-transport-ws"
+
+// ErrSubscriptionsUnavailable is returned when the websocket can not be
+// opened, e.g. when a proxy blocks it, callers fall back to polling.
+var ErrSubscriptionsUnavailable = errors.New("subscriptions unavailable")
+
+const notificationSubscription = `subscription Notification($organisationId: UUID!) {
+  notification(organisation_id: $organisationId) {
+    notification_id
+    aggregate_id
+    aggregate_type
+    message
+    timestamp
+    read
+    metadata
+  }
+}`
+
+const logsSubscription = `subscription Logs($input: LogsSubscriptionInput!) {
+  logs(input: $input) {
+    logs {
+      event_id
+      ingestion_time
+      log_stream_name
+      message
+      timestamp
+    }
+    next_token
+  }
+}`
+
+// LogsSubscriptionInput selects the log events streamed by SubscribeLogs,
+// times are unix milliseconds.
+type LogsSubscriptionInput struct {
+	Id              uuid.UUID `json:"id"`
+	Organisation_id uuid.UUID `json:"organisation_id"`
+	Deployment_id   uuid.UUID `json:"deployment_id"`
+	Region          string    `json:"region"`
+	Log_group       string    `json:"log_group"`
+	Start_time      *int64    `json:"start_time"`
+	End_time        *int64    `json:"end_time"`
+	Next_token      *string   `json:"next_token"`
+}
+
+// Log is a CloudWatch log event, times are unix milliseconds.
+type Log struct {
+	Event_id        string `json:"event_id"`
+	Ingestion_time  int64  `json:"ingestion_time"`
+	Log_stream_name string `json:"log_stream_name"`
+	Message         string `json:"message"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// LogsOutput is a page of log events, Next_token is nil once the end time
+// is reached.
+type LogsOutput struct {
+	Logs       []*Log  `json:"logs"`
+	Next_token *string `json:"next_token"`
+}
+
+// Notification is sent when an aggregate in the organisation changes, e.g.
+// a deployment's state.
+type Notification struct {
+	Notification_id uuid.UUID              `json:"notification_id"`
+	Aggregate_id    uuid.UUID              `json:"aggregate_id"`
+	Aggregate_type  string                 `json:"aggregate_type"`
+	Message         string                 `json:"message"`
+	Timestamp       time.Time              `json:"timestamp"`
+	Read            bool                   `json:"read"`
+	Metadata        map[string]interface{} `json:"metadata"`
+}
+
+// SubscriptionOptions configures the websocket and how it reconnects.
+type SubscriptionOptions struct {
+	// Token is called on every connect so an expired token is refreshed.
+	Token func(ctx context.Context) (*oauth2.Token, error)
+	// TLSConfig is used for wss urls.
+	TLSConfig *tls.Config
+	// DialTimeout bounds connecting and the connection_ack.
+	DialTimeout time.Duration
+	// Retries is the number of reconnects in a row before giving up.
+	Retries int
+	// Wait is the backoff before the first reconnect, it doubles on each one.
+	Wait time.Duration
+	// MaxWait caps the backoff between reconnects.
+	MaxWait time.Duration
+	// Logger logs reconnects, nothing is logged when nil.
+	Logger *slog.Logger
+}
+
+// SubscriptionClient runs graphql subscriptions over a graphql-ws websocket.
+type SubscriptionClient struct {
+	url  string
+	opts SubscriptionOptions
+}
+
+// NewSubscriptionClient creates a client for the ws or wss url.
+func NewSubscriptionClient(url string, opts SubscriptionOptions) *SubscriptionClient {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 10 * time.Second
+	}
+	if opts.Wait <= 0 {
+		opts.Wait = 500 * time.Millisecond
+	}
+	if opts.MaxWait < opts.Wait {
+		opts.MaxWait = opts.Wait
+	}
+	return &SubscriptionClient{url: url, opts: opts}
+}
+
+type wsMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors gqlerror.List   `json:"errors"`
+}
+
+// connError is a failure of the websocket rather than the subscription, the
+// subscription is resumed on a new connection.
+type connError struct {
+	err error
+	// acked is set when the connection was established before it failed.
+	acked bool
+}
+
+func (e *connError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connError) Unwrap() error {
+	return e.err
+}
+
+// Subscribe calls fn with the data of every result until the subscription
+// completes, fn or the api returns an error or the context is done. A lost
+// connection is reopened with backoff, ErrSubscriptionsUnavailable is
+// returned once the retries are used up.
+func (c *SubscriptionClient) Subscribe(ctx context.Context, req *graphql.Request, fn func(data json.RawMessage) error) error {
+	wait := c.opts.Wait
+	failures := 0
+	for {
+		err := c.run(ctx, req, fn)
+
+		var connErr *connError
+		if !errors.As(err, &connErr) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if connErr.acked {
+			failures = 0
+			wait = c.opts.Wait
+		}
+		failures++
+		if failures > c.opts.Retries {
+			return fmt.Errorf("%w: %v", ErrSubscriptionsUnavailable, connErr.err)
+		}
+
+		if c.opts.Logger != nil {
+			c.opts.Logger.InfoContext(ctx, "reconnecting subscription", "operation", req.OpName, "attempt", failures, "wait", wait, "error", connErr.err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		wait *= 2
+		if wait > c.opts.MaxWait {
+			wait = c.opts.MaxWait
+		}
+	}
+}
+
+func (c *SubscriptionClient) dial(ctx context.Context) (*websocket.Conn, error) {
+	wsConfig, err := websocket.NewConfig(c.url, originOf(c.url))
+	if err != nil {
+		return nil, err
+	}
+	wsConfig.Protocol = []string{GraphQLWSProtocol}
+	wsConfig.TlsConfig = c.opts.TLSConfig
+	wsConfig.Dialer = &net.Dialer{Timeout: c.opts.DialTimeout}
+	wsConfig.Header = http.Header{}
+
+	var token *oauth2.Token
+	if c.opts.Token != nil {
+		token, err = c.opts.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		wsConfig.Header.Set("Authorization", token.Type()+" "+token.AccessToken)
+	}
+
+	conn, err := wsConfig.DialContext(ctx)
+	if err != nil {
+		return nil, &connError{err: err}
+	}
+
+	// the token is sent again in the payload as browsers can not set headers.
+	payload := map[string]string{}
+	if token != nil {
+		payload["Authorization"] = token.Type() + " " + token.AccessToken
+	}
+	raw, _ := json.Marshal(payload)
+
+	conn.SetDeadline(time.Now().Add(c.opts.DialTimeout))
+	if err := websocket.JSON.Send(conn, &wsMessage{Type: "connection_init", Payload: raw}); err != nil {
+		conn.Close()
+		return nil, &connError{err: err}
+	}
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			conn.Close()
+			return nil, &connError{err: fmt.Errorf("no connection_ack: %w", err)}
+		}
+		if msg.Type == "connection_ack" {
+			break
+		}
+		if msg.Type == "ping" {
+			websocket.JSON.Send(conn, &wsMessage{Type: "pong"})
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (c *SubscriptionClient) run(ctx context.Context, req *graphql.Request, fn func(data json.RawMessage) error) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	// reads block, closing the connection ends them when the context is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	id := uuid.NewString()
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if err := websocket.JSON.Send(conn, &wsMessage{Id: id, Type: "subscribe", Payload: payload}); err != nil {
+		return &connError{err: err, acked: true}
+	}
+
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &connError{err: err, acked: true}
+		}
+
+		switch msg.Type {
+		case "ping":
+			websocket.JSON.Send(conn, &wsMessage{Type: "pong"})
+		case "next":
+			if msg.Id != id {
+				continue
+			}
+			var result wsResult
+			if err := json.Unmarshal(msg.Payload, &result); err != nil {
+				return err
+			}
+			if len(result.Errors) > 0 {
+				return result.Errors
+			}
+			if err := fn(result.Data); err != nil {
+				websocket.JSON.Send(conn, &wsMessage{Id: id, Type: "complete"})
+				return err
+			}
+		case "error":
+			var list gqlerror.List
+			if err := json.Unmarshal(msg.Payload, &list); err != nil {
+				return fmt.Errorf("subscription error: %s", msg.Payload)
+			}
+			return list
+		case "complete":
+			if msg.Id == id {
+				return nil
+			}
+		}
+	}
+}
+
+// originOf is the http url for the websocket url, it is sent as the Origin.
+func originOf(wsUrl string) string {
+	u, err := url.Parse(wsUrl)
+	if err != nil {
+		return wsUrl
+	}
+	switch u.Scheme {
+	case "wss":
+		u.Scheme = "https"
+	case "ws":
+		u.Scheme = "http"
+	}
+	u.Path = ""
+	u.RawQuery = ""
+	return u.String()
+}
+
+// SubscriptionsURL is the websocket url for the GraphQL url.
+func SubscriptionsURL(graphqlUrl string) (string, error) {
+	u, err := url.Parse(graphqlUrl)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("invalid graphql url %s", graphqlUrl)
+	}
+	return u.String(), nil
+}
+
+// newSubscriptionClient returns nil when subscriptions are disabled or a
+// proxy is in the way, the websocket does not go through proxies.
+func newSubscriptionClient[C any, T any](cfg *config.NoOps[C, T], httpClient *http.Client) (*SubscriptionClient, error) {
+	if !cfg.Api.Subscriptions {
+		return nil, nil
+	}
+
+	wsUrl := cfg.Api.SubscriptionsURL
+	if len(wsUrl) == 0 {
+		var err error
+		wsUrl, err = SubscriptionsURL(cfg.Api.GraphQL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := SubscriptionOptions{
+		Token:   cfg.AccessToken,
+		Retries: cfg.Api.Retries,
+		Wait:    cfg.Api.RetryWait,
+		MaxWait: cfg.Api.RetryMaxWait,
+		Logger:  cfg.Logger,
+	}
+
+	if transport, ok := httpClient.Transport.(*http.Transport); ok {
+		opts.TLSConfig = transport.TLSClientConfig
+		if transport.Proxy != nil {
+			req, err := http.NewRequest(http.MethodGet, originOf(wsUrl), nil)
+			if err != nil {
+				return nil, err
+			}
+			if proxy, err := transport.Proxy(req); err != nil || proxy != nil {
+				cfg.Logger.Debug("subscriptions disabled as a proxy is set", "url", wsUrl)
+				return nil, nil
+			}
+		}
+	}
+	return NewSubscriptionClient(wsUrl, opts), nil
+}
+
+func (q *queries) SubscribeNotifications(ctx context.Context, organisationId uuid.UUID, fn func(*Notification) error) error {
+	if q.subscriptions == nil {
+		return newError("Notification", ErrSubscriptionsUnavailable)
+	}
+
+	req := &graphql.Request{
+		OpName:    "Notification",
+		Query:     notificationSubscription,
+		Variables: map[string]interface{}{"organisationId": organisationId},
+	}
+	err := q.subscriptions.Subscribe(ctx, req, func(data json.RawMessage) error {
+		resp := struct {
+			Notification *Notification `json:"notification"`
+		}{}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return err
+		}
+		if resp.Notification == nil {
+			return nil
+		}
+		return fn(resp.Notification)
+	})
+	return newError("Notification", err)
+}
+
+// SubscribeLogs streams pages of log events. The input's Next_token follows
+// the pages so a reconnect resumes after the last page.
+func (q *queries) SubscribeLogs(ctx context.Context, input *LogsSubscriptionInput, fn func(*LogsOutput) error) error {
+	if q.subscriptions == nil {
+		return newError("Logs", ErrSubscriptionsUnavailable)
+	}
+
+	req := &graphql.Request{
+		OpName:    "Logs",
+		Query:     logsSubscription,
+		Variables: map[string]interface{}{"input": input},
+	}
+	err := q.subscriptions.Subscribe(ctx, req, func(data json.RawMessage) error {
+		resp := struct {
+			Logs *LogsOutput `json:"logs"`
+		}{}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return err
+		}
+		if resp.Logs == nil {
+			return nil
+		}
+		if resp.Logs.Next_token != nil {
+			input.Next_token = resp.Logs.Next_token
+		}
+		return fn(resp.Logs)
+	})
+	return newError("Logs", err)
+}
@@ -0,0 +1,250 @@
+package queries
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+	"golang.org/x/oauth2"
+)
+
+// wsServer is a graphql-ws stand-in, each connection is acked and then
+// handled by handle with the subscribe message.
+func wsServer(t *testing.T, token string, handle func(conn *websocket.Conn, subscribe wsMessage)) (*httptest.Server, *int32) {
+	var connections int32
+	server := httptest.NewServer(websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				return fmt.Errorf("unauthenticated")
+			}
+			config.Protocol = []string{GraphQLWSProtocol}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			atomic.AddInt32(&connections, 1)
+
+			var init wsMessage
+			if err := websocket.JSON.Receive(conn, &init); err != nil || init.Type != "connection_init" {
+				t.Errorf("expected connection_init, got %+v %v", init, err)
+				return
+			}
+			payload := map[string]string{}
+			json.Unmarshal(init.Payload, &payload)
+			if payload["Authorization"] != "Bearer "+token {
+				t.Errorf("expected the token in the payload, got %v", payload)
+			}
+			websocket.JSON.Send(conn, &wsMessage{Type: "connection_ack"})
+
+			var subscribe wsMessage
+			if err := websocket.JSON.Receive(conn, &subscribe); err != nil || subscribe.Type != "subscribe" {
+				t.Errorf("expected subscribe, got %+v %v", subscribe, err)
+				return
+			}
+			handle(conn, subscribe)
+		},
+	})
+	return server, &connections
+}
+
+func sendNotification(conn *websocket.Conn, id string, aggregateId uuid.UUID) {
+	payload, _ := json.Marshal(map[string]any{
+		"data": map[string]any{
+			"notification": map[string]any{
+				"notification_id": uuid.New(),
+				"aggregate_id":    aggregateId,
+				"aggregate_type":  "Deployment",
+				"message":         "deployment created",
+				"timestamp":       time.Now(),
+			},
+		},
+	})
+	websocket.JSON.Send(conn, &wsMessage{Id: id, Type: "next", Payload: payload})
+}
+
+func subscriptionClient(server *httptest.Server, token string) *SubscriptionClient {
+	url, _ := SubscriptionsURL(server.URL)
+	return NewSubscriptionClient(url, SubscriptionOptions{
+		Token: func(ctx context.Context) (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: token, TokenType: "Bearer"}, nil
+		},
+		Retries:     2,
+		Wait:        time.Millisecond,
+		MaxWait:     5 * time.Millisecond,
+		DialTimeout: time.Second,
+	})
+}
+
+func Test_SubscribeNotifications(t *testing.T) {
+	aggregateIds := []uuid.UUID{uuid.New(), uuid.New()}
+
+	var subscribes int32
+	server, connections := wsServer(t, "token", func(conn *websocket.Conn, subscribe wsMessage) {
+		req := graphql.Request{}
+		json.Unmarshal(subscribe.Payload, &req)
+		if req.OpName != "Notification" || !strings.Contains(req.Query, "subscription Notification") {
+			t.Errorf("unexpected subscribe %s", subscribe.Payload)
+		}
+
+		websocket.JSON.Send(conn, &wsMessage{Type: "ping"})
+		var pong wsMessage
+		if err := websocket.JSON.Receive(conn, &pong); err != nil || pong.Type != "pong" {
+			t.Errorf("expected pong, got %+v %v", pong, err)
+		}
+
+		// the first connection drops after a notification, it is resumed on
+		// the second which completes.
+		n := atomic.AddInt32(&subscribes, 1)
+		sendNotification(conn, subscribe.Id, aggregateIds[n-1])
+		if n == 2 {
+			websocket.JSON.Send(conn, &wsMessage{Id: subscribe.Id, Type: "complete"})
+		}
+	})
+	defer server.Close()
+
+	q := &queries{subscriptions: subscriptionClient(server, "token")}
+
+	received := []uuid.UUID{}
+	err := q.SubscribeNotifications(context.Background(), uuid.New(), func(notification *Notification) error {
+		received = append(received, notification.Aggregate_id)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || received[0] != aggregateIds[0] || received[1] != aggregateIds[1] {
+		t.Fatalf("expected %v, got %v", aggregateIds, received)
+	}
+	if *connections != 2 {
+		t.Fatalf("expected 2 connections, got %d", *connections)
+	}
+}
+
+func Test_SubscribeLogs_Resume(t *testing.T) {
+	tokens := []string{}
+	server, _ := wsServer(t, "token", func(conn *websocket.Conn, subscribe wsMessage) {
+		req := struct {
+			Variables struct {
+				Input LogsSubscriptionInput `json:"input"`
+			} `json:"variables"`
+		}{}
+		json.Unmarshal(subscribe.Payload, &req)
+
+		// the first connection drops after a page, the second resumes from
+		// its token and sends the last page.
+		if req.Variables.Input.Next_token == nil {
+			tokens = append(tokens, "")
+			payload, _ := json.Marshal(map[string]any{"data": map[string]any{"logs": map[string]any{"logs": []any{map[string]any{"event_id": "1"}}, "next_token": "page-2"}}})
+			websocket.JSON.Send(conn, &wsMessage{Id: subscribe.Id, Type: "next", Payload: payload})
+			return
+		}
+		tokens = append(tokens, *req.Variables.Input.Next_token)
+		payload, _ := json.Marshal(map[string]any{"data": map[string]any{"logs": map[string]any{"logs": []any{map[string]any{"event_id": "2"}}}}})
+		websocket.JSON.Send(conn, &wsMessage{Id: subscribe.Id, Type: "next", Payload: payload})
+		websocket.JSON.Send(conn, &wsMessage{Id: subscribe.Id, Type: "complete"})
+	})
+	defer server.Close()
+
+	q := &queries{subscriptions: subscriptionClient(server, "token")}
+
+	received := []string{}
+	err := q.SubscribeLogs(context.Background(), &LogsSubscriptionInput{Log_group: "/noops/api/dev"}, func(page *LogsOutput) error {
+		for _, log := range page.Logs {
+			received = append(received, log.Event_id)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(received, ",") != "1,2" || strings.Join(tokens, ",") != ",page-2" {
+		t.Fatalf("expected events 1,2 resumed from page-2, got %v with tokens %q", received, tokens)
+	}
+}
+
+func Test_SubscribeNotifications_Errors(t *testing.T) {
+	server, _ := wsServer(t, "token", func(conn *websocket.Conn, subscribe wsMessage) {
+		payload, _ := json.Marshal([]map[string]any{{"message": "not allowed", "extensions": map[string]any{"code": "FORBIDDEN"}}})
+		websocket.JSON.Send(conn, &wsMessage{Id: subscribe.Id, Type: "error", Payload: payload})
+	})
+	defer server.Close()
+
+	q := &queries{subscriptions: subscriptionClient(server, "token")}
+	err := q.SubscribeNotifications(context.Background(), uuid.New(), func(notification *Notification) error { return nil })
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected %v, got %v", ErrForbidden, err)
+	}
+
+	// a rejected handshake is retried and then reported as unavailable.
+	q = &queries{subscriptions: subscriptionClient(server, "wrong")}
+	err = q.SubscribeNotifications(context.Background(), uuid.New(), func(notification *Notification) error { return nil })
+	if !errors.Is(err, ErrSubscriptionsUnavailable) {
+		t.Fatalf("expected %v, got %v", ErrSubscriptionsUnavailable, err)
+	}
+
+	// as is an endpoint blocking websockets.
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer blocked.Close()
+
+	q = &queries{subscriptions: subscriptionClient(blocked, "token")}
+	err = q.SubscribeNotifications(context.Background(), uuid.New(), func(notification *Notification) error { return nil })
+	if !errors.Is(err, ErrSubscriptionsUnavailable) {
+		t.Fatalf("expected %v, got %v", ErrSubscriptionsUnavailable, err)
+	}
+
+	// disabled subscriptions are unavailable too.
+	q = &queries{}
+	if err := q.SubscribeNotifications(context.Background(), uuid.New(), nil); !errors.Is(err, ErrSubscriptionsUnavailable) {
+		t.Fatalf("expected %v, got %v", ErrSubscriptionsUnavailable, err)
+	}
+}
+
+func Test_SubscribeNotifications_Cancel(t *testing.T) {
+	server, _ := wsServer(t, "token", func(conn *websocket.Conn, subscribe wsMessage) {
+		sendNotification(conn, subscribe.Id, uuid.New())
+
+		// waits for the client to close the connection.
+		var msg wsMessage
+		websocket.JSON.Receive(conn, &msg)
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := &queries{subscriptions: subscriptionClient(server, "token")}
+	err := q.SubscribeNotifications(ctx, uuid.New(), func(notification *Notification) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func Test_SubscriptionsURL(t *testing.T) {
+	cases := map[string]string{
+		"https://api.getnoops.com/graphql": "wss://api.getnoops.com/graphql",
+		"http://localhost:8080/graphql":    "ws://localhost:8080/graphql",
+	}
+	for in, expected := range cases {
+		out, err := SubscriptionsURL(in)
+		if err != nil || out != expected {
+			t.Fatalf("expected %s, got %s %v", expected, out, err)
+		}
+	}
+	if _, err := SubscriptionsURL("ftp://example.com"); err == nil {
+		t.Fatal("expected an error for ftp")
+	}
+}
@@ -0,0 +1,1350 @@
+package queriestest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/google/uuid"
+)
+
+// Fake is a stateful in-memory stand in for the NoOps api, it is used with
+// queries.WithFactory to test commands without a server.
+//
+// Objects returned by the fake are the ones it holds, changes made by the
+// caller are visible to later queries.
+type Fake struct {
+	// Errors are returned by the operation with the same name, e.g.
+	// "CreateSecret", before any state is changed.
+	Errors map[string]error
+	// DeploymentState is the state new deployment revisions are created in.
+	DeploymentState queries.StackState
+	// CancelState is the state a cancelled deployment and its revision are
+	// left in.
+	CancelState queries.StackState
+	// Registry is the registry returned for configs and container logins.
+	Registry *queries.AuthContainerRepository
+	// LogsPageSize is the number of log events in each page of SubscribeLogs.
+	LogsPageSize int
+	// MetricValues are returned for the metric name in order, a metric
+	// without values returns 0, 1, 2 and so on.
+	MetricValues map[string][]float64
+
+	mu            sync.Mutex
+	calls         map[string]int
+	organisations []*queries.Organisation
+	environments  map[uuid.UUID][]*queries.Environment
+	configs       map[uuid.UUID][]*queries.Config
+	apiKeys       map[uuid.UUID][]*queries.ApiKey
+	apiKeyTokens  map[uuid.UUID]string
+	secretValues  map[uuid.UUID]string
+	deployments   map[uuid.UUID]*queries.Deployment
+	revisions     map[uuid.UUID]*queries.DeploymentRevision
+	logs          map[uuid.UUID][]*logEvent
+	logInputs     []queries.LogsSubscriptionInput
+	metricInputs  []queries.MetricsInput
+	stacks        map[uuid.UUID]*queries.StackDetails
+	resources     map[uuid.UUID][]*queries.StackResource
+	history       map[uuid.UUID][]*deployed
+	stackInputs   []queries.StackSetInstanceInput
+}
+
+// stackResourceTypes are the CloudFormation types of the resources of a
+// config, buckets are left out.
+var stackResourceTypes = map[queries.ResourceType]string{
+	queries.ResourceTypeContainer:    "AWS::ECS::Service",
+	queries.ResourceTypeDatabase:     "AWS::RDS::DBInstance",
+	queries.ResourceTypeCluster:      "AWS::RDS::DBCluster",
+	queries.ResourceTypeQueue:        "AWS::SQS::Queue",
+	queries.ResourceTypeNotification: "AWS::SNS::Topic",
+}
+
+// stackResources returns a CloudFormation resource for each resource of the
+// config, the logical id is the code of the resource and the physical id
+// looks like the one AWS gives the type.
+func stackResources(config *queries.Config, environment *queries.Environment, now time.Time) []*queries.StackResource {
+	out := []*queries.StackResource{}
+	for _, resource := range config.Resources {
+		resourceType, ok := stackResourceTypes[resource.Type]
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("%s-%s-%s", config.Code, resource.Code, environment.Code)
+		physicalId := name
+		switch resource.Type {
+		case queries.ResourceTypeContainer:
+			physicalId = fmt.Sprintf("arn:aws:ecs:ap-southeast-2:000000000000:service/noops-%s/%s", environment.Code, name)
+		case queries.ResourceTypeQueue:
+			physicalId = fmt.Sprintf("https://sqs.ap-southeast-2.amazonaws.com/000000000000/%s", name)
+		case queries.ResourceTypeNotification:
+			physicalId = fmt.Sprintf("arn:aws:sns:ap-southeast-2:000000000000:%s", name)
+		}
+		out = append(out, &queries.StackResource{
+			Physical_resource_id: physicalId,
+			Logical_resource_id:  resource.Code,
+			Resource_type:        resourceType,
+			Resource_status:      "CREATE_COMPLETE",
+			Timestamp:            now,
+		})
+	}
+	return out
+}
+
+// deployed is a revision of a deployment and the config revision it
+// deployed.
+type deployed struct {
+	revisionId       uuid.UUID
+	configRevisionId uuid.UUID
+}
+
+type logEvent struct {
+	group string
+	log   *queries.Log
+}
+
+// New creates an empty fake.
+func New() *Fake {
+	return &Fake{
+		Errors:          map[string]error{},
+		DeploymentState: queries.StackStateCreated,
+		CancelState:     queries.StackStateFailed,
+		LogsPageSize:    2,
+		MetricValues:    map[string][]float64{},
+		Registry: &queries.AuthContainerRepository{
+			Username:     "AWS",
+			Password:     "password",
+			Registry_url: "registry.getnoops.test",
+		},
+		calls:        map[string]int{},
+		environments: map[uuid.UUID][]*queries.Environment{},
+		configs:      map[uuid.UUID][]*queries.Config{},
+		apiKeys:      map[uuid.UUID][]*queries.ApiKey{},
+		apiKeyTokens: map[uuid.UUID]string{},
+		secretValues: map[uuid.UUID]string{},
+		deployments:  map[uuid.UUID]*queries.Deployment{},
+		revisions:    map[uuid.UUID]*queries.DeploymentRevision{},
+		logs:         map[uuid.UUID][]*logEvent{},
+		stacks:       map[uuid.UUID]*queries.StackDetails{},
+		resources:    map[uuid.UUID][]*queries.StackResource{},
+		history:      map[uuid.UUID][]*deployed{},
+	}
+}
+
+// Factory returns a client of the fake for the organisation code, it can be
+// passed to queries.WithFactory.
+func (f *Fake) Factory(ctx context.Context, organisationCode string) (queries.Queries, error) {
+	return f.Client(organisationCode), nil
+}
+
+// Client returns a client of the fake which resolves the current organisation
+// from the code, like the api client does.
+func (f *Fake) Client(organisationCode string) queries.Queries {
+	return &client{Fake: f, organisationCode: organisationCode}
+}
+
+// Calls returns the number of times the operation was called.
+func (f *Fake) Calls(operation string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[operation]
+}
+
+// AddOrganisation adds an organisation the user is a member of.
+func (f *Fake) AddOrganisation(code string, name string) *queries.Organisation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	org := &queries.Organisation{
+		Id:         uuid.New(),
+		Code:       code,
+		Name:       name,
+		State:      queries.OrganisationStateCreated,
+		Created_at: now,
+		Updated_at: now,
+	}
+	f.organisations = append(f.organisations, org)
+	return org
+}
+
+// AddEnvironment adds a created static environment to the organisation, it
+// is sorted after the environments added before it.
+func (f *Fake) AddEnvironment(organisationId uuid.UUID, code string) *queries.Environment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	env := &queries.Environment{
+		Id:         uuid.New(),
+		Type:       queries.EnvironmentTypeStatic,
+		State:      queries.StackStateCreated,
+		Code:       code,
+		Name:       code,
+		Regions:    []string{"ap-southeast-2"},
+		Sort_order: len(f.environments[organisationId]),
+		Created_at: now,
+		Updated_at: now,
+	}
+	f.environments[organisationId] = append(f.environments[organisationId], env)
+	return env
+}
+
+// AddConfig adds a config without any revisions to the organisation.
+func (f *Fake) AddConfig(organisationId uuid.UUID, code string, class queries.ConfigClass) *queries.Config {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.addConfig(organisationId, uuid.New(), code, code, class)
+}
+
+// AddRevision adds a revision to the config as if it was updated.
+func (f *Fake) AddRevision(config *queries.Config, versionNumber string) *queries.RevisionItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.addRevision(config, uuid.New(), versionNumber)
+}
+
+// Config returns the config with the code, nil if it does not exist.
+func (f *Fake) Config(organisationId uuid.UUID, code string) *queries.Config {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.findConfig(organisationId, code)
+}
+
+// SecretValue returns the value of the secret.
+func (f *Fake) SecretValue(id uuid.UUID) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	value, ok := f.secretValues[id]
+	return value, ok
+}
+
+// ApiKeyToken returns the current token of the api key.
+func (f *Fake) ApiKeyToken(id uuid.UUID) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token, ok := f.apiKeyTokens[id]
+	return token, ok
+}
+
+// DeploymentRevision returns the deployment revision, nil if it does not exist.
+func (f *Fake) DeploymentRevision(id uuid.UUID) *queries.DeploymentRevision {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.revisions[id]
+}
+
+// AddLogs adds log events to the log group of the deployment, they are
+// streamed in the order added.
+func (f *Fake) AddLogs(deploymentId uuid.UUID, logGroup string, logs ...*queries.Log) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, log := range logs {
+		f.logs[deploymentId] = append(f.logs[deploymentId], &logEvent{group: logGroup, log: log})
+	}
+}
+
+// LogsInputs returns the input of every SubscribeLogs call.
+func (f *Fake) LogsInputs() []queries.LogsSubscriptionInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]queries.LogsSubscriptionInput{}, f.logInputs...)
+}
+
+// MetricsInputs returns the input of every GetMetrics call.
+func (f *Fake) MetricsInputs() []queries.MetricsInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]queries.MetricsInput{}, f.metricInputs...)
+}
+
+// SetStack sets the stack details returned by GetStackInfo for the
+// deployment.
+func (f *Fake) SetStack(deploymentId uuid.UUID, details *queries.StackDetails) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stacks[deploymentId] = details
+}
+
+// StackInfoInputs returns the input of every GetStackInfo call.
+func (f *Fake) StackInfoInputs() []queries.StackSetInstanceInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]queries.StackSetInstanceInput{}, f.stackInputs...)
+}
+
+// call counts the operation and returns the error configured for it.
+func (f *Fake) call(operation string) error {
+	f.calls[operation]++
+	return f.Errors[operation]
+}
+
+func (f *Fake) addConfig(organisationId uuid.UUID, id uuid.UUID, name string, code string, class queries.ConfigClass) *queries.Config {
+	now := time.Now()
+	config := &queries.Config{
+		Id:    id,
+		Code:  code,
+		Class: class,
+		Name:  name,
+		State: queries.ConfigStateRunning,
+		Registry: &queries.Registry{
+			Username:     f.Registry.Username,
+			Registry_url: f.Registry.Registry_url,
+		},
+		Created_at: now,
+		Updated_at: now,
+	}
+	f.configs[organisationId] = append(f.configs[organisationId], config)
+	return config
+}
+
+func (f *Fake) addRevision(config *queries.Config, id uuid.UUID, versionNumber string) *queries.RevisionItem {
+	now := time.Now()
+	revision := &queries.RevisionItem{
+		Id:             id,
+		Version_number: versionNumber,
+		State:          queries.ConfigStateRunning,
+		Created_at:     now,
+		Updated_at:     now,
+	}
+	config.Revisions = append(config.Revisions, revision)
+	config.Version_number = versionNumber
+	config.Updated_at = now
+	return revision
+}
+
+func (f *Fake) findOrganisation(id uuid.UUID) *queries.Organisation {
+	for _, org := range f.organisations {
+		if org.Id == id {
+			return org
+		}
+	}
+	return nil
+}
+
+func (f *Fake) findEnvironment(organisationId uuid.UUID, id uuid.UUID) *queries.Environment {
+	for _, env := range f.environments[organisationId] {
+		if env.Id == id {
+			return env
+		}
+	}
+	return nil
+}
+
+func (f *Fake) findConfig(organisationId uuid.UUID, code string) *queries.Config {
+	for _, config := range f.configs[organisationId] {
+		if config.Code == code {
+			return config
+		}
+	}
+	return nil
+}
+
+func (f *Fake) findConfigById(organisationId uuid.UUID, id uuid.UUID) *queries.Config {
+	for _, config := range f.configs[organisationId] {
+		if config.Id == id {
+			return config
+		}
+	}
+	return nil
+}
+
+func (f *Fake) findSecret(organisationId uuid.UUID, id uuid.UUID) *queries.SecretItem {
+	for _, config := range f.configs[organisationId] {
+		for _, secret := range config.Secrets {
+			if secret.Id == id {
+				return secret
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Fake) findApiKey(id uuid.UUID) *queries.ApiKey {
+	for _, keys := range f.apiKeys {
+		for _, key := range keys {
+			if key.Id == id {
+				return key
+			}
+		}
+	}
+	return nil
+}
+
+func notFound(op string, format string, args ...any) error {
+	return &queries.Error{Kind: queries.ErrNotFound, Operation: op, Message: fmt.Sprintf(format, args...)}
+}
+
+func conflict(op string, format string, args ...any) error {
+	return &queries.Error{Kind: queries.ErrConflict, Operation: op, Message: fmt.Sprintf(format, args...)}
+}
+
+// paged returns the items on the page along with the totals.
+func paged[T any](items []*T, page int, pageSize int) ([]*T, int, int) {
+	if pageSize <= 0 {
+		pageSize = queries.DefaultPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	total := len(items)
+	pages := (total + pageSize - 1) / pageSize
+
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	return items[start:end], total, pages
+}
+
+// each calls fn for the items up to the limit.
+func each[T any](items []*T, opts queries.PageOptions, fn func(*T) error) error {
+	for i, item := range items {
+		if opts.Limit > 0 && i >= opts.Limit {
+			return nil
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Fake) GetMemberOrganisations(ctx context.Context, page int, pageSize int) (*queries.GetMemberOrganisationsMemberOrganisationsPagedOrganisationsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetMemberOrganisations"); err != nil {
+		return nil, err
+	}
+
+	items, total, pages := paged(f.organisations, page, pageSize)
+	return &queries.GetMemberOrganisationsMemberOrganisationsPagedOrganisationsOutput{
+		Items:       items,
+		Page:        page,
+		Page_size:   pageSize,
+		Total_items: total,
+		Total_pages: pages,
+	}, nil
+}
+
+func (f *Fake) EachMemberOrganisation(ctx context.Context, opts queries.PageOptions, fn func(*queries.Organisation) error) error {
+	f.mu.Lock()
+	if err := f.call("GetMemberOrganisations"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	items := append([]*queries.Organisation{}, f.organisations...)
+	f.mu.Unlock()
+
+	return each(items, opts, fn)
+}
+
+func (f *Fake) filterEnvironments(organisationId uuid.UUID, codes []string, states []queries.StackState) []*queries.Environment {
+	out := []*queries.Environment{}
+	for _, env := range f.environments[organisationId] {
+		if len(codes) > 0 && !contains(codes, env.Code) {
+			continue
+		}
+		if len(states) > 0 && !contains(states, env.State) {
+			continue
+		}
+		out = append(out, env)
+	}
+	return out
+}
+
+func (f *Fake) GetEnvironments(ctx context.Context, organisationId uuid.UUID, codes []string, states []queries.StackState, page int, pageSize int) (*queries.GetEnvironmentsEnvironmentsPagedEnvironmentsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetEnvironments"); err != nil {
+		return nil, err
+	}
+
+	items, total, pages := paged(f.filterEnvironments(organisationId, codes, states), page, pageSize)
+	return &queries.GetEnvironmentsEnvironmentsPagedEnvironmentsOutput{
+		Items:       items,
+		Page:        page,
+		Page_size:   pageSize,
+		Total_items: total,
+		Total_pages: pages,
+	}, nil
+}
+
+func (f *Fake) EachEnvironment(ctx context.Context, organisationId uuid.UUID, codes []string, states []queries.StackState, opts queries.PageOptions, fn func(*queries.Environment) error) error {
+	f.mu.Lock()
+	if err := f.call("GetEnvironments"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	items := f.filterEnvironments(organisationId, codes, states)
+	f.mu.Unlock()
+
+	return each(items, opts, fn)
+}
+
+func (f *Fake) filterConfigs(organisationId uuid.UUID, classes []queries.ConfigClass) []*queries.ConfigItem {
+	out := []*queries.ConfigItem{}
+	for _, config := range f.configs[organisationId] {
+		if len(classes) > 0 && !contains(classes, config.Class) {
+			continue
+		}
+		out = append(out, &queries.ConfigItem{
+			Id:         config.Id,
+			Code:       config.Code,
+			Class:      config.Class,
+			Name:       config.Name,
+			State:      config.State,
+			Created_at: config.Created_at,
+			Updated_at: config.Updated_at,
+		})
+	}
+	return out
+}
+
+func (f *Fake) GetConfigs(ctx context.Context, organisationId uuid.UUID, classes []queries.ConfigClass, page int, pageSize int) (*queries.GetConfigsConfigsPagedConfigsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetConfigs"); err != nil {
+		return nil, err
+	}
+
+	items, total, pages := paged(f.filterConfigs(organisationId, classes), page, pageSize)
+	return &queries.GetConfigsConfigsPagedConfigsOutput{
+		Items:       items,
+		Page:        page,
+		Page_size:   pageSize,
+		Total_items: total,
+		Total_pages: pages,
+	}, nil
+}
+
+func (f *Fake) EachConfig(ctx context.Context, organisationId uuid.UUID, classes []queries.ConfigClass, opts queries.PageOptions, fn func(*queries.ConfigItem) error) error {
+	f.mu.Lock()
+	if err := f.call("GetConfigs"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	items := f.filterConfigs(organisationId, classes)
+	f.mu.Unlock()
+
+	return each(items, opts, fn)
+}
+
+func (f *Fake) GetConfig(ctx context.Context, organisationId uuid.UUID, code string) (*queries.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetConfig"); err != nil {
+		return nil, err
+	}
+
+	config := f.findConfig(organisationId, code)
+	if config == nil {
+		return nil, notFound("GetConfig", "config %s not found", code)
+	}
+	return config, nil
+}
+
+func (f *Fake) CreateConfig(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, name string, code string, class queries.ConfigClass) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("CreateConfig"); err != nil {
+		return nil, err
+	}
+
+	if f.findOrganisation(organisationId) == nil {
+		return nil, notFound("CreateConfig", "organisation %s not found", organisationId)
+	}
+	if existing := f.findConfigById(organisationId, id); existing != nil {
+		return &existing.Id, nil
+	}
+	if f.findConfig(organisationId, code) != nil {
+		return nil, conflict("CreateConfig", "config %s already exists", code)
+	}
+
+	config := f.addConfig(organisationId, id, name, code, class)
+	return &config.Id, nil
+}
+
+func (f *Fake) UpdateConfig(ctx context.Context, input *queries.UpdateConfigInput) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("UpdateConfig"); err != nil {
+		return nil, err
+	}
+
+	config := f.findConfigById(input.Organisation_id, input.Aggregate_id)
+	if config == nil {
+		return nil, notFound("UpdateConfig", "config %s not found", input.Aggregate_id)
+	}
+	for _, revision := range config.Revisions {
+		if revision.Id == input.Revision_id {
+			return &config.Id, nil
+		}
+	}
+
+	resources := []*queries.Resources{}
+	for _, resource := range input.Resources {
+		resources = append(resources, &queries.Resources{
+			Code: resource.Code,
+			Type: resource.Type,
+			Data: resource.Data,
+		})
+	}
+
+	config.Name = input.Name
+	config.Resources = resources
+	if input.Access != nil {
+		config.Access = &queries.Access{
+			Inbound:  input.Access.Inbound,
+			Outbound: input.Access.Outbound,
+		}
+	}
+	f.addRevision(config, input.Revision_id, input.Version_number)
+	return &config.Id, nil
+}
+
+func (f *Fake) CreateContainerRepository(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, configId uuid.UUID, code string) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("CreateContainerRepository"); err != nil {
+		return nil, err
+	}
+
+	config := f.findConfigById(organisationId, configId)
+	if config == nil {
+		return nil, notFound("CreateContainerRepository", "config %s not found", configId)
+	}
+	for _, repository := range config.ContainerRepositories {
+		if repository.Id == id {
+			return &repository.Id, nil
+		}
+		if repository.Code == code && repository.State != queries.StackStateDeleted {
+			return nil, conflict("CreateContainerRepository", "container repository %s already exists", code)
+		}
+	}
+
+	now := time.Now()
+	config.ContainerRepositories = append(config.ContainerRepositories, &queries.ContainerRepositoryItem{
+		Id:         id,
+		Code:       code,
+		Stack:      &queries.ContainerRepositoryItemStack{},
+		State:      queries.StackStateCreated,
+		Created_at: now,
+		Updated_at: now,
+	})
+	return &id, nil
+}
+
+func (f *Fake) DeleteContainerRepository(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("DeleteContainerRepository"); err != nil {
+		return nil, err
+	}
+
+	for _, config := range f.configs[organisationId] {
+		for _, repository := range config.ContainerRepositories {
+			if repository.Id == id {
+				repository.State = queries.StackStateDeleted
+				repository.Updated_at = time.Now()
+				return &id, nil
+			}
+		}
+	}
+	return nil, notFound("DeleteContainerRepository", "container repository %s not found", id)
+}
+
+func (f *Fake) LoginContainerRepository(ctx context.Context, organisationId uuid.UUID) (*queries.AuthContainerRepository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("LoginContainerRepository"); err != nil {
+		return nil, err
+	}
+
+	if f.findOrganisation(organisationId) == nil {
+		return nil, notFound("LoginContainerRepository", "organisation %s not found", organisationId)
+	}
+	auth := *f.Registry
+	return &auth, nil
+}
+
+func (f *Fake) CreateSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, configId uuid.UUID, environmentId uuid.UUID, code string, value string) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("CreateSecret"); err != nil {
+		return nil, err
+	}
+
+	config := f.findConfigById(organisationId, configId)
+	if config == nil {
+		return nil, notFound("CreateSecret", "config %s not found", configId)
+	}
+	environment := f.findEnvironment(organisationId, environmentId)
+	if environment == nil {
+		return nil, notFound("CreateSecret", "environment %s not found", environmentId)
+	}
+	for _, secret := range config.Secrets {
+		if secret.Id == id {
+			return &secret.Id, nil
+		}
+		if secret.Code == code && secret.Environment.Id == environmentId && secret.State != queries.StackStateDeleted {
+			return nil, conflict("CreateSecret", "secret %s already exists in %s", code, environment.Code)
+		}
+	}
+
+	now := time.Now()
+	config.Secrets = append(config.Secrets, &queries.SecretItem{
+		Id:          id,
+		Code:        code,
+		Environment: environment,
+		Stack:       &queries.SecretItemStack{},
+		State:       queries.StackStateCreated,
+		Created_at:  now,
+		Updated_at:  now,
+	})
+	f.secretValues[id] = value
+	return &id, nil
+}
+
+func (f *Fake) UpdateSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID, configId uuid.UUID, environmentId uuid.UUID, code string, value string) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("UpdateSecret"); err != nil {
+		return nil, err
+	}
+
+	secret := f.findSecret(organisationId, id)
+	if secret == nil {
+		return nil, notFound("UpdateSecret", "secret %s not found", id)
+	}
+
+	secret.State = queries.StackStateUpdated
+	secret.Updated_at = time.Now()
+	f.secretValues[id] = value
+	return &id, nil
+}
+
+func (f *Fake) RestoreSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error) {
+	return f.setSecretState("RestoreSecret", organisationId, id, queries.StackStateCreated)
+}
+
+func (f *Fake) DeleteSecret(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*uuid.UUID, error) {
+	return f.setSecretState("DeleteSecret", organisationId, id, queries.StackStateDeleted)
+}
+
+func (f *Fake) setSecretState(op string, organisationId uuid.UUID, id uuid.UUID, state queries.StackState) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call(op); err != nil {
+		return nil, err
+	}
+
+	secret := f.findSecret(organisationId, id)
+	if secret == nil {
+		return nil, notFound(op, "secret %s not found", id)
+	}
+
+	secret.State = state
+	secret.Updated_at = time.Now()
+	return &id, nil
+}
+
+func (f *Fake) GetApiKeys(ctx context.Context, organisationId uuid.UUID, page int, pageSize int) (*queries.GetApiKeysApiKeysPagedApiKeysOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetApiKeys"); err != nil {
+		return nil, err
+	}
+
+	items, total, pages := paged(f.apiKeys[organisationId], page, pageSize)
+	return &queries.GetApiKeysApiKeysPagedApiKeysOutput{
+		Items:       items,
+		Page:        page,
+		Page_size:   pageSize,
+		Total_items: total,
+		Total_pages: pages,
+	}, nil
+}
+
+func (f *Fake) EachApiKey(ctx context.Context, organisationId uuid.UUID, opts queries.PageOptions, fn func(*queries.ApiKey) error) error {
+	f.mu.Lock()
+	if err := f.call("GetApiKeys"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	items := append([]*queries.ApiKey{}, f.apiKeys[organisationId]...)
+	f.mu.Unlock()
+
+	return each(items, opts, fn)
+}
+
+func (f *Fake) CreateApiKey(ctx context.Context, organisationId uuid.UUID) (*queries.IdWithToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("CreateApiKey"); err != nil {
+		return nil, err
+	}
+
+	if f.findOrganisation(organisationId) == nil {
+		return nil, notFound("CreateApiKey", "organisation %s not found", organisationId)
+	}
+
+	now := time.Now()
+	key := &queries.ApiKey{
+		Id:              uuid.New(),
+		State:           queries.ApiKeyStateCreated,
+		Organisation_id: organisationId,
+		Created_at:      now,
+		Updated_at:      now,
+	}
+	f.apiKeys[organisationId] = append(f.apiKeys[organisationId], key)
+	f.apiKeyTokens[key.Id] = uuid.NewString()
+
+	return &queries.IdWithToken{Id: key.Id, Token: f.apiKeyTokens[key.Id]}, nil
+}
+
+func (f *Fake) UpdateApiKey(ctx context.Context, id uuid.UUID) (*queries.IdWithToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("UpdateApiKey"); err != nil {
+		return nil, err
+	}
+
+	key := f.findApiKey(id)
+	if key == nil || key.State == queries.ApiKeyStateDeleted {
+		return nil, notFound("UpdateApiKey", "api key %s not found", id)
+	}
+
+	key.Updated_at = time.Now()
+	f.apiKeyTokens[key.Id] = uuid.NewString()
+	return &queries.IdWithToken{Id: key.Id, Token: f.apiKeyTokens[key.Id]}, nil
+}
+
+func (f *Fake) DeleteApiKey(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("DeleteApiKey"); err != nil {
+		return nil, err
+	}
+
+	key := f.findApiKey(id)
+	if key == nil {
+		return nil, notFound("DeleteApiKey", "api key %s not found", id)
+	}
+
+	now := time.Now()
+	key.State = queries.ApiKeyStateDeleted
+	key.Updated_at = now
+	key.Deleted_at = now
+	delete(f.apiKeyTokens, id)
+	return &id, nil
+}
+
+func (f *Fake) NewDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID, environmentId uuid.UUID, configId uuid.UUID, configRevisionId uuid.UUID, revisionId uuid.UUID) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("NewDeployment"); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.revisions[revisionId]; ok {
+		return &deploymentId, nil
+	}
+
+	config := f.findConfigById(organisationId, configId)
+	if config == nil {
+		return nil, notFound("NewDeployment", "config %s not found", configId)
+	}
+	environment := f.findEnvironment(organisationId, environmentId)
+	if environment == nil {
+		return nil, notFound("NewDeployment", "environment %s not found", environmentId)
+	}
+	if !containsRevision(config.Revisions, configRevisionId) {
+		return nil, notFound("NewDeployment", "config revision %s not found", configRevisionId)
+	}
+
+	now := time.Now()
+	deployment, ok := f.deployments[deploymentId]
+	if !ok {
+		deployment = &queries.Deployment{
+			Id:          deploymentId,
+			Environment: environment,
+			Stack: &queries.DeploymentStack{
+				Name: fmt.Sprintf("noops-%s-%s", config.Code, environment.Code),
+				Outputs: []*queries.StackOutput{
+					{Output_key: "LogGroupName", Output_value: fmt.Sprintf("/noops/%s/%s", config.Code, environment.Code)},
+				},
+			},
+			Created_at: now,
+		}
+		f.deployments[deploymentId] = deployment
+		f.resources[deploymentId] = stackResources(config, environment, now)
+		config.Deployments = append(config.Deployments, deployment)
+	}
+	deployment.State = f.DeploymentState
+	deployment.Updated_at = now
+
+	f.history[deploymentId] = append(f.history[deploymentId], &deployed{revisionId: revisionId, configRevisionId: configRevisionId})
+	f.revisions[revisionId] = &queries.DeploymentRevision{
+		Id:          revisionId,
+		State:       f.DeploymentState,
+		Deployment:  &queries.RevisionDeployment{Id: deploymentId},
+		Environment: environment,
+		Config: &queries.ConfigItem{
+			Id:         config.Id,
+			Code:       config.Code,
+			Class:      config.Class,
+			Name:       config.Name,
+			State:      config.State,
+			Created_at: config.Created_at,
+			Updated_at: config.Updated_at,
+		},
+		Created_at: now,
+		Updated_at: now,
+	}
+	return &deploymentId, nil
+}
+
+func (f *Fake) DeleteDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("DeleteDeployment"); err != nil {
+		return nil, err
+	}
+
+	deployment, ok := f.deployments[deploymentId]
+	if !ok {
+		return nil, notFound("DeleteDeployment", "deployment %s not found", deploymentId)
+	}
+
+	deployment.State = queries.StackStateDeleted
+	deployment.Updated_at = time.Now()
+	return &deploymentId, nil
+}
+
+// CancelDeployment moves a deployment in progress and its revisions in
+// progress to CancelState.
+func (f *Fake) CancelDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("CancelDeployment"); err != nil {
+		return nil, err
+	}
+
+	deployment, ok := f.deployments[deploymentId]
+	if !ok {
+		return nil, notFound("CancelDeployment", "deployment %s not found", deploymentId)
+	}
+	if !inProgress(deployment.State) {
+		return nil, conflict("CancelDeployment", "deployment %s is %s", deploymentId, deployment.State)
+	}
+
+	now := time.Now()
+	deployment.State = f.CancelState
+	deployment.Updated_at = now
+	for _, revision := range f.revisions {
+		if revision.Deployment.Id == deploymentId && inProgress(revision.State) {
+			revision.State = f.CancelState
+			revision.Updated_at = now
+		}
+	}
+	return &deploymentId, nil
+}
+
+// inProgress is true for the states a deployment can be cancelled in.
+func inProgress(state queries.StackState) bool {
+	switch state {
+	case queries.StackStateNew, queries.StackStateCreating, queries.StackStateUpdating, queries.StackStateCancelling:
+		return true
+	}
+	return false
+}
+
+func (f *Fake) GetDeploymentRevision(ctx context.Context, organisationId uuid.UUID, deploymentRevisionId uuid.UUID) (*queries.DeploymentRevision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetDeploymentRevision"); err != nil {
+		return nil, err
+	}
+
+	revision, ok := f.revisions[deploymentRevisionId]
+	if !ok {
+		return nil, notFound("GetDeploymentRevision", "deployment revision %s not found", deploymentRevisionId)
+	}
+	return f.revision(revision), nil
+}
+
+// revision returns a copy of the revision with its deployment, whose stack
+// has the status and resources GetStackInfo returns for it.
+func (f *Fake) revision(revision *queries.DeploymentRevision) *queries.DeploymentRevision {
+	out := *revision
+	deployment, ok := f.deployments[revision.Deployment.Id]
+	if !ok {
+		return &out
+	}
+
+	out.Deployment = &queries.RevisionDeployment{
+		Id:          deployment.Id,
+		State:       deployment.State,
+		Environment: deployment.Environment,
+		Created_at:  deployment.Created_at,
+		Updated_at:  deployment.Updated_at,
+	}
+	if deployment.Stack != nil {
+		details := f.stackDetails(deployment)
+		out.Deployment.Stack = &queries.RevisionStack{
+			Name:      deployment.Stack.Name,
+			Status:    details.Status,
+			Resources: details.Resources,
+			Outputs:   deployment.Stack.Outputs,
+		}
+	}
+	return &out
+}
+
+// stackDetails returns the details set with SetStack for the deployment, or
+// its generated resources in the deployment's state.
+func (f *Fake) stackDetails(deployment *queries.Deployment) *queries.StackDetails {
+	if details, ok := f.stacks[deployment.Id]; ok {
+		return details
+	}
+	return &queries.StackDetails{
+		Status:    strings.ToUpper(string(deployment.State)),
+		Resources: append([]*queries.StackResource{}, f.resources[deployment.Id]...),
+		Outputs:   []*queries.StackDetailsOutput{},
+	}
+}
+
+func (f *Fake) GetDeployment(ctx context.Context, organisationId uuid.UUID, deploymentId uuid.UUID) (*queries.Deployment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetDeployment"); err != nil {
+		return nil, err
+	}
+
+	deployment, ok := f.deployments[deploymentId]
+	if !ok {
+		return nil, notFound("GetDeployment", "deployment %s not found", deploymentId)
+	}
+	return deployment, nil
+}
+
+// GetDeployments returns the config's versions newest first, each with an
+// item for every environment it was deployed to. The active version of an
+// environment is the last one deployed and the previous is the last one
+// before it which did not fail.
+func (f *Fake) GetDeployments(ctx context.Context, organisationId uuid.UUID, configId uuid.UUID, page int, pageSize int) (*queries.Deployments, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetDeployments"); err != nil {
+		return nil, err
+	}
+
+	config := f.findConfigById(organisationId, configId)
+	if config == nil {
+		return nil, notFound("GetDeployments", "config %s not found", configId)
+	}
+
+	out := &queries.Deployments{Versions: []*queries.DeploymentVersion{}, Environments: []*queries.DeploymentEnvironment{}}
+	active := map[uuid.UUID]uuid.UUID{}
+	previous := map[uuid.UUID]uuid.UUID{}
+	for _, deployment := range config.Deployments {
+		history := f.history[deployment.Id]
+		if len(history) == 0 {
+			continue
+		}
+		last := history[len(history)-1].configRevisionId
+		active[deployment.Id] = last
+		for i := len(history) - 2; i >= 0; i-- {
+			state := f.revisions[history[i].revisionId].State
+			if history[i].configRevisionId != last && state != queries.StackStateFailed {
+				previous[deployment.Id] = history[i].configRevisionId
+				break
+			}
+		}
+
+		environment := deployment.Environment
+		out.Environments = append(out.Environments, &queries.DeploymentEnvironment{
+			Id:         environment.Id,
+			Code:       environment.Code,
+			Name:       environment.Name,
+			Created_at: environment.Created_at,
+			Updated_at: environment.Updated_at,
+		})
+	}
+
+	for i := len(config.Revisions) - 1; i >= 0; i-- {
+		revision := config.Revisions[i]
+		version := &queries.DeploymentVersion{
+			Version_number: revision.Version_number,
+			State:          revision.State,
+			Created_at:     revision.Created_at,
+			Updated_at:     revision.Updated_at,
+			Items:          []*queries.DeploymentItem{},
+		}
+		for _, deployment := range config.Deployments {
+			// the item is the last time the version was deployed.
+			var latest *deployed
+			for _, entry := range f.history[deployment.Id] {
+				if entry.configRevisionId == revision.Id {
+					latest = entry
+				}
+			}
+			if latest == nil {
+				continue
+			}
+			deploymentRevision := f.revisions[latest.revisionId]
+			version.Items = append(version.Items, &queries.DeploymentItem{
+				Deployment_id:          deployment.Id,
+				Version_number:         revision.Version_number,
+				Deployment_revision_id: latest.revisionId,
+				Config_revision_id:     revision.Id,
+				Environment: &queries.DeploymentItemEnvironment{
+					Id:   deployment.Environment.Id,
+					Code: deployment.Environment.Code,
+					Name: deployment.Environment.Name,
+				},
+				Exists:     true,
+				Active:     active[deployment.Id] == revision.Id,
+				Previous:   previous[deployment.Id] == revision.Id,
+				State:      deploymentRevision.State,
+				Updated_at: deploymentRevision.Updated_at,
+			})
+		}
+		out.Versions = append(out.Versions, version)
+	}
+
+	if pageSize > 0 {
+		start := (page - 1) * pageSize
+		if start < 0 {
+			start = 0
+		}
+		if start > len(out.Versions) {
+			start = len(out.Versions)
+		}
+		end := start + pageSize
+		if end > len(out.Versions) {
+			end = len(out.Versions)
+		}
+		out.Versions = out.Versions[start:end]
+	}
+	return out, nil
+}
+
+// GetMetrics returns a point every period from the start to the end time for
+// each metric query.
+func (f *Fake) GetMetrics(ctx context.Context, input *queries.MetricsInput) (*queries.Metrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetMetrics"); err != nil {
+		return nil, err
+	}
+	if f.findOrganisation(input.Organisation_id) == nil {
+		return nil, notFound("GetMetrics", "organisation %s not found", input.Organisation_id)
+	}
+	if _, ok := f.deployments[input.Deployment_id]; !ok {
+		return nil, notFound("GetMetrics", "deployment %s not found", input.Deployment_id)
+	}
+	f.metricInputs = append(f.metricInputs, *input)
+
+	out := &queries.Metrics{Metric_data_results: []*queries.MetricDataResult{}, Messages: []*queries.MessageData{}}
+	for _, query := range input.Metric_queries {
+		result := &queries.MetricDataResult{
+			Id:          query.Id,
+			Label:       query.Label,
+			Status_code: queries.MetricStatusCodeComplete,
+			Timestamps:  []time.Time{},
+			Values:      []float64{},
+		}
+		values := f.MetricValues[query.Metric_name]
+		for i, timestamp := 0, input.Start_time; timestamp < input.End_time && query.Period > 0 && i < 1440; i, timestamp = i+1, timestamp+query.Period {
+			value := float64(i)
+			if len(values) > 0 {
+				value = values[i%len(values)]
+			}
+			result.Timestamps = append(result.Timestamps, time.Unix(int64(timestamp), 0).UTC())
+			result.Values = append(result.Values, value)
+		}
+		out.Metric_data_results = append(out.Metric_data_results, result)
+	}
+	return out, nil
+}
+
+// GetStackInfo returns the details set with SetStack for the deployment with
+// the stack name, or its generated resources in the deployment's state.
+func (f *Fake) GetStackInfo(ctx context.Context, input *queries.StackSetInstanceInput) (*queries.StackDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("GetStackInfo"); err != nil {
+		return nil, err
+	}
+	if f.findOrganisation(input.Organisation_id) == nil {
+		return nil, notFound("GetStackInfo", "organisation %s not found", input.Organisation_id)
+	}
+	f.stackInputs = append(f.stackInputs, *input)
+
+	for _, deployment := range f.deployments {
+		if deployment.Environment.Id != input.Environment_id || deployment.Stack == nil || deployment.Stack.Name != input.Stack_set_name {
+			continue
+		}
+		return f.stackDetails(deployment), nil
+	}
+	return nil, notFound("GetStackInfo", "stack %s not found", input.Stack_set_name)
+}
+
+// SubscribeNotifications is unavailable so watches poll the fake.
+func (f *Fake) SubscribeNotifications(ctx context.Context, organisationId uuid.UUID, fn func(*queries.Notification) error) error {
+	return &queries.Error{Kind: queries.ErrSubscriptionsUnavailable, Operation: "Notification", Err: queries.ErrSubscriptionsUnavailable}
+}
+
+// SubscribeLogs sends the matching log events in pages of LogsPageSize and
+// then completes, the next token is the offset of the next page.
+func (f *Fake) SubscribeLogs(ctx context.Context, input *queries.LogsSubscriptionInput, fn func(*queries.LogsOutput) error) error {
+	f.mu.Lock()
+	if err := f.call("Logs"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	f.logInputs = append(f.logInputs, *input)
+
+	matching := []*queries.Log{}
+	for _, event := range f.logs[input.Deployment_id] {
+		if event.group != input.Log_group {
+			continue
+		}
+		if input.Start_time != nil && event.log.Timestamp < *input.Start_time {
+			continue
+		}
+		if input.End_time != nil && event.log.Timestamp > *input.End_time {
+			continue
+		}
+		matching = append(matching, event.log)
+	}
+	f.mu.Unlock()
+
+	offset := 0
+	if input.Next_token != nil {
+		fmt.Sscanf(*input.Next_token, "%d", &offset)
+	}
+	if offset > len(matching) {
+		offset = len(matching)
+	}
+
+	size := f.LogsPageSize
+	if size <= 0 {
+		size = len(matching) + 1
+	}
+	for {
+		end := offset + size
+		if end > len(matching) {
+			end = len(matching)
+		}
+
+		page := &queries.LogsOutput{Logs: matching[offset:end]}
+		if end < len(matching) {
+			token := fmt.Sprintf("%d", end)
+			page.Next_token = &token
+			input.Next_token = &token
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if page.Next_token == nil {
+			return nil
+		}
+		offset = end
+	}
+}
+
+// client is the fake as seen by a command run with an organisation code.
+type client struct {
+	*Fake
+
+	organisationCode string
+}
+
+var _ queries.Queries = (*client)(nil)
+
+func (c *client) GetCurrentOrganisation(ctx context.Context) (*queries.Organisation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.call("GetCurrentOrganisation"); err != nil {
+		return nil, err
+	}
+
+	for _, org := range c.organisations {
+		if len(c.organisationCode) > 0 && strings.EqualFold(org.Code, c.organisationCode) {
+			return org, nil
+		}
+	}
+	if len(c.organisationCode) > 0 {
+		return nil, notFound("GetCurrentOrganisation", "organisation %s not found", c.organisationCode)
+	}
+	if len(c.organisations) == 1 {
+		return c.organisations[0], nil
+	}
+	return nil, config.ErrNoOrganisation
+}
+
+func contains[T comparable](items []T, item T) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRevision(revisions []*queries.RevisionItem, id uuid.UUID) bool {
+	for _, revision := range revisions {
+		if revision.Id == id {
+			return true
+		}
+	}
+	return false
+}
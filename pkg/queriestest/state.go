@@ -0,0 +1,163 @@
+package queriestest
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/google/uuid"
+)
+
+// state is the persisted form of the fake, objects shared between configs and
+// deployments are linked again when it is loaded.
+type state struct {
+	Organisations       []*queries.Organisation                `json:"organisations"`
+	Environments        map[uuid.UUID][]*queries.Environment   `json:"environments"`
+	Configs             map[uuid.UUID][]*queries.Config        `json:"configs"`
+	ApiKeys             map[uuid.UUID][]*queries.ApiKey        `json:"api_keys"`
+	ApiKeyTokens        map[uuid.UUID]string                   `json:"api_key_tokens"`
+	SecretValues        map[uuid.UUID]string                   `json:"secret_values"`
+	DeploymentRevisions []*queries.DeploymentRevision          `json:"deployment_revisions"`
+	StackResources      map[uuid.UUID][]*queries.StackResource `json:"stack_resources"`
+	History             map[uuid.UUID][]*historyEntry          `json:"history"`
+}
+
+// historyEntry is the persisted form of deployed.
+type historyEntry struct {
+	RevisionId       uuid.UUID `json:"revision_id"`
+	ConfigRevisionId uuid.UUID `json:"config_revision_id"`
+}
+
+// Save writes the state of the fake as json.
+func (f *Fake) Save(w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	revisions := []*queries.DeploymentRevision{}
+	for _, revision := range f.revisions {
+		revisions = append(revisions, f.revision(revision))
+	}
+
+	history := map[uuid.UUID][]*historyEntry{}
+	for deploymentId, entries := range f.history {
+		for _, entry := range entries {
+			history[deploymentId] = append(history[deploymentId], &historyEntry{RevisionId: entry.revisionId, ConfigRevisionId: entry.configRevisionId})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&state{
+		Organisations:       f.organisations,
+		Environments:        f.environments,
+		Configs:             f.configs,
+		ApiKeys:             f.apiKeys,
+		ApiKeyTokens:        f.apiKeyTokens,
+		SecretValues:        f.secretValues,
+		DeploymentRevisions: revisions,
+		StackResources:      f.resources,
+		History:             history,
+	})
+}
+
+// Load replaces the state of the fake with the json written by Save.
+func (f *Fake) Load(r io.Reader) error {
+	var s state
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.organisations = s.Organisations
+	f.environments = orEmpty(s.Environments)
+	f.configs = orEmpty(s.Configs)
+	f.apiKeys = orEmpty(s.ApiKeys)
+	f.apiKeyTokens = orEmpty(s.ApiKeyTokens)
+	f.secretValues = orEmpty(s.SecretValues)
+	f.deployments = map[uuid.UUID]*queries.Deployment{}
+	f.revisions = map[uuid.UUID]*queries.DeploymentRevision{}
+	f.resources = orEmpty(s.StackResources)
+	f.history = map[uuid.UUID][]*deployed{}
+
+	for organisationId, configs := range f.configs {
+		for _, config := range configs {
+			for _, secret := range config.Secrets {
+				secret.Environment = f.linkEnvironment(organisationId, secret.Environment)
+			}
+			for _, deployment := range config.Deployments {
+				deployment.Environment = f.linkEnvironment(organisationId, deployment.Environment)
+				f.deployments[deployment.Id] = deployment
+			}
+		}
+	}
+	for _, revision := range s.DeploymentRevisions {
+		if revision.Deployment == nil {
+			continue
+		}
+		// the deployment is filled in from the config's deployment when read.
+		if deployment, ok := f.deployments[revision.Deployment.Id]; ok {
+			revision.Environment = deployment.Environment
+		}
+		revision.Deployment = &queries.RevisionDeployment{Id: revision.Deployment.Id}
+		f.revisions[revision.Id] = revision
+	}
+	for deploymentId, entries := range s.History {
+		for _, entry := range entries {
+			f.history[deploymentId] = append(f.history[deploymentId], &deployed{revisionId: entry.RevisionId, configRevisionId: entry.ConfigRevisionId})
+		}
+	}
+	return nil
+}
+
+func (f *Fake) linkEnvironment(organisationId uuid.UUID, env *queries.Environment) *queries.Environment {
+	if env == nil {
+		return nil
+	}
+	if found := f.findEnvironment(organisationId, env.Id); found != nil {
+		return found
+	}
+	return env
+}
+
+// DeploymentRevisions returns every deployment revision.
+func (f *Fake) DeploymentRevisions() []*queries.DeploymentRevision {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := []*queries.DeploymentRevision{}
+	for _, revision := range f.revisions {
+		out = append(out, f.revision(revision))
+	}
+	return out
+}
+
+// SetDeploymentState moves the deployment revision and its deployment to the
+// state, it is used to simulate the deployment progressing.
+func (f *Fake) SetDeploymentState(deploymentRevisionId uuid.UUID, state queries.StackState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	revision, ok := f.revisions[deploymentRevisionId]
+	if !ok {
+		return notFound("SetDeploymentState", "deployment revision %s not found", deploymentRevisionId)
+	}
+
+	now := time.Now()
+	revision.State = state
+	revision.Updated_at = now
+	if deployment, ok := f.deployments[revision.Deployment.Id]; ok {
+		deployment.State = state
+		deployment.Updated_at = now
+	}
+	return nil
+}
+
+func orEmpty[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return map[K]V{}
+	}
+	return m
+}
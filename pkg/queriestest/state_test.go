@@ -0,0 +1,57 @@
+package queriestest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/google/uuid"
+)
+
+func Test_SaveLoad_History(t *testing.T) {
+	ctx := context.Background()
+
+	fake := queriestest.New()
+	org := fake.AddOrganisation("noops", "NoOps")
+	env := fake.AddEnvironment(org.Id, "dev")
+	config := fake.AddConfig(org.Id, "api", queries.ConfigClassCompute)
+	first := fake.AddRevision(config, "1.0.0")
+	second := fake.AddRevision(config, "1.1.0")
+
+	deploymentId := uuid.New()
+	for _, revision := range []*queries.RevisionItem{first, second} {
+		if _, err := fake.NewDeployment(ctx, org.Id, deploymentId, env.Id, config.Id, revision.Id, uuid.New()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := fake.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := queriestest.New()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	deployments, err := loaded.GetDeployments(ctx, org.Id, config.Id, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, previous := map[string]bool{}, map[string]bool{}
+	for _, version := range deployments.Versions {
+		for _, item := range version.Items {
+			if !item.Exists || item.Environment == nil || item.Environment.Id != env.Id {
+				continue
+			}
+			active[version.Version_number] = item.Active
+			previous[version.Version_number] = item.Previous
+		}
+	}
+	if !active["1.1.0"] || !previous["1.0.0"] {
+		t.Fatalf("expected 1.1.0 active and 1.0.0 previous after loading, got active %v previous %v", active, previous)
+	}
+}
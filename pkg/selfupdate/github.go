@@ -11,9 +11,10 @@ import (
 )
 
 type Github struct {
-	api   *github.Client
-	owner string
-	repo  string
+	api        *github.Client
+	httpClient *http.Client
+	owner      string
+	repo       string
 }
 
 func (g *Github) ListReleases(ctx context.Context) ([]*github.RepositoryRelease, error) {
@@ -28,15 +29,16 @@ func (g *Github) ListReleases(ctx context.Context) ([]*github.RepositoryRelease,
 }
 
 func (g *Github) DownloadReleaseAsset(ctx context.Context, assetId int64) (io.ReadCloser, error) {
-	client := http.DefaultClient
-	asset, _, err := g.api.Repositories.DownloadReleaseAsset(ctx, g.owner, g.repo, assetId, client)
+	asset, _, err := g.api.Repositories.DownloadReleaseAsset(ctx, g.owner, g.repo, assetId, g.httpClient)
 	if err != nil {
 		return nil, err
 	}
 	return asset, nil
 }
 
-func NewGithub(repositorySlug string) (*Github, error) {
+// NewGithub creates a client for the releases of the repository, a nil
+// httpClient uses http.DefaultClient.
+func NewGithub(repositorySlug string, httpClient *http.Client) (*Github, error) {
 	out := strings.Split(repositorySlug, "/")
 	if len(out) != 2 {
 		return nil, errors.New("invalid repository slug")
@@ -45,8 +47,13 @@ func NewGithub(repositorySlug string) (*Github, error) {
 	owner := out[0]
 	repo := out[1]
 
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
 	return &Github{
-		api: github.NewClient(nil),
+		api:        github.NewClient(httpClient),
+		httpClient: httpClient,
 
 		owner: owner,
 		repo:  repo,
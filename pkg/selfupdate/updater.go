@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -138,8 +139,8 @@ func (u *updater) UpdateTo(ctx context.Context, asset *ReleaseAsset, exePath str
 	return nil
 }
 
-func NewUpdater(repositorySlug string, prerelease bool, draft bool) (Updater, error) {
-	gh, err := NewGithub(repositorySlug)
+func NewUpdater(repositorySlug string, prerelease bool, draft bool, httpClient *http.Client) (Updater, error) {
+	gh, err := NewGithub(repositorySlug, httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -22,10 +24,18 @@ func BindBoolFlag(cmd *cobra.Command, name string, description string, value boo
 	cmd.Flags().Bool(name, value, description)
 }
 
+func BindBoolPFlag(cmd *cobra.Command, name string, shorthand string, description string, value bool) {
+	cmd.Flags().BoolP(name, shorthand, value, description)
+}
+
 func BindIntFlag(cmd *cobra.Command, name string, description string, value int) {
 	cmd.Flags().Int(name, value, description)
 }
 
+func BindDurationFlag(cmd *cobra.Command, name string, description string, value time.Duration) {
+	cmd.Flags().Duration(name, value, description)
+}
+
 func BindPreRun(cmd *cobra.Command, args []string) {
 	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
 		viper.BindPFlag("command."+flag.Name, flag)
@@ -38,3 +48,17 @@ func BindStringPersistentFlag(cmd *cobra.Command, name, description string, valu
 
 	viper.BindPFlag("global."+name, flag)
 }
+
+func BindBoolPersistentFlag(cmd *cobra.Command, name, description string, value bool) {
+	cmd.PersistentFlags().Bool(name, value, description)
+	flag := cmd.PersistentFlags().Lookup(name)
+
+	viper.BindPFlag("global."+name, flag)
+}
+
+func BindDurationPersistentFlag(cmd *cobra.Command, name, description string, value time.Duration) {
+	cmd.PersistentFlags().Duration(name, value, description)
+	flag := cmd.PersistentFlags().Lookup(name)
+
+	viper.BindPFlag("global."+name, flag)
+}
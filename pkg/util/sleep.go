@@ -0,0 +1,20 @@
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep waits for the duration, it returns early with the context's error
+// when the command is interrupted or times out.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
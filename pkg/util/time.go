@@ -0,0 +1,22 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseTime parses a duration before now, e.g. 15m, or an RFC3339 time such
+// as the --since and --until flags take.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("invalid time %s, the duration must be positive", value)
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, should be a duration such as 15m or a time such as 2024-03-01T10:00:00Z", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,30 @@
+package util_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/util"
+)
+
+func Test_ParseTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"15m":                  now.Add(-15 * time.Minute),
+		"1h30m":                now.Add(-90 * time.Minute),
+		"2024-03-01T09:00:00Z": time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+	for value, expected := range cases {
+		out, err := util.ParseTime(value, now)
+		if err != nil || !out.Equal(expected) {
+			t.Fatalf("expected %s for %s, got %s %v", expected, value, out, err)
+		}
+	}
+
+	for _, value := range []string{"-5m", "yesterday"} {
+		if _, err := util.ParseTime(value, now); err == nil {
+			t.Fatalf("expected an error for %s", value)
+		}
+	}
+}
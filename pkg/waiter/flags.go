@@ -0,0 +1,42 @@
+package waiter
+
+import (
+	"time"
+
+	"github.com/getnoops/ops/pkg/config"
+	"github.com/getnoops/ops/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// Flags are the options of commands which can wait, they are embedded in the
+// command's config with `mapstructure:",squash"`.
+type Flags struct {
+	Watch        bool          `mapstructure:"watch" default:"false"`
+	WaitTimeout  time.Duration `mapstructure:"wait-timeout"`
+	WaitInterval time.Duration `mapstructure:"wait-interval"`
+}
+
+// BindFlags adds --watch, --wait-timeout and --wait-interval to the command.
+func BindFlags(cmd *cobra.Command, watch string) {
+	util.BindBoolFlag(cmd, "watch", watch, false)
+	util.BindDurationFlag(cmd, "wait-timeout", "Stop watching after this long, defaults to Wait.Timeout", 0)
+	util.BindDurationFlag(cmd, "wait-interval", "How often to check the state at first, defaults to Wait.Interval", 0)
+}
+
+// Options applies the flags over the wait settings, events go to the renderer.
+func (f Flags) Options(wait config.WaitConfig, renderer Renderer) Options {
+	out := Options{
+		Interval:    wait.Interval,
+		Backoff:     wait.Backoff,
+		MaxInterval: wait.MaxInterval,
+		Timeout:     wait.Timeout,
+		OnEvent:     renderer.Event,
+	}
+	if f.WaitInterval > 0 {
+		out.Interval = f.WaitInterval
+	}
+	if f.WaitTimeout > 0 {
+		out.Timeout = f.WaitTimeout
+	}
+	return out
+}
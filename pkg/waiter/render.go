@@ -0,0 +1,165 @@
+package waiter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Renderer shows the progress of a wait.
+type Renderer interface {
+	Event(event Event)
+	// Done ends the output, e.g. stops the spinner.
+	Done()
+}
+
+// NewRenderer picks how progress is written for the output format, json
+// lines for json, a spinner on a terminal and plain lines otherwise.
+func NewRenderer(format string, w io.Writer) Renderer {
+	if strings.ToLower(format) == "json" {
+		return NewJSONRenderer(w)
+	}
+	if file, ok := w.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		return NewSpinnerRenderer(w)
+	}
+	return NewPlainRenderer(w)
+}
+
+type plainRenderer struct {
+	w io.Writer
+}
+
+// NewPlainRenderer writes a line whenever the state changes.
+func NewPlainRenderer(w io.Writer) Renderer {
+	return &plainRenderer{w: w}
+}
+
+func (r *plainRenderer) Event(event Event) {
+	if !event.Changed {
+		return
+	}
+	fmt.Fprintln(r.w, describe(event))
+}
+
+func (r *plainRenderer) Done() {}
+
+type jsonEvent struct {
+	Resource string  `json:"resource"`
+	State    string  `json:"state"`
+	Outcome  Outcome `json:"outcome"`
+	Attempt  int     `json:"attempt"`
+	Elapsed  float64 `json:"elapsed_seconds"`
+}
+
+type jsonRenderer struct {
+	encoder *json.Encoder
+}
+
+// NewJSONRenderer writes every event as a json line.
+func NewJSONRenderer(w io.Writer) Renderer {
+	return &jsonRenderer{encoder: json.NewEncoder(w)}
+}
+
+func (r *jsonRenderer) Event(event Event) {
+	r.encoder.Encode(&jsonEvent{
+		Resource: event.Resource,
+		State:    event.State,
+		Outcome:  event.Outcome,
+		Attempt:  event.Attempt,
+		Elapsed:  event.Elapsed.Seconds(),
+	})
+}
+
+func (r *jsonRenderer) Done() {}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+type spinnerRenderer struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	last    *Event
+	start   time.Time
+	frame   int
+	stopped bool
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewSpinnerRenderer redraws a single line with the current state and the
+// time waited, the terminal state is left as a plain line.
+func NewSpinnerRenderer(w io.Writer) Renderer {
+	r := &spinnerRenderer{
+		w:     w,
+		start: time.Now(),
+		done:  make(chan struct{}),
+	}
+	go r.spin()
+	return r
+}
+
+func (r *spinnerRenderer) spin() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.draw()
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *spinnerRenderer) draw() {
+	if r.last == nil || r.stopped {
+		return
+	}
+	r.frame = (r.frame + 1) % len(spinnerFrames)
+	elapsed := time.Since(r.start).Round(time.Second)
+	fmt.Fprintf(r.w, "\r\033[K%s %s %s (%s)", spinnerFrames[r.frame], r.last.Resource, r.last.State, elapsed)
+}
+
+func (r *spinnerRenderer) Event(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.Outcome != Pending {
+		fmt.Fprintf(r.w, "\r\033[K%s\n", describe(event))
+		r.last = nil
+		return
+	}
+	r.last = &event
+	r.draw()
+}
+
+func (r *spinnerRenderer) Done() {
+	r.once.Do(func() {
+		close(r.done)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.stopped = true
+		if r.last != nil {
+			fmt.Fprint(r.w, "\r\033[K")
+			r.last = nil
+		}
+	})
+}
+
+// describe is the line written for an event, e.g. "Deployment still creating".
+func describe(event Event) string {
+	if event.Outcome == Pending {
+		return fmt.Sprintf("%s still %s", event.Resource, event.State)
+	}
+	return fmt.Sprintf("%s %s", event.Resource, event.State)
+}
@@ -0,0 +1,105 @@
+package waiter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/google/uuid"
+)
+
+// DeploymentRevision waits for a deployment revision to be created or updated.
+func DeploymentRevision(ctx context.Context, q queries.Queries, organisationId uuid.UUID, id uuid.UUID, opts Options) (queries.StackState, error) {
+	// notifications are about the deployment, not the revision.
+	revision, err := q.GetDeploymentRevision(ctx, organisationId, id)
+	if err != nil {
+		return "", err
+	}
+	if revision.Deployment != nil {
+		var stop func()
+		opts, stop = subscribe(ctx, q, organisationId, revision.Deployment.Id, opts)
+		defer stop()
+	}
+
+	return Wait(ctx, "Deployment", StackStates, opts, func(ctx context.Context) (queries.StackState, error) {
+		revision, err := q.GetDeploymentRevision(ctx, organisationId, id)
+		if err != nil {
+			return "", err
+		}
+		return revision.State, nil
+	})
+}
+
+// Deployment waits for a deployment to reach a terminal state in the table,
+// e.g. DeleteStackStates after deleting it.
+func Deployment(ctx context.Context, q queries.Queries, organisationId uuid.UUID, id uuid.UUID, states States[queries.StackState], opts Options) (queries.StackState, error) {
+	opts, stop := subscribe(ctx, q, organisationId, id, opts)
+	defer stop()
+
+	return Wait(ctx, "Deployment", states, opts, func(ctx context.Context) (queries.StackState, error) {
+		deployment, err := q.GetDeployment(ctx, organisationId, id)
+		if err != nil {
+			return "", err
+		}
+		return deployment.State, nil
+	})
+}
+
+// Secret waits for a secret of the config to be created.
+func Secret(ctx context.Context, q queries.Queries, organisationId uuid.UUID, configCode string, id uuid.UUID, opts Options) (queries.StackState, error) {
+	return Wait(ctx, "Secret", StackStates, opts, func(ctx context.Context) (queries.StackState, error) {
+		config, err := q.GetConfig(ctx, organisationId, configCode)
+		if err != nil {
+			return "", err
+		}
+		for _, secret := range config.Secrets {
+			if secret.Id == id {
+				return secret.State, nil
+			}
+		}
+		return "", fmt.Errorf("secret %s: %w", id, queries.ErrNotFound)
+	})
+}
+
+// ContainerRepository waits for a container repository of the config to be
+// created.
+func ContainerRepository(ctx context.Context, q queries.Queries, organisationId uuid.UUID, configCode string, id uuid.UUID, opts Options) (queries.StackState, error) {
+	return Wait(ctx, "Container repository", StackStates, opts, func(ctx context.Context) (queries.StackState, error) {
+		config, err := q.GetConfig(ctx, organisationId, configCode)
+		if err != nil {
+			return "", err
+		}
+		for _, repository := range config.ContainerRepositories {
+			if repository.Id == id {
+				return repository.State, nil
+			}
+		}
+		return "", fmt.Errorf("container repository %s: %w", id, queries.ErrNotFound)
+	})
+}
+
+// subscribe streams the organisation's notifications while waiting on a
+// deployment and polls as soon as one is about the deployment with the id. Any subscription error,
+// e.g. when websockets are blocked, leaves the wait polling on its interval.
+// stop ends the subscription.
+func subscribe(ctx context.Context, q queries.Queries, organisationId uuid.UUID, id uuid.UUID, opts Options) (Options, func()) {
+	if opts.Notify != nil {
+		return opts, func() {}
+	}
+
+	notify := make(chan struct{}, 1)
+	opts.Notify = notify
+
+	ctx, stop := context.WithCancel(ctx)
+	go q.SubscribeNotifications(ctx, organisationId, func(notification *queries.Notification) error {
+		if notification.Aggregate_id != id {
+			return nil
+		}
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	return opts, stop
+}
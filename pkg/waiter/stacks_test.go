@@ -0,0 +1,81 @@
+package waiter_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/queriestest"
+	"github.com/getnoops/ops/pkg/waiter"
+	"github.com/google/uuid"
+)
+
+// notifier sends the notifications of the subscription from a channel, the
+// deployment revision is creating until it has been polled twice.
+type notifier struct {
+	queries.Queries
+	deploymentId  uuid.UUID
+	polls         atomic.Int32
+	notifications chan *queries.Notification
+}
+
+func (n *notifier) GetDeploymentRevision(ctx context.Context, organisationId uuid.UUID, id uuid.UUID) (*queries.DeploymentRevision, error) {
+	state := queries.StackStateCreating
+	if n.polls.Add(1) > 2 {
+		state = queries.StackStateCreated
+	}
+	return &queries.DeploymentRevision{Id: id, State: state, Deployment: &queries.RevisionDeployment{Id: n.deploymentId}}, nil
+}
+
+func (n *notifier) SubscribeNotifications(ctx context.Context, organisationId uuid.UUID, fn func(*queries.Notification) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-n.notifications:
+			if err := fn(notification); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func Test_DeploymentRevision_Notify(t *testing.T) {
+	q := &notifier{
+		Queries:       queriestest.New().Client("noops"),
+		deploymentId:  uuid.New(),
+		notifications: make(chan *queries.Notification),
+	}
+	revisionId := uuid.New()
+
+	done := make(chan error, 1)
+	go func() {
+		opts := waiter.Options{Interval: time.Hour, Timeout: 5 * time.Second}
+		_, err := waiter.DeploymentRevision(context.Background(), q, uuid.New(), revisionId, opts)
+		done <- err
+	}()
+
+	// the lookup of the deployment and the first poll.
+	deadline := time.Now().Add(5 * time.Second)
+	for q.polls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 polls, got %d", q.polls.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// other deployments in the organisation and the revision id don't poll.
+	q.notifications <- &queries.Notification{Aggregate_id: uuid.New(), Aggregate_type: "deployment"}
+	q.notifications <- &queries.Notification{Aggregate_id: revisionId, Aggregate_type: "deployment"}
+	time.Sleep(50 * time.Millisecond)
+	if polls := q.polls.Load(); polls != 2 {
+		t.Fatalf("expected no poll for other notifications, got %d polls", polls)
+	}
+
+	q.notifications <- &queries.Notification{Aggregate_id: q.deploymentId, Aggregate_type: "deployment"}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
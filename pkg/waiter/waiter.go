@@ -0,0 +1,243 @@
+package waiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/util"
+)
+
+// Outcome is what a state means for the thing being waited on.
+type Outcome string
+
+const (
+	// Pending states are polled again.
+	Pending Outcome = "pending"
+	// Succeeded states stop the wait.
+	Succeeded Outcome = "succeeded"
+	// Failed states stop the wait with an *Error.
+	Failed Outcome = "failed"
+)
+
+// ErrFailed is matched by the *Error returned when a failed state is reached.
+var ErrFailed = errors.New("failed")
+
+// States maps each state to its outcome, states missing from the table are
+// treated as pending.
+type States[S ~string] map[S]Outcome
+
+// StackStates is used when waiting for a stack to be created or updated.
+var StackStates = States[queries.StackState]{
+	queries.StackStateNew:        Pending,
+	queries.StackStateCreating:   Pending,
+	queries.StackStateCreated:    Succeeded,
+	queries.StackStateUpdating:   Pending,
+	queries.StackStateUpdated:    Succeeded,
+	queries.StackStateFailed:     Failed,
+	queries.StackStateDeleting:   Pending,
+	queries.StackStateDeleted:    Failed,
+	queries.StackStateCancelling: Pending,
+}
+
+// DeleteStackStates is used when waiting for a stack to be deleted, it may
+// still be created until the delete is picked up.
+var DeleteStackStates = States[queries.StackState]{
+	queries.StackStateNew:        Pending,
+	queries.StackStateCreating:   Pending,
+	queries.StackStateCreated:    Pending,
+	queries.StackStateUpdating:   Pending,
+	queries.StackStateUpdated:    Pending,
+	queries.StackStateFailed:     Failed,
+	queries.StackStateDeleting:   Pending,
+	queries.StackStateDeleted:    Succeeded,
+	queries.StackStateCancelling: Pending,
+}
+
+// CancelStackStates is used when waiting for a cancelled stack to settle,
+// any state it is left in ends the wait.
+var CancelStackStates = States[queries.StackState]{
+	queries.StackStateNew:        Pending,
+	queries.StackStateCreating:   Pending,
+	queries.StackStateCreated:    Succeeded,
+	queries.StackStateUpdating:   Pending,
+	queries.StackStateUpdated:    Succeeded,
+	queries.StackStateFailed:     Succeeded,
+	queries.StackStateDeleting:   Pending,
+	queries.StackStateDeleted:    Succeeded,
+	queries.StackStateCancelling: Pending,
+}
+
+// Event is emitted after every poll.
+type Event struct {
+	// Resource names what is waited on, e.g. "Deployment".
+	Resource string
+	State    string
+	Outcome  Outcome
+	Attempt  int
+	Elapsed  time.Duration
+	// Changed is set when the state differs from the previous poll.
+	Changed bool
+}
+
+// Options configures how often the state is polled and for how long.
+type Options struct {
+	// Interval is the wait before the second poll.
+	Interval time.Duration
+	// Backoff multiplies the interval after each poll that stays pending.
+	Backoff float64
+	// MaxInterval caps the interval.
+	MaxInterval time.Duration
+	// Timeout stops the wait, 0 waits until the context is done.
+	Timeout time.Duration
+	// OnEvent is called with every event, e.g. a Renderer's Event.
+	OnEvent func(Event)
+	// Notify polls straight away when it receives, e.g. when a subscription
+	// reports a change, the interval is kept as the fallback.
+	Notify <-chan struct{}
+}
+
+// Error is returned when the wait ends in a failed state.
+type Error struct {
+	Resource string
+	State    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s", lower(e.Resource), e.State)
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrFailed
+}
+
+// TimeoutError is returned when Options.Timeout passes before the state is
+// terminal, it wraps context.DeadlineExceeded.
+type TimeoutError struct {
+	Resource string
+	State    string
+	Timeout  time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for %s, last state %s", e.Timeout, lower(e.Resource), e.State)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Wait polls until the state is succeeded or failed, the context is done or
+// the timeout passes. The last state is returned with any error.
+func Wait[S ~string](ctx context.Context, resource string, states States[S], opts Options, poll func(ctx context.Context) (S, error)) (S, error) {
+	opts = opts.withDefaults()
+
+	waitCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	interval := opts.Interval
+
+	var last S
+	for attempt := 1; ; attempt++ {
+		state, err := poll(waitCtx)
+		if err != nil {
+			if timedOut(ctx, waitCtx) {
+				return last, &TimeoutError{Resource: resource, State: string(last), Timeout: opts.Timeout}
+			}
+			return last, err
+		}
+
+		outcome, ok := states[state]
+		if !ok {
+			outcome = Pending
+		}
+
+		if opts.OnEvent != nil {
+			opts.OnEvent(Event{
+				Resource: resource,
+				State:    string(state),
+				Outcome:  outcome,
+				Attempt:  attempt,
+				Elapsed:  time.Since(start),
+				Changed:  attempt == 1 || state != last,
+			})
+		}
+		last = state
+
+		switch outcome {
+		case Succeeded:
+			return state, nil
+		case Failed:
+			return state, &Error{Resource: resource, State: string(state)}
+		}
+
+		if err := sleep(waitCtx, interval, opts.Notify); err != nil {
+			if timedOut(ctx, waitCtx) {
+				return last, &TimeoutError{Resource: resource, State: string(last), Timeout: opts.Timeout}
+			}
+			return last, err
+		}
+		interval = opts.next(interval)
+	}
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = 2 * time.Second
+	}
+	if o.Backoff < 1 {
+		o.Backoff = 1
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	return o
+}
+
+func (o Options) next(interval time.Duration) time.Duration {
+	next := time.Duration(float64(interval) * o.Backoff)
+	if next > o.MaxInterval {
+		return o.MaxInterval
+	}
+	return next
+}
+
+// sleep waits for the interval or until notify receives.
+func sleep(ctx context.Context, interval time.Duration, notify <-chan struct{}) error {
+	if notify == nil {
+		return util.Sleep(ctx, interval)
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	case <-notify:
+	}
+	return nil
+}
+
+// timedOut is true when the wait's own timeout ended it rather than the
+// command's context.
+func timedOut(ctx context.Context, waitCtx context.Context) bool {
+	return ctx.Err() == nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded)
+}
+
+// lower lower cases the first letter so the resource reads in a sentence.
+func lower(resource string) string {
+	if len(resource) == 0 {
+		return resource
+	}
+	return strings.ToLower(resource[:1]) + resource[1:]
+}
@@ -0,0 +1,197 @@
+package waiter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getnoops/ops/pkg/queries"
+	"github.com/getnoops/ops/pkg/waiter"
+)
+
+// poller returns the states in order and then the last one again.
+func poller(states ...queries.StackState) (func(context.Context) (queries.StackState, error), *[]time.Time) {
+	polls := &[]time.Time{}
+	return func(ctx context.Context) (queries.StackState, error) {
+		*polls = append(*polls, time.Now())
+		i := len(*polls) - 1
+		if i >= len(states) {
+			i = len(states) - 1
+		}
+		return states[i], nil
+	}, polls
+}
+
+func Test_Wait(t *testing.T) {
+	cases := []struct {
+		name   string
+		states []queries.StackState
+		table  waiter.States[queries.StackState]
+		last   queries.StackState
+		err    error
+	}{
+		{
+			name:   "created",
+			states: []queries.StackState{queries.StackStateNew, queries.StackStateCreating, queries.StackStateCreated},
+			table:  waiter.StackStates,
+			last:   queries.StackStateCreated,
+		},
+		{
+			name:   "failed",
+			states: []queries.StackState{queries.StackStateUpdating, queries.StackStateCancelling, queries.StackStateFailed},
+			table:  waiter.StackStates,
+			last:   queries.StackStateFailed,
+			err:    waiter.ErrFailed,
+		},
+		{
+			name:   "deleted",
+			states: []queries.StackState{queries.StackStateCreated, queries.StackStateDeleting, queries.StackStateDeleted},
+			table:  waiter.DeleteStackStates,
+			last:   queries.StackStateDeleted,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			poll, polls := poller(c.states...)
+
+			events := []waiter.Event{}
+			opts := waiter.Options{
+				Interval: time.Millisecond,
+				OnEvent:  func(event waiter.Event) { events = append(events, event) },
+			}
+
+			last, err := waiter.Wait(context.Background(), "Deployment", c.table, opts, poll)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected %v, got %v", c.err, err)
+			}
+			if last != c.last {
+				t.Fatalf("expected %s, got %s", c.last, last)
+			}
+			if len(*polls) != len(c.states) || len(events) != len(c.states) {
+				t.Fatalf("expected %d polls, got %d with %d events", len(c.states), len(*polls), len(events))
+			}
+			if events[len(events)-1].Outcome == waiter.Pending {
+				t.Fatalf("expected the last event to be terminal, got %+v", events[len(events)-1])
+			}
+		})
+	}
+}
+
+func Test_Wait_Backoff(t *testing.T) {
+	poll, polls := poller(queries.StackStateCreating, queries.StackStateCreating, queries.StackStateCreating, queries.StackStateCreating, queries.StackStateCreated)
+
+	opts := waiter.Options{
+		Interval:    10 * time.Millisecond,
+		Backoff:     2,
+		MaxInterval: 30 * time.Millisecond,
+	}
+	if _, err := waiter.Wait(context.Background(), "Deployment", waiter.StackStates, opts, poll); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10ms, 20ms and then capped at 30ms.
+	minimums := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}
+	for i, minimum := range minimums {
+		if waited := (*polls)[i+1].Sub((*polls)[i]); waited < minimum {
+			t.Fatalf("expected poll %d to wait at least %s, waited %s", i+1, minimum, waited)
+		}
+	}
+}
+
+func Test_Wait_Timeout(t *testing.T) {
+	poll, _ := poller(queries.StackStateCreating)
+
+	opts := waiter.Options{Interval: 5 * time.Millisecond, Timeout: 20 * time.Millisecond}
+	last, err := waiter.Wait(context.Background(), "Deployment", waiter.StackStates, opts, poll)
+
+	var timeout *waiter.TimeoutError
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if last != queries.StackStateCreating || err.Error() != "timed out after 20ms waiting for deployment, last state creating" {
+		t.Fatalf("unexpected timeout %s %v", last, err)
+	}
+
+	// cancelling the command is reported as is.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := waiter.Wait(ctx, "Deployment", waiter.StackStates, opts, poll); !errors.Is(err, context.Canceled) || errors.As(err, &timeout) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func Test_Wait_Notify(t *testing.T) {
+	poll, polls := poller(queries.StackStateCreating, queries.StackStateCreated)
+
+	notify := make(chan struct{}, 1)
+	notify <- struct{}{}
+
+	// the interval is never waited, the notification polls straight away.
+	opts := waiter.Options{Interval: time.Hour, Timeout: 5 * time.Second, Notify: notify}
+	last, err := waiter.Wait(context.Background(), "Deployment", waiter.StackStates, opts, poll)
+	if err != nil || last != queries.StackStateCreated || len(*polls) != 2 {
+		t.Fatalf("expected created after 2 polls, got %s after %d %v", last, len(*polls), err)
+	}
+}
+
+func Test_Wait_PollError(t *testing.T) {
+	expected := &queries.Error{Kind: queries.ErrNotFound}
+	_, err := waiter.Wait(context.Background(), "Deployment", waiter.StackStates, waiter.Options{}, func(ctx context.Context) (queries.StackState, error) {
+		return "", expected
+	})
+	if !errors.Is(err, queries.ErrNotFound) {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+}
+
+func Test_Renderers(t *testing.T) {
+	events := []waiter.Event{
+		{Resource: "Deployment", State: "creating", Outcome: waiter.Pending, Attempt: 1, Changed: true},
+		{Resource: "Deployment", State: "creating", Outcome: waiter.Pending, Attempt: 2},
+		{Resource: "Deployment", State: "created", Outcome: waiter.Succeeded, Attempt: 3, Elapsed: 1500 * time.Millisecond, Changed: true},
+	}
+
+	plain := &bytes.Buffer{}
+	renderer := waiter.NewRenderer("table", plain)
+	for _, event := range events {
+		renderer.Event(event)
+	}
+	renderer.Done()
+	if plain.String() != "Deployment still creating\nDeployment created\n" {
+		t.Fatalf("unexpected plain output %q", plain.String())
+	}
+
+	out := &bytes.Buffer{}
+	renderer = waiter.NewRenderer("json", out)
+	for _, event := range events {
+		renderer.Event(event)
+	}
+	renderer.Done()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(events) {
+		t.Fatalf("expected a line per event, got %q", out.String())
+	}
+	last := map[string]any{}
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last["state"] != "created" || last["outcome"] != "succeeded" || last["attempt"] != 3.0 || last["elapsed_seconds"] != 1.5 {
+		t.Fatalf("unexpected json event %v", last)
+	}
+
+	spinner := &bytes.Buffer{}
+	renderer = waiter.NewSpinnerRenderer(spinner)
+	for _, event := range events {
+		renderer.Event(event)
+	}
+	renderer.Done()
+	if !strings.Contains(spinner.String(), "Deployment creating") || !strings.HasSuffix(spinner.String(), "\r\033[KDeployment created\n") {
+		t.Fatalf("unexpected spinner output %q", spinner.String())
+	}
+}